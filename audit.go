@@ -0,0 +1,213 @@
+package cblib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cblib/internal/audit"
+	"github.com/clearblade/cblib/internal/reportout"
+	"github.com/clearblade/cblib/models/roles"
+)
+
+var (
+	auditPull   bool
+	auditFormat string
+	auditOutput string
+	auditKind   string
+	auditAsset  string
+	auditVerbs  string
+)
+
+func init() {
+
+	usage :=
+		`
+	Audit the permissions of the system. 'audit permissions' reads the local roles,
+	the roles of users (users/roles) and the roles of devices (devices/roles) and
+	reports, for each asset, which roles can access it and who holds those roles.
+	Roles granting writes to Anonymous or Authenticated, or letting a role edit
+	roles, are listed as over-privileged.
+	`
+
+	example :=
+		`
+	cb-cli audit permissions										# Permission matrix and over-privileged roles
+	cb-cli audit permissions -pull									# Pull roles, users and devices first
+	cb-cli audit permissions -kind=collections -asset=orders -verbs=create,update,delete	# Who can write to the orders collection?
+	cb-cli audit permissions -kind=services -asset=closeOrder -verbs=read	# Which roles can execute the closeOrder service?
+	cb-cli audit permissions -format=csv -output=permissions.csv		# Export the matrix as CSV
+	`
+
+	auditCommand := &SubCommand{
+		name:      "audit",
+		usage:     usage,
+		needsAuth: false,
+		run:       doAudit,
+		example:   example,
+	}
+
+	auditCommand.flags.BoolVar(&auditPull, "pull", false, "Pull roles, users and devices from the platform before auditing")
+	auditCommand.flags.StringVar(&auditFormat, "format", audit.FormatText, "Report format: text, json or csv")
+	auditCommand.flags.StringVar(&auditOutput, "output", "", "File to write the report to. Defaults to stdout")
+	auditCommand.flags.StringVar(&auditKind, "kind", "", "Only report permissions of this kind, e.g. collections, services, devices")
+	auditCommand.flags.StringVar(&auditAsset, "asset", "", "Only report permissions on the asset with this name (requires -kind)")
+	auditCommand.flags.StringVar(&auditVerbs, "verbs", "", "Comma separated verbs (read, create, update, delete). Only report permissions granting any of them")
+
+	AddCommand("audit", auditCommand)
+}
+
+func doAudit(cmd *SubCommand, client *cb.DevClient, args ...string) error {
+	if len(args) == 0 || args[0] != "permissions" {
+		return fmt.Errorf("usage: cb-cli audit permissions [options]")
+	}
+
+	// flags given after the 'permissions' keyword
+	err := cmd.flags.Parse(args[1:])
+	if err != nil {
+		return err
+	}
+	if cmd.flags.NArg() != 0 {
+		return fmt.Errorf("audit permissions takes no arguments; only options")
+	}
+
+	if auditKind != "" {
+		if _, ok := roles.FindKind(auditKind); !ok {
+			return fmt.Errorf("unknown permission kind '%s'", auditKind)
+		}
+	} else if auditAsset != "" {
+		return fmt.Errorf("-asset requires -kind")
+	}
+
+	if err := reportout.CheckFormat(auditFormat, audit.Formats); err != nil {
+		return err
+	}
+
+	var level roles.Level
+	if auditVerbs != "" {
+		level, err = roles.LevelFromVerbs(strings.Split(auditVerbs, ","))
+		if err != nil {
+			return err
+		}
+	}
+
+	SetRootDir(".")
+
+	if auditPull {
+		err = pullForAudit(client)
+		if err != nil {
+			return err
+		}
+	}
+
+	input, err := readAuditInput()
+	if err != nil {
+		return err
+	}
+
+	report, err := audit.Build(input)
+	if err != nil {
+		return err
+	}
+	report = report.Filter(auditKind, auditAsset, level)
+
+	var out io.Writer = os.Stdout
+	if auditOutput != "" {
+		f, err := os.Create(auditOutput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return report.Write(out, auditFormat)
+}
+
+func pullForAudit(client *cb.DevClient) error {
+	if client == nil {
+		return fmt.Errorf("-pull must be run inside a system directory")
+	}
+
+	systemInfo, err := getSysMeta()
+	if err != nil {
+		return err
+	}
+
+	if err := setupDirectoryStructure(); err != nil {
+		return err
+	}
+
+	client, err = checkIfTokenHasExpired(client, systemInfo.Key)
+	if err != nil {
+		return fmt.Errorf("Re-auth failed: %s\n", err)
+	}
+
+	_, err = pullAssets(systemInfo, client, AffectedAssets{
+		AllRoles:    true,
+		AllUsers:    true,
+		AllDevices:  true,
+		ExportUsers: true,
+	})
+	return err
+}
+
+func readAuditInput() (audit.Input, error) {
+	input := audit.Input{
+		UserRoles:   map[string][]string{},
+		DeviceRoles: map[string][]string{},
+	}
+
+	rawRoles, err := getRoles()
+	if err != nil {
+		return input, err
+	}
+	for _, raw := range rawRoles {
+		role, err := roles.RoleFromGetShape(raw)
+		if err != nil {
+			return input, fmt.Errorf("could not read role '%v': %s", raw["Name"], err)
+		}
+		input.Roles = append(input.Roles, role)
+	}
+
+	input.UserRoles, err = readRoleAssignments(usersRolesDir, getUserRoles)
+	if err != nil {
+		return input, err
+	}
+
+	input.DeviceRoles, err = readRoleAssignments(devicesRolesDir, getDeviceRoles)
+	if err != nil {
+		return input, err
+	}
+
+	return input, nil
+}
+
+// readRoleAssignments reads the <name>.json role lists of the given directory.
+// A missing directory means nothing was pulled, so nobody holds a role.
+func readRoleAssignments(dir string, read func(string) ([]string, error)) (map[string][]string, error) {
+	rtn := map[string][]string{}
+
+	fileNames, err := getFileList(dir, []string{})
+	if os.IsNotExist(err) {
+		return rtn, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, fileName := range fileNames {
+		if !strings.HasSuffix(fileName, ".json") {
+			continue
+		}
+		name := strings.TrimSuffix(fileName, ".json")
+		assigned, err := read(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not read roles of '%s': %s", name, err)
+		}
+		rtn[name] = assigned
+	}
+
+	return rtn, nil
+}
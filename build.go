@@ -0,0 +1,88 @@
+package cblib
+
+import (
+	"fmt"
+	"strings"
+
+	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cblib/internal/build"
+)
+
+var (
+	buildForce   bool
+	buildService string
+)
+
+func init() {
+
+	usage :=
+		`
+	Build the services kept under src/. The entry point of each service,
+	src/services/<name>/<name>.ts (or .js, index.ts, index.js), is bundled into
+	code/services/<name>/<name>.js with its source map. Services whose sources didn't
+	change since the last build are skipped. The bundler command defaults to esbuild and
+	can be set in src/build.json, e.g. {"command": ["npx", "webpack", "--entry", "{entry}", ...]}.
+	push builds the services it pushes first.
+	`
+
+	example :=
+		`
+	cb-cli build							# Build every service with sources in src/services
+	cb-cli build -service=Service1			# Build one service
+	cb-cli build -force						# Rebuild services even if their sources didn't change
+	`
+
+	buildCommand := &SubCommand{
+		name:      "build",
+		usage:     usage,
+		needsAuth: false,
+		run:       doBuild,
+		example:   example,
+	}
+
+	buildCommand.flags.BoolVar(&buildForce, "force", false, "Rebuild services even if their sources didn't change")
+	buildCommand.flags.StringVar(&buildService, "service", "", "Name of the service to build")
+
+	AddCommand("build", buildCommand)
+}
+
+func doBuild(cmd *SubCommand, client *cb.DevClient, args ...string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("There are no arguments to the build command, only command line options")
+	}
+	SetRootDir(".")
+
+	if !build.HasSources(rootDir) {
+		return fmt.Errorf("Nothing to build: %s/services not found", build.SrcDir)
+	}
+
+	services := []string{}
+	if buildService != "" {
+		services = append(services, buildService)
+	}
+	return buildSources(build.Options{Force: buildForce, Services: services})
+}
+
+// buildSources builds the services kept under src/, if any.
+func buildSources(opts build.Options) error {
+	if !build.HasSources(rootDir) {
+		return nil
+	}
+
+	config, err := build.LoadConfig(rootDir)
+	if err != nil {
+		return err
+	}
+
+	result, err := build.Build(rootDir, build.NewCommandBuilder(config, rootDir), opts)
+	if result != nil && len(result.Built) > 0 {
+		fmt.Printf("Built services: %s\n", strings.Join(result.Built, ", "))
+	}
+	if err != nil {
+		return err
+	}
+	if len(result.Cached) > 0 {
+		fmt.Printf("Up to date: %s\n", strings.Join(result.Cached, ", "))
+	}
+	return nil
+}
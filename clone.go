@@ -0,0 +1,187 @@
+package cblib
+
+import (
+	"fmt"
+	"os"
+
+	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cblib/internal/remote"
+	"github.com/clearblade/cblib/models/systemUpload"
+	"github.com/clearblade/cblib/types"
+)
+
+var (
+	cloneFrom string
+	cloneTo   string
+)
+
+func init() {
+
+	usage :=
+		`
+	Copy a system from one remote to another without touching the local filesystem.
+	Assets are pulled from the source remote into a temporary directory and pushed
+	to the destination remote as a single system upload.
+	`
+
+	example :=
+		`
+	cb-cli clone -from=dev -to=prod							# Copy every asset, users and collection rows from dev to prod
+	cb-cli clone -from=dev -to=prod -importusers=false -importrows=false	# Copy every asset except users and collection rows
+	`
+
+	cloneCommand := &SubCommand{
+		name:      "clone",
+		usage:     usage,
+		needsAuth: false,
+		run:       doClone,
+		example:   example,
+	}
+
+	cloneCommand.flags.StringVar(&cloneFrom, "from", "", "Name of the remote to copy the system from")
+	cloneCommand.flags.StringVar(&cloneTo, "to", "", "Name of the remote to copy the system to")
+	cloneCommand.flags.BoolVar(&importRows, "importrows", true, "copies all data of all collections")
+	cloneCommand.flags.BoolVar(&importUsers, "importusers", true, "copies all users")
+	cloneCommand.flags.BoolVar(&AutoApprove, "auto-approve", false, "automatically answer yes to all prompts")
+	setBackoffFlags(cloneCommand.flags)
+
+	AddCommand("clone", cloneCommand)
+}
+
+func doClone(cmd *SubCommand, _ *cb.DevClient, args ...string) error {
+	parseBackoffFlags()
+	if len(args) != 0 {
+		return fmt.Errorf("clone command takes no arguments; only options\n")
+	}
+
+	if cloneFrom == "" || cloneTo == "" {
+		return fmt.Errorf("both -from and -to remotes are required")
+	}
+
+	if cloneFrom == cloneTo {
+		return fmt.Errorf("source and destination remotes must be different")
+	}
+
+	remotes, err := remote.LoadFromDirOrLegacy(".")
+	if err != nil {
+		return err
+	}
+
+	from, ok := remotes.FindByName(cloneFrom)
+	if !ok {
+		return fmt.Errorf("no remote named '%s'", cloneFrom)
+	}
+
+	to, ok := remotes.FindByName(cloneTo)
+	if !ok {
+		return fmt.Errorf("no remote named '%s'", cloneTo)
+	}
+
+	from, err = remote.Resolve(from)
+	if err != nil {
+		return err
+	}
+
+	to, err = remote.Resolve(to)
+	if err != nil {
+		return err
+	}
+
+	config := MakeImportConfigFromGlobals()
+	config.IntoExistingSystem = true
+	config.ExistingSystemKey = to.SystemKey
+	config.ExistingSystemSecret = to.SystemSecret
+
+	return cloneSystem(config, from, to)
+}
+
+func authorizeUsingRemote(r *remote.Remote) (*cb.DevClient, error) {
+	client, err := authorizeUsing(r.PlatformURL, r.MessagingURL, "", "", r.Token)
+	if err != nil {
+		return nil, fmt.Errorf("could not authorize with remote '%s': %s", r.Name, err)
+	}
+	return client, nil
+}
+
+// cloneSystem pulls every asset of the source remote into a temporary system
+// directory and pushes it to the destination remote. Collection, user and role
+// ids of the source are translated to names by the zip id mapper, so the
+// destination resolves them to its own ids.
+func cloneSystem(config ImportConfig, from, to *remote.Remote) error {
+	srcClient, err := authorizeUsingRemote(from)
+	if err != nil {
+		return err
+	}
+
+	dstClient, err := authorizeUsingRemote(to)
+	if err != nil {
+		return err
+	}
+
+	tempDir, err := os.MkdirTemp("", "cb-cli-clone-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	// WARNING: side-effect (changes globals). The working tree is never
+	// written to since every path is now rooted at the temporary directory.
+	SetRootDir(tempDir)
+	defer SetRootDir(".")
+
+	err = setupDirectoryStructure()
+	if err != nil {
+		return err
+	}
+
+	srcMeta, err := pullSystemMeta(from.SystemKey, srcClient)
+	if err != nil {
+		return fmt.Errorf("could not get system from remote '%s': %s", from.Name, err)
+	}
+	setGlobalSystemDotJSONFromSystemMeta(srcMeta)
+
+	logInfo(fmt.Sprintf("Pulling system '%s' from remote '%s'", srcMeta.Name, from.Name))
+	assets := AffectedAssets{
+		AllAssets:    true,
+		ExportUsers:  config.ImportUsers,
+		ExportRows:   config.ImportRows,
+		ExportItemId: ExportItemIdDefault,
+	}
+	_, err = pullAssets(srcMeta, srcClient, assets)
+	if err != nil {
+		return err
+	}
+
+	err = storeSystemDotJSON(systemDotJSON)
+	if err != nil {
+		return err
+	}
+
+	dstMeta := &types.System_meta{
+		Name:        srcMeta.Name,
+		Key:         config.ExistingSystemKey,
+		Secret:      config.ExistingSystemSecret,
+		Description: srcMeta.Description,
+		Services:    srcMeta.Services,
+		PlatformUrl: dstClient.HttpAddr,
+		MessageUrl:  dstClient.MqttAddr,
+	}
+
+	version, err := systemUpload.GetSystemUploadVersion(dstMeta, dstClient)
+	if err != nil {
+		return err
+	}
+
+	if version < 5 {
+		return fmt.Errorf("remote '%s' does not support system uploads; use export and import instead", to.Name)
+	}
+
+	logInfo(fmt.Sprintf("Pushing system '%s' to remote '%s'", srcMeta.Name, to.Name))
+	err = pushSystemZip(dstMeta, dstClient, makeImportZipOptions(config))
+	if err != nil {
+		return err
+	}
+
+	logInfo(fmt.Sprintf("Cloned system '%s' from remote '%s' to remote '%s'", srcMeta.Name, from.Name, to.Name))
+	return nil
+}
@@ -131,6 +131,7 @@ func createOneService(systemInfo *types.System_meta, client *cb.DevClient) error
 	if err != nil {
 		return err
 	}
+	service["name"] = ServiceName
 	return createService(systemInfo.Key, service, client)
 }
 
@@ -153,6 +154,7 @@ func createOneLibrary(systemInfo *types.System_meta, client *cb.DevClient) error
 	if err != nil {
 		return err
 	}
+	library["name"] = LibraryName
 	return createLibrary(systemInfo.Key, library, client)
 }
 
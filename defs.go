@@ -48,6 +48,7 @@ var (
 	BackoffRetryMultiplier     float64
 	Email                      string
 	Password                   string
+	BrowserLoginMethod         string
 	CollectionSchema           string
 	ServiceName                string
 	LibraryName                string
@@ -66,6 +67,8 @@ var (
 	AdaptorName                string
 	DeploymentName             string
 	PreserveEdges              bool
+	WithDependents             bool
+	WithDeps                   bool
 	ExcludeIndexes             bool
 	ServiceCacheName           string
 	WebhookName                string
@@ -0,0 +1,233 @@
+package cblib
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	cb "github.com/clearblade/Go-SDK"
+	libPkg "github.com/clearblade/cblib/models/libraries"
+)
+
+var (
+	depsFormat  string
+	depsReverse string
+)
+
+func init() {
+
+	usage :=
+		`
+	Show the dependency graph of the local libraries and services, built from their
+	'dependencies' field. Dependency cycles and dependencies on libraries that don't
+	exist are reported as errors. Use -reverse to see what depends on a library, i.e.
+	what may break when it changes.
+	`
+
+	example :=
+		`
+	cb-cli deps									# List the dependencies of every library and service
+	cb-cli deps -reverse=myLib					# Libraries and services that depend on myLib
+	cb-cli deps -format=dot | dot -Tpng > deps.png	# Draw the graph with Graphviz
+	`
+
+	depsCommand := &SubCommand{
+		name:      "deps",
+		usage:     usage,
+		needsAuth: false,
+		run:       doDeps,
+		example:   example,
+	}
+
+	depsCommand.flags.StringVar(&depsFormat, "format", "text", "Output format: text or dot")
+	depsCommand.flags.StringVar(&depsReverse, "reverse", "", "Name of a library. Show the libraries and services that depend on it")
+
+	AddCommand("deps", depsCommand)
+}
+
+func doDeps(cmd *SubCommand, client *cb.DevClient, args ...string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("There are no arguments to the deps command, only command line options")
+	}
+	if depsFormat != "text" && depsFormat != "dot" {
+		return fmt.Errorf("Unknown format '%s'; must be text or dot", depsFormat)
+	}
+	SetRootDir(".")
+
+	graph, err := loadDependencyGraph()
+	if err != nil {
+		return err
+	}
+
+	if depsReverse != "" {
+		if _, ok := graph.Library(depsReverse); !ok {
+			return fmt.Errorf("Library '%s' not found", depsReverse)
+		}
+		libraries, services := graph.Dependents(depsReverse)
+		fmt.Printf("Libraries depending on %s: %s\n", depsReverse, joinOrNone(libraries))
+		fmt.Printf("Services depending on %s: %s\n", depsReverse, joinOrNone(services))
+		return graph.Check()
+	}
+
+	if depsFormat == "dot" {
+		if err := graph.WriteDOT(os.Stdout); err != nil {
+			return err
+		}
+		return graph.Check()
+	}
+
+	for _, node := range graph.Nodes() {
+		fmt.Printf("%s: %s\n", node, joinOrNone(node.Dependencies))
+	}
+	return graph.Check()
+}
+
+func joinOrNone(names []string) string {
+	if len(names) == 0 {
+		return "(none)"
+	}
+	return strings.Join(names, ", ")
+}
+
+// loadDependencyGraph builds the dependency graph of the local libraries and
+// services.
+func loadDependencyGraph() (*libPkg.Graph, error) {
+	nodes := []*libPkg.Node{}
+
+	libraries, err := getLibraries()
+	if err != nil {
+		return nil, err
+	}
+	for _, library := range libraries {
+		node, err := libPkg.NodeFromMap(libPkg.KindLibrary, library)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	services, err := getServices()
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range services {
+		node, err := libPkg.NodeFromMap(libPkg.KindService, service)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	return libPkg.NewGraph(nodes), nil
+}
+
+// libraryDependents returns the local libraries and services that depend on
+// the given library, directly or not.
+func libraryDependents(name string) (libraries []string, services []string, err error) {
+	graph, err := loadDependencyGraph()
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := graph.Library(name); !ok {
+		return nil, nil, fmt.Errorf("Library '%s' not found", name)
+	}
+	libraries, services = graph.Dependents(name)
+	// only what is pushed matters
+	if err := graph.CheckCycles(append([]string{name}, libraries...)...); err != nil {
+		return nil, nil, err
+	}
+	pushed := []*libPkg.Node{}
+	for _, library := range append([]string{name}, libraries...) {
+		node, _ := graph.Library(library)
+		pushed = append(pushed, node)
+	}
+	for _, service := range services {
+		node, _ := graph.Service(service)
+		pushed = append(pushed, node)
+	}
+	warnNonLocalDependencies(graph, pushed)
+	return libraries, services, nil
+}
+
+// codeDependencies returns the local libraries the given library and service
+// depend on, directly or not, in the order they must be pushed. Either name
+// can be empty. Dependencies on libraries which aren't local are warned about.
+func codeDependencies(libraryName, serviceName string) ([]string, error) {
+	graph, err := loadDependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []*libPkg.Node{}
+	if libraryName != "" {
+		node, ok := graph.Library(libraryName)
+		if !ok {
+			return nil, fmt.Errorf("Library '%s' not found", libraryName)
+		}
+		nodes = append(nodes, node)
+	}
+	if serviceName != "" {
+		node, ok := graph.Service(serviceName)
+		if !ok {
+			return nil, fmt.Errorf("Service '%s' not found", serviceName)
+		}
+		nodes = append(nodes, node)
+	}
+
+	rtn := []string{}
+	for _, node := range nodes {
+		deps, err := graph.Dependencies(node)
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range deps {
+			if dep != libraryName && !slices.Contains(rtn, dep) {
+				rtn = append(rtn, dep)
+			}
+		}
+	}
+	for _, dep := range rtn {
+		node, _ := graph.Library(dep)
+		nodes = append(nodes, node)
+	}
+	warnNonLocalDependencies(graph, nodes)
+	return rtn, nil
+}
+
+// orderLibraries returns the libraries in the order they must be pushed,
+// dependencies first. Dependency cycles are errors, as the libraries couldn't
+// be pushed in any order; dependencies on libraries which aren't local are
+// warned about.
+func orderLibraries(rawLibraries []map[string]interface{}) ([]libPkg.Library, error) {
+	libraries := make([]libPkg.Library, 0, len(rawLibraries))
+	nodes := make([]*libPkg.Node, 0, len(rawLibraries))
+	for _, rawLib := range rawLibraries {
+		library, err := libPkg.NewLibraryFromMap(rawLib)
+		if err != nil {
+			return nil, err
+		}
+		libraries = append(libraries, library)
+		nodes = append(nodes, &libPkg.Node{Kind: libPkg.KindLibrary, Name: library.GetName(), Dependencies: library.GetDependencies()})
+	}
+
+	graph := libPkg.NewGraph(nodes)
+	if err := graph.CheckCycles(); err != nil {
+		return nil, err
+	}
+	warnNonLocalDependencies(graph, nodes)
+	return libPkg.PostorderLibraries(libraries), nil
+}
+
+// warnNonLocalDependencies warns, once per library, about the dependencies of
+// the given nodes on libraries which aren't local. Those are assumed to be
+// global libraries, which are never pulled, and are left out of pushes.
+func warnNonLocalDependencies(graph *libPkg.Graph, nodes []*libPkg.Node) {
+	warned := map[string]bool{}
+	for _, u := range graph.Unknown() {
+		if !warned[u.Name] && slices.Contains(nodes, u.From) {
+			warned[u.Name] = true
+			logWarning(fmt.Sprintf("%s depends on library '%s', which isn't local; assuming it is a global library", u.From, u.Name))
+		}
+	}
+}
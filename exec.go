@@ -1,7 +1,6 @@
 package cblib
 
 import (
-	"errors"
 	"fmt"
 
 	cb "github.com/clearblade/Go-SDK"
@@ -21,6 +20,8 @@ func init() {
 	example :=
 		`
 	cb-cli exec -service=Service1				# Execute the code service Service1 on the Platform
+	cb-cli exec -service=Service1 -params-file=params.json -format=raw	# Execute with params read from a file and print the results as JSON
+	cb-cli exec -service=Service1 -tail-logs=30s	# Execute and print the service's logs for 30 seconds
 	`
 
 	execCommand := &SubCommand{
@@ -33,26 +34,22 @@ func init() {
 
 	execCommand.flags.BoolVar(&loggingEnabled, "enable-logs", false, "Enable logs for the service")
 	execCommand.flags.StringVar(&ServiceName, "service", "", "Name of service to execute")
+	setServiceCallFlags(&execCommand.flags)
 
 	AddCommand("exec", execCommand)
 }
 
 func doExec(cmd *SubCommand, client *cb.DevClient, args ...string) error {
-	systemInfo, err := getSysMeta()
-	if err != nil {
-		return err
+	if len(args) != 0 {
+		return fmt.Errorf("There are no arguments to the exec command, only command line options")
 	}
-
-	resp, err := client.CallService(systemInfo.Key, ServiceName, nil, loggingEnabled)
+	if ServiceName == "" {
+		return fmt.Errorf("-service=<service_name> is required")
+	}
+	systemInfo, err := getSysMeta()
 	if err != nil {
 		return err
 	}
 
-	if success, ok := resp["success"].(bool); ok && success == false {
-		return errors.New(resp["results"].(string))
-	}
-
-	fmt.Println(resp)
-
-	return nil
+	return callService(systemInfo.Key, ServiceName, client, loggingEnabled)
 }
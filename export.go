@@ -726,7 +726,7 @@ func PullPortals(sysMeta *types.System_meta, cli *cb.DevClient) ([]map[string]in
 			return nil, err
 		}
 		fmt.Printf(" %s", currentPortal["name"].(string))
-		err = writePortal(currentPortal["name"].(string), currentPortal)
+		err = writePulledPortal(currentPortal["name"].(string), currentPortal)
 		if err != nil {
 			return nil, err
 		}
@@ -12,9 +12,13 @@ import (
 
 	cb "github.com/clearblade/Go-SDK"
 
+	"github.com/clearblade/cblib/internal/metaformat"
+	"github.com/clearblade/cblib/internal/pluginfiles"
 	"github.com/clearblade/cblib/models"
+	"github.com/clearblade/cblib/models/assets"
 	"github.com/clearblade/cblib/models/bucketSetFiles"
 	"github.com/clearblade/cblib/models/filestores"
+	"github.com/clearblade/cblib/models/roles"
 	rt "github.com/clearblade/cblib/resourcetree"
 	"github.com/clearblade/cblib/types"
 )
@@ -221,7 +225,7 @@ func writeUsersFile(allUsers []map[string]interface{}) error {
 }
 
 func getDict(filename string) (map[string]interface{}, error) {
-	jsonStr, err := ioutil.ReadFile(filename)
+	jsonStr, err := metaformat.ReadAsJSON(filename)
 	if err != nil {
 		return nil, err
 	}
@@ -275,7 +279,7 @@ func getCollectionItems(collectionName string) ([]interface{}, error) {
 
 func getAdaptor(sysKey, adaptorName string, client *cb.DevClient) (*models.Adaptor, error) {
 	currentDir := createFilePath(adaptorsDir, adaptorName)
-	currentAdaptorInfo, err := getObject(currentDir, adaptorName+".json")
+	currentAdaptorInfo, err := getMetadataObject(currentDir, adaptorName)
 	if err != nil {
 		return nil, err
 	}
@@ -368,6 +372,54 @@ func writeEntity(dirName, fileName string, stuff interface{}) error {
 	return nil
 }
 
+// writeMetadataEntity is like writeEntity but honors the metadata format of
+// the system (see metaformat.Settings), writing YAML when it is configured.
+func writeMetadataEntity(dirName, fileName string, stuff interface{}) error {
+	settings, err := metaformat.LoadSettings(rootDir)
+	if err != nil {
+		return err
+	}
+	return writeEntityAs(dirName, fileName, settings.MetadataFormat, stuff)
+}
+
+// writeEntityAs is like writeEntity but writes the given format, removing the
+// file of the other format if present.
+func writeEntityAs(dirName, fileName string, format metaformat.Format, stuff interface{}) error {
+	stuff = removeBogusColumns(stuff)
+	marshalled, err := json.MarshalIndent(stuff, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Could not marshall %s: %s", fileName, err.Error())
+	}
+	if err = metaformat.WriteFile(dirName, fileName, format, stuff, marshalled); err != nil {
+		return fmt.Errorf("Could not write to %s: %s", fileName, err.Error())
+	}
+	return nil
+}
+
+// normalizeAsset decodes the map into the given model, so that malformed
+// assets are reported as errors, and returns it as a map again.
+func normalizeAsset(data map[string]interface{}, model assets.Model) (map[string]interface{}, error) {
+	if err := assets.FromMap(data, model); err != nil {
+		return nil, err
+	}
+	return assets.ToMap(model)
+}
+
+// whitelistAsset is normalizeAsset for assets from the platform, whose fields
+// the model has no counterpart for aren't written to disk.
+func whitelistAsset(data map[string]interface{}, model assets.Model) (map[string]interface{}, error) {
+	if err := assets.FromMap(data, model); err != nil {
+		return nil, err
+	}
+	assets.DropExtras(model)
+	return assets.ToMap(model)
+}
+
+// getMetadataObject reads <dirName>/<name>.{json,yaml,yml}
+func getMetadataObject(dirName, name string) (map[string]interface{}, error) {
+	return getDict(metaformat.FindFile(dirName, name))
+}
+
 func whitelistCollection(data map[string]interface{}, items []interface{}) map[string]interface{} {
 	collection := map[string]interface{}{
 		"items":   items,
@@ -559,7 +611,9 @@ func writeCollection(collectionName string, data map[string]interface{}) error {
 		fmt.Printf("Warning - Failed to write collection name to ID map; subsequent operations may fail. %+v\n", err.Error())
 	}
 
-	return writeEntity(dataDir, collectionName, whitelistCollection(data, itemArray))
+	// Collections stay JSON whatever the metadata format, since their items
+	// are data rather than metadata
+	return writeEntityAs(dataDir, collectionName, metaformat.JSON, whitelistCollection(data, itemArray))
 }
 
 func blacklistUser(data map[string]interface{}) {
@@ -601,104 +655,71 @@ func deleteCollectionIDFromKeyValuePairs(trig map[string]interface{}) {
 	}
 }
 
-func whitelistTrigger(data map[string]interface{}) map[string]interface{} {
-	deleteCollectionIDFromKeyValuePairs(data)
-	return map[string]interface{}{
-		"event_definition": data["event_definition"],
-		"key_value_pairs":  data["key_value_pairs"],
-		"name":             data["name"],
-		"service_name":     data["service_name"],
-		"disabled":         data["disabled"],
-	}
-}
-
 func writeTrigger(name string, data map[string]interface{}) error {
 	if err := os.MkdirAll(triggersDir, 0777); err != nil {
 		return err
 	}
-	return writeEntity(triggersDir, name, whitelistTrigger(data))
-}
-
-func whitelistTimer(data map[string]interface{}) map[string]interface{} {
-	return map[string]interface{}{
-		"description":  data["description"],
-		"frequency":    data["frequency"],
-		"name":         data["name"],
-		"repeats":      data["repeats"],
-		"service_name": data["service_name"],
-		"start_time":   data["start_time"],
-		"disabled":     data["disabled"],
+	deleteCollectionIDFromKeyValuePairs(data)
+	trigger, err := whitelistAsset(data, &assets.Trigger{})
+	if err != nil {
+		return err
 	}
+	return writeMetadataEntity(triggersDir, name, trigger)
 }
 
 func writeTimer(name string, data map[string]interface{}) error {
 	if err := os.MkdirAll(timersDir, 0777); err != nil {
 		return err
 	}
-	return writeEntity(timersDir, name, whitelistTimer(data))
-}
-
-func whitelistDeployment(data map[string]interface{}) map[string]interface{} {
-	return map[string]interface{}{
-		"assets":      data["assets"],
-		"description": data["description"],
-		"edges":       data["edges"],
-		"name":        data["name"],
+	timer, err := whitelistAsset(data, &assets.Timer{})
+	if err != nil {
+		return err
 	}
+	return writeMetadataEntity(timersDir, name, timer)
 }
 
 func writeDeployment(name string, data map[string]interface{}) error {
 	if err := os.MkdirAll(deploymentsDir, 0777); err != nil {
 		return err
 	}
-	return writeEntity(deploymentsDir, name, whitelistDeployment(data))
-}
-
-func whitelistServiceCache(data map[string]interface{}) map[string]interface{} {
-	return map[string]interface{}{
-		"description": data["description"],
-		"ttl":         data["ttl"],
-		"name":        data["name"],
-	}
-}
-
-func whitelistWebhook(data map[string]interface{}) map[string]interface{} {
-	return map[string]interface{}{
-		"auth_method":  data["auth_method"],
-		"description":  data["description"],
-		"name":         data["name"],
-		"service_name": data["service_name"],
-		"path":         data["path"],
-	}
-}
-
-func whitelistExternalDatabase(data map[string]interface{}) map[string]interface{} {
-	return map[string]interface{}{
-		"credentials": data["credentials"],
-		"name":        data["name"],
-		"dbtype":      data["dbtype"],
+	deployment, err := whitelistAsset(data, &assets.Deployment{})
+	if err != nil {
+		return err
 	}
+	return writeMetadataEntity(deploymentsDir, name, deployment)
 }
 
 func writeServiceCache(name string, data map[string]interface{}) error {
 	if err := os.MkdirAll(serviceCachesDir, 0777); err != nil {
 		return err
 	}
-	return writeEntity(serviceCachesDir, name, whitelistServiceCache(data))
+	cache, err := whitelistAsset(data, &assets.ServiceCache{})
+	if err != nil {
+		return err
+	}
+	return writeEntity(serviceCachesDir, name, cache)
 }
 
 func writeWebhook(name string, data map[string]interface{}) error {
 	if err := os.MkdirAll(webhooksDir, 0777); err != nil {
 		return err
 	}
-	return writeEntity(webhooksDir, name, whitelistWebhook(data))
+	webhook, err := whitelistAsset(data, &assets.Webhook{})
+	if err != nil {
+		return err
+	}
+	return writeMetadataEntity(webhooksDir, name, webhook)
 }
 
 func writeExternalDatabase(name string, data map[string]interface{}) error {
 	if err := os.MkdirAll(externalDatabasesDir, 0777); err != nil {
 		return err
 	}
-	return writeEntity(externalDatabasesDir, name, whitelistExternalDatabase(data))
+	db, err := whitelistAsset(data, &assets.ExternalDatabase{})
+	if err != nil {
+		return err
+	}
+	return writeEntity(externalDatabasesDir, name, db)
 }
 
 func whitelistServicesPermissions(data []interface{}) []map[string]interface{} {
@@ -798,7 +819,39 @@ func writeRole(name string, data map[string]interface{}) error {
 	if err != nil {
 		fmt.Printf("Warning - Failed to write role name to ID map; subsequent operations may fail. %+v\n", err.Error())
 	}
-	return writeEntity(rolesDir, name, whitelistRole(data))
+	return writeRoleEntity(name, whitelistRole(data))
+}
+
+// writeRoleEntity writes the role in the role format of the system (see
+// metaformat.Settings).
+func writeRoleEntity(name string, role map[string]interface{}) error {
+	settings, err := metaformat.LoadSettings(rootDir)
+	if err != nil {
+		return err
+	}
+
+	if settings.RoleFormat != metaformat.ReadableRoles {
+		return writeMetadataEntity(rolesDir, name, role)
+	}
+
+	typed, err := roles.RoleFromGetShape(role)
+	if err != nil {
+		return fmt.Errorf("Could not convert role %s to the readable format: %s", name, err)
+	}
+	readable, err := typed.Readable()
+	if err != nil {
+		return fmt.Errorf("Could not convert role %s to the readable format: %s", name, err)
+	}
+	return writeMetadataEntity(rolesDir, name, readable)
+}
+
+// roleFromDisk converts a role read from disk to the platform's shape if it
+// is stored in the readable format.
+func roleFromDisk(role map[string]interface{}) (map[string]interface{}, error) {
+	if !roles.IsReadableShape(role) {
+		return role, nil
+	}
+	return roles.ReadableToGetShape(role)
 }
 
 // Deletes fields from the service map that we dont want to write to disk
@@ -813,13 +866,17 @@ func writeService(name string, data map[string]interface{}) error {
 		return err
 	}
 
-	if err := ioutil.WriteFile(mySvcDir+"/"+name+".js", []byte(data["code"].(string)), 0666); err != nil {
+	svc := &assets.Service{}
+	if err := assets.FromMap(data, svc); err != nil {
 		return err
 	}
 
-	sourceMap, ok := data["source_map"].(string)
-	if ok && sourceMap != "" {
-		if err := ioutil.WriteFile(mySvcDir+"/"+name+".js.map", []byte(data["source_map"].(string)), 0666); err != nil {
+	if err := ioutil.WriteFile(mySvcDir+"/"+name+".js", []byte(svc.Code), 0666); err != nil {
+		return err
+	}
+
+	if svc.SourceMap != "" {
+		if err := ioutil.WriteFile(mySvcDir+"/"+name+".js.map", []byte(svc.SourceMap), 0666); err != nil {
 			return err
 		}
 	}
@@ -873,17 +930,21 @@ func getMessageHistoryStorage() ([]cb.MessageHistoryStorageEntry, error) {
 
 	typedEntries := make([]cb.MessageHistoryStorageEntry, 0)
 	for i := range entries {
-		if m, ok := entries[i].(map[string]interface{}); ok {
-			typedEntries = append(typedEntries, cb.MessageHistoryStorageEntry{
-				Edge:     m["edge"].(bool),
-				Platform: m["platform"].(bool),
-				MaxRows:  int(m["max_rows"].(float64)),
-				MaxTime:  int(m["max_time"].(float64)),
-				Topic:    m["topic"].(string),
-			})
-		} else {
+		m, ok := entries[i].(map[string]interface{})
+		if !ok {
 			return nil, fmt.Errorf("Unexpected type from storage.json")
 		}
+		entry := assets.MessageHistoryStorageEntry{}
+		if err := assets.FromMap(m, &entry); err != nil {
+			return nil, fmt.Errorf("storage.json: %s", err)
+		}
+		typedEntries = append(typedEntries, cb.MessageHistoryStorageEntry{
+			Edge:     entry.Edge,
+			Platform: entry.Platform,
+			MaxRows:  entry.MaxRows,
+			MaxTime:  entry.MaxTime,
+			Topic:    entry.Topic,
+		})
 	}
 
 	return typedEntries, nil
@@ -932,31 +993,31 @@ func whitelistBucketSet(data map[string]interface{}) map[string]interface{} {
 	}
 }
 
-func whitelistLibrary(data map[string]interface{}) map[string]interface{} {
-	return map[string]interface{}{
-		"api":          data["api"],
-		"dependencies": data["dependencies"],
-		"description":  data["description"],
-		"name":         data["name"],
-		"visibility":   data["visibility"],
-	}
-}
-
 func writeLibrary(name string, data map[string]interface{}) error {
 	myLibDir := libDir + "/" + name
 	if err := os.MkdirAll(myLibDir, 0777); err != nil {
 		return err
 	}
-	if err := ioutil.WriteFile(myLibDir+"/"+name+".js", []byte(data["code"].(string)), 0666); err != nil {
+	lib := &assets.Library{}
+	if err := assets.FromMap(data, lib); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(myLibDir+"/"+name+".js", []byte(lib.Code), 0666); err != nil {
 		return err
 	}
-	sourceMap, ok := data["source_map"].(string)
-	if ok && sourceMap != "" {
-		if err := ioutil.WriteFile(myLibDir+"/"+name+".js.map", []byte(data["source_map"].(string)), 0666); err != nil {
+	if lib.SourceMap != "" {
+		if err := ioutil.WriteFile(myLibDir+"/"+name+".js.map", []byte(lib.SourceMap), 0666); err != nil {
 			return err
 		}
 	}
-	return writeEntity(myLibDir, name, whitelistLibrary(data))
+	lib.Code = ""
+	lib.SourceMap = ""
+	assets.DropExtras(lib)
+	meta, err := assets.ToMap(lib)
+	if err != nil {
+		return err
+	}
+	return writeEntity(myLibDir, name, meta)
 }
 
 func blacklistEdge(data map[string]interface{}) {
@@ -987,6 +1048,9 @@ func writeEdge(name string, data map[string]interface{}) error {
 	if err := os.MkdirAll(edgesDir, 0777); err != nil {
 		return err
 	}
+	if err := assets.FromMap(data, &assets.Edge{}); err != nil {
+		return err
+	}
 	return writeEntity(edgesDir, name, data)
 }
 
@@ -1039,7 +1103,18 @@ func writePlugin(name string, data map[string]interface{}) error {
 	if err := os.MkdirAll(pluginsDir, 0777); err != nil {
 		return err
 	}
-	return writeEntity(pluginsDir, name, data)
+	if err := assets.FromMap(data, &assets.Plugin{}); err != nil {
+		return err
+	}
+	data = removeBogusColumns(data).(map[string]interface{})
+	if err := pluginfiles.Decompress(data, filepath.Join(pluginsDir, name)); err != nil {
+		return err
+	}
+	// plugins used to be kept in a single file
+	if err := os.Remove(filepath.Join(pluginsDir, name+".json")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
 }
 
 func whitelistAdapterInfo(data map[string]interface{}) map[string]interface{} {
@@ -1075,7 +1150,7 @@ func writeAdaptor(a *models.Adaptor) error {
 		return err
 	}
 
-	err := writeEntity(myAdaptorDir, a.Name, whitelistAdapterInfo(a.Info))
+	err := writeMetadataEntity(myAdaptorDir, a.Name, whitelistAdapterInfo(a.Info))
 	if err != nil {
 		return err
 	}
@@ -1156,7 +1231,22 @@ func getObjectList(dirName string, exceptions []string) ([]map[string]interface{
 	return rval, nil
 }
 
-func getCodeStuff(dirName string) ([]map[string]interface{}, error) {
+// getAssetList is like getObjectList but decodes every object into a model.
+func getAssetList(dirName string, exceptions []string, newModel func() assets.Model) ([]map[string]interface{}, error) {
+	list, err := getObjectList(dirName, exceptions)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i], err = normalizeAsset(list[i], newModel())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func getCodeStuff(dirName string, newModel func() assets.Model) ([]map[string]interface{}, error) {
 	dirList, err := getFileList(dirName, []string{".DS_Store", ".git", ".gitignore"}) // For starters
 	if err != nil {
 		fmt.Printf("getFileListFailed: %s, %s\n", dirName, err)
@@ -1189,21 +1279,35 @@ func getCodeStuff(dirName string) ([]map[string]interface{}, error) {
 		}
 		myObj["code"] = string(byts)
 		delete(myObj, "source")
+		myObj, err = normalizeAsset(myObj, newModel())
+		if err != nil {
+			return nil, err
+		}
 		rval = append(rval, myObj)
 	}
 	return rval, nil
 }
 
 func getLibraries() ([]map[string]interface{}, error) {
-	return getCodeStuff(libDir)
+	return getCodeStuff(libDir, func() assets.Model { return &assets.Library{} })
 }
 
 func getServices() ([]map[string]interface{}, error) {
-	return getCodeStuff(svcDir)
+	return getCodeStuff(svcDir, func() assets.Model { return &assets.Service{} })
 }
 
 func getRoles() ([]map[string]interface{}, error) {
-	return getObjectList(rolesDir, []string{})
+	list, err := getObjectList(rolesDir, []string{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i], err = roleFromDisk(list[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
 }
 
 func getUsers() ([]map[string]interface{}, error) {
@@ -1215,27 +1319,27 @@ func getCollections() ([]map[string]interface{}, error) {
 }
 
 func getTriggers() ([]map[string]interface{}, error) {
-	return getObjectList(triggersDir, []string{})
+	return getAssetList(triggersDir, []string{}, func() assets.Model { return &assets.Trigger{} })
 }
 
 func getTimers() ([]map[string]interface{}, error) {
-	return getObjectList(timersDir, []string{})
+	return getAssetList(timersDir, []string{}, func() assets.Model { return &assets.Timer{} })
 }
 
 func getDeployments() ([]map[string]interface{}, error) {
-	return getObjectList(deploymentsDir, []string{})
+	return getAssetList(deploymentsDir, []string{}, func() assets.Model { return &assets.Deployment{} })
 }
 
 func getServiceCaches() ([]map[string]interface{}, error) {
-	return getObjectList(serviceCachesDir, []string{})
+	return getAssetList(serviceCachesDir, []string{}, func() assets.Model { return &assets.ServiceCache{} })
 }
 
 func getWebhooks() ([]map[string]interface{}, error) {
-	return getObjectList(webhooksDir, []string{})
+	return getAssetList(webhooksDir, []string{}, func() assets.Model { return &assets.Webhook{} })
 }
 
 func getExternalDatabases() ([]map[string]interface{}, error) {
-	return getObjectList(externalDatabasesDir, []string{})
+	return getAssetList(externalDatabasesDir, []string{}, func() assets.Model { return &assets.ExternalDatabase{} })
 }
 
 func getBucketSets() ([]map[string]interface{}, error) {
@@ -1255,11 +1359,15 @@ func getSecret(name string) (map[string]interface{}, error) {
 }
 
 func getDeployment(name string) (map[string]interface{}, error) {
-	return getObject(deploymentsDir, name+".json")
+	data, err := getMetadataObject(deploymentsDir, name)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeAsset(data, &assets.Deployment{})
 }
 
 func getEdges() ([]map[string]interface{}, error) {
-	return getObjectList(edgesDir, []string{"schema.json"})
+	return getAssetList(edgesDir, []string{"schema.json"}, func() assets.Model { return &assets.Edge{} })
 }
 
 func getEdgesSchema() (map[string]interface{}, error) {
@@ -1271,7 +1379,7 @@ func getDevicesSchema() (map[string]interface{}, error) {
 }
 
 func getDevices() ([]map[string]interface{}, error) {
-	return getObjectList(devicesDir, []string{"schema.json", "roles"})
+	return getAssetList(devicesDir, []string{"schema.json", "roles"}, func() assets.Model { return &assets.Device{} })
 }
 
 func getPortals() ([]map[string]interface{}, error) {
@@ -1330,6 +1438,7 @@ func hasLegacyPortalDirectory() bool {
 	return isLegacy
 }
 
+// getCompressedPortals lints and compresses the portals, before they are pushed.
 func getCompressedPortals() ([]map[string]interface{}, error) {
 	portals, err := getPortals()
 	if err != nil {
@@ -1338,6 +1447,9 @@ func getCompressedPortals() ([]map[string]interface{}, error) {
 	rtn := make([]map[string]interface{}, 0)
 	for _, p := range portals {
 		name := p["name"].(string)
+		if err := lintPortalCode(name); err != nil {
+			return nil, err
+		}
 		compressedPortal, err := compressPortal(name)
 		if err != nil {
 			return nil, fmt.Errorf("Error compressing portal '%s': %s\n", name, err.Error())
@@ -1347,8 +1459,30 @@ func getCompressedPortals() ([]map[string]interface{}, error) {
 	return rtn, nil
 }
 
+// getPlugins returns the plugins, decompressed in a directory each or kept
+// in a single file.
 func getPlugins() ([]map[string]interface{}, error) {
-	return getObjectList(pluginsDir, []string{})
+	fileList, err := ioutil.ReadDir(pluginsDir)
+	if err != nil {
+		fmt.Printf("Warning, could not read directory '%s' -- ignoring\n", pluginsDir)
+		return []map[string]interface{}{}, nil
+	}
+	rval := []map[string]interface{}{}
+	for _, oneFile := range fileList {
+		name := oneFile.Name()
+		if isException(name, []string{".DS_Store", ".git", ".gitignore"}) {
+			continue
+		}
+		if !oneFile.IsDir() {
+			name = strings.TrimSuffix(name, ".json")
+		}
+		plugin, err := getPlugin(name)
+		if err != nil {
+			return nil, err
+		}
+		rval = append(rval, plugin)
+	}
+	return rval, nil
 }
 
 func getEdgeDeployInfo() (map[string]interface{}, error) {
@@ -1368,7 +1502,11 @@ func getUserSchema() (map[string]interface{}, error) {
 }
 
 func getRole(name string) (map[string]interface{}, error) {
-	return getObject(rolesDir, name+".json")
+	role, err := getMetadataObject(rolesDir, name)
+	if err != nil {
+		return nil, err
+	}
+	return roleFromDisk(role)
 }
 
 func getFullUserObject(email string) (map[string]interface{}, error) {
@@ -1398,15 +1536,27 @@ func getUser(email string) (map[string]interface{}, error) {
 }
 
 func getTrigger(name string) (map[string]interface{}, error) {
-	return getObject(triggersDir, name+".json")
+	data, err := getMetadataObject(triggersDir, name)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeAsset(data, &assets.Trigger{})
 }
 
 func getTimer(name string) (map[string]interface{}, error) {
-	return getObject(timersDir, name+".json")
+	data, err := getMetadataObject(timersDir, name)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeAsset(data, &assets.Timer{})
 }
 
 func getDevice(name string) (map[string]interface{}, error) {
-	return getObject(devicesDir, name+".json")
+	data, err := getObject(devicesDir, name+".json")
+	if err != nil {
+		return nil, err
+	}
+	return normalizeAsset(data, &assets.Device{})
 }
 
 func getDeviceRoles(name string) ([]string, error) {
@@ -1419,11 +1569,19 @@ func getDeviceRoles(name string) ([]string, error) {
 }
 
 func getEdge(name string) (map[string]interface{}, error) {
-	return getObject(edgesDir, name+".json")
+	data, err := getObject(edgesDir, name+".json")
+	if err != nil {
+		return nil, err
+	}
+	return normalizeAsset(data, &assets.Edge{})
 }
 
 func getPortal(name string) (map[string]interface{}, error) {
-	return getObject(portalsDir+"/"+name, name+".json")
+	data, err := getObject(portalsDir+"/"+name, name+".json")
+	if err != nil {
+		return nil, err
+	}
+	return normalizeAsset(data, &assets.Portal{})
 }
 
 func getRawPortal(name string) (string, error) {
@@ -1439,23 +1597,45 @@ func readFileAsString(absFilePath string) (string, error) {
 }
 
 func getPlugin(name string) (map[string]interface{}, error) {
-	return getObject(pluginsDir, name+".json")
+	var data map[string]interface{}
+	var err error
+	if ok, _ := dirExists(filepath.Join(pluginsDir, name)); ok {
+		data, err = pluginfiles.Compress(filepath.Join(pluginsDir, name))
+	} else {
+		data, err = getObject(pluginsDir, name+".json")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return normalizeAsset(data, &assets.Plugin{})
 }
 
 func getServiceCache(name string) (map[string]interface{}, error) {
-	return getObject(serviceCachesDir, name+".json")
+	data, err := getObject(serviceCachesDir, name+".json")
+	if err != nil {
+		return nil, err
+	}
+	return normalizeAsset(data, &assets.ServiceCache{})
 }
 
 func getWebhook(name string) (map[string]interface{}, error) {
-	return getObject(webhooksDir, name+".json")
+	data, err := getMetadataObject(webhooksDir, name)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeAsset(data, &assets.Webhook{})
 }
 
 func getExternalDatabase(name string) (map[string]interface{}, error) {
-	return getObject(externalDatabasesDir, name+".json")
+	data, err := getObject(externalDatabasesDir, name+".json")
+	if err != nil {
+		return nil, err
+	}
+	return normalizeAsset(data, &assets.ExternalDatabase{})
 }
 
 func getCollection(name string) (map[string]interface{}, error) {
-	return getObject(dataDir, name+".json")
+	return getMetadataObject(dataDir, name)
 }
 
 func getService(name string) (map[string]interface{}, error) {
@@ -1484,7 +1664,7 @@ func getService(name string) (map[string]interface{}, error) {
 		return nil, err
 	}
 	svcMap["code"] = string(byts)
-	return svcMap, nil
+	return normalizeAsset(svcMap, &assets.Service{})
 }
 
 func getLibrary(name string) (map[string]interface{}, error) {
@@ -1513,7 +1693,7 @@ func getLibrary(name string) (map[string]interface{}, error) {
 		return nil, err
 	}
 	libMap["code"] = string(byts)
-	return libMap, nil
+	return normalizeAsset(libMap, &assets.Library{})
 }
 
 func getSysMeta() (*types.System_meta, error) {
@@ -54,6 +54,9 @@ type ZipOptions struct {
 
 	AllLibraries bool
 	LibraryName  string
+	// LibraryNames are more libraries to push, e.g. the dependents of
+	// LibraryName
+	LibraryNames []string
 
 	AllPlugins bool
 	PluginName string
@@ -67,8 +70,9 @@ type ZipOptions struct {
 	AllSecrets bool
 	SecretName string
 
-	AllServices bool
-	ServiceName string
+	AllServices  bool
+	ServiceName  string
+	ServiceNames []string
 
 	AllTimers bool
 	TimerName string
@@ -162,7 +166,7 @@ func (s *ZipOptions) shouldPushService(name string) bool {
 		return true
 	}
 
-	return s.ServiceName == name
+	return s.ServiceName == name || slices.Contains(s.ServiceNames, name)
 }
 
 func (s *ZipOptions) shouldPushLibrary(name string) bool {
@@ -170,7 +174,7 @@ func (s *ZipOptions) shouldPushLibrary(name string) bool {
 		return true
 	}
 
-	return s.LibraryName == name
+	return s.LibraryName == name || slices.Contains(s.LibraryNames, name)
 }
 
 func (s *ZipOptions) shouldPushCollection(name string) bool {
@@ -271,7 +275,8 @@ func (s *ZipOptions) shouldPushPlugin(name string) bool {
 	return s.PluginName == name
 }
 
-func (s *ZipOptions) shouldPushPortal(name string) bool {
+// ShouldPushPortal returns true if the portal is part of the push.
+func (s *ZipOptions) ShouldPushPortal(name string) bool {
 	if s.AllAssets || s.AllPortals {
 		return true
 	}
@@ -29,6 +29,7 @@ type systemFileHandler interface {
 	WalkMessageHistoryStorage(path string)
 	WalkMessageTypeTriggers(path string)
 	WalkPlugin(path, relPath string, pluginName string)
+	WalkPluginMeta(path, relPath string, pluginName string)
 	WalkPortal(path, relPath string, portalName string)
 	WalkPortalDatasource(path, relPath string, portalName string)
 	WalkPortalInternalResources(path, relPath string, portalName string)
@@ -43,6 +44,9 @@ type systemFileHandler interface {
 	WalkUserRole(path, relPath string, email string)
 	WalkUserSchema(path string)
 	WalkWebhook(path, relPath string, webhookName string)
+	// Err returns the error which stops the walk, if a file couldn't be
+	// handled.
+	Err() error
 }
 
 func walkSystemFiles(rootDir string, handler systemFileHandler) error {
@@ -61,6 +65,9 @@ func walkSystemFiles(rootDir string, handler systemFileHandler) error {
 		// Only call handlers on files
 		if !d.IsDir() && err == nil {
 			callHandler(handler, absolutePath, path)
+			if handlerErr := handler.Err(); handlerErr != nil {
+				return handlerErr
+			}
 		}
 
 		return err
@@ -209,6 +216,8 @@ func callMessageTypeTriggersHandlers(handler systemFileHandler, absPath, relPath
 func callPluginHandlers(handler systemFileHandler, absPath, relPath string) {
 	if name, err := syspath.GetPluginNameFromPath(relPath); err == nil {
 		handler.WalkPlugin(absPath, relPath, name)
+	} else if name, err := syspath.GetPluginMetaNameFromPath(relPath); err == nil {
+		handler.WalkPluginMeta(absPath, relPath, name)
 	}
 }
 
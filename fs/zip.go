@@ -5,7 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/clearblade/cblib/internal/metaformat"
+	"github.com/clearblade/cblib/internal/pluginfiles"
+	"github.com/clearblade/cblib/internal/portalassets"
+	"github.com/clearblade/cblib/models/roles"
 	"github.com/clearblade/cblib/syspath"
 )
 
@@ -37,6 +43,7 @@ func writeSystemZip(rootDir string, prompter SecretPrompter, opts *ZipOptions) (
 	w := zip.NewWriter(archive)
 
 	if err = walkSystemFiles(rootDir, &zipper{
+		rootDir:  rootDir,
 		prompter: prompter,
 		writer:   w,
 		opts:     opts,
@@ -52,9 +59,15 @@ func writeSystemZip(rootDir string, prompter SecretPrompter, opts *ZipOptions) (
 }
 
 type zipper struct {
+	rootDir  string
 	prompter SecretPrompter
 	writer   *zip.Writer
 	opts     *ZipOptions
+	err      error
+}
+
+func (z *zipper) Err() error {
+	return z.err
 }
 
 // ----------------------
@@ -82,6 +95,54 @@ func (z *zipper) WalkExternalDatabase(path, relPath string, externalDatabaseName
 	}
 }
 
+/**
+ * Decompressed plugins are kept as a meta file and code files, so we need to put them back together in the single file the platform expects
+ */
+func (z *zipper) WalkPluginMeta(path, relPath string, pluginName string) {
+	if z.opts.shouldPushPlugin(pluginName) {
+		z.err = z.copyFileToZipWithTransform(path, "plugins/"+pluginName+".json", func(content []byte) ([]byte, error) {
+			plugin, err := pluginfiles.Compress(filepath.Dir(path))
+			if err != nil {
+				return nil, err
+			}
+			return json.Marshal(plugin)
+		})
+	}
+}
+
+/**
+ * Assets embedded in portals are kept as files of their own, so we need to put them back in place of their references
+ */
+func (z *zipper) WalkPortal(path, relPath string, portalName string) {
+	if z.opts.ShouldPushPortal(portalName) {
+		z.copyPortalFileToZip(path, relPath, portalName)
+	}
+}
+
+func (z *zipper) WalkPortalDatasource(path, relPath string, portalName string) {
+	if z.opts.ShouldPushPortal(portalName) {
+		z.copyPortalFileToZip(path, relPath, portalName)
+	}
+}
+
+func (z *zipper) WalkPortalInternalResources(path, relPath string, portalName string) {
+	if z.opts.ShouldPushPortal(portalName) {
+		z.copyPortalFileToZip(path, relPath, portalName)
+	}
+}
+
+func (z *zipper) WalkPortalWidget(path, relPath string, portalName string) {
+	if z.opts.ShouldPushPortal(portalName) {
+		z.copyPortalFileToZip(path, relPath, portalName)
+	}
+}
+
+func (z *zipper) WalkPortalWidgetParser(path, relPath string, portalName string) {
+	if z.opts.ShouldPushPortal(portalName) {
+		z.copyPortalFileToZip(path, relPath, portalName)
+	}
+}
+
 // ----------------------
 // Boring Stuff
 // ----------------------
@@ -194,39 +255,9 @@ func (z *zipper) WalkPlugin(path, relPath string, pluginName string) {
 	}
 }
 
-func (z *zipper) WalkPortal(path, relPath string, portalName string) {
-	if z.opts.shouldPushPortal(portalName) {
-		z.copyFileToZip(path, relPath)
-	}
-}
-
-func (z *zipper) WalkPortalDatasource(path, relPath string, portalName string) {
-	if z.opts.shouldPushPortal(portalName) {
-		z.copyFileToZip(path, relPath)
-	}
-}
-
-func (z *zipper) WalkPortalInternalResources(path, relPath string, portalName string) {
-	if z.opts.shouldPushPortal(portalName) {
-		z.copyFileToZip(path, relPath)
-	}
-}
-
-func (z *zipper) WalkPortalWidget(path, relPath string, portalName string) {
-	if z.opts.shouldPushPortal(portalName) {
-		z.copyFileToZip(path, relPath)
-	}
-}
-
-func (z *zipper) WalkPortalWidgetParser(path, relPath string, portalName string) {
-	if z.opts.shouldPushPortal(portalName) {
-		z.copyFileToZip(path, relPath)
-	}
-}
-
 func (z *zipper) WalkRole(path, relPath string, roleName string) {
 	if z.opts.shouldPushRole(roleName) {
-		z.copyFileToZip(path, relPath)
+		z.copyRoleToZip(path, relPath)
 	}
 }
 
@@ -294,6 +325,28 @@ func (z *zipper) copyCollectionSchemaToZip(localPath string, zipPath string) {
 	})
 }
 
+/**
+ * Converts roles stored in the readable format back to the platform's format
+ */
+func (z *zipper) copyRoleToZip(localPath string, zipPath string) {
+	z.copyFileToZipWithTransformNoErr(localPath, zipPath, func(content []byte) ([]byte, error) {
+		var data map[string]interface{}
+		if err := json.Unmarshal(content, &data); err != nil {
+			return nil, err
+		}
+
+		if !roles.IsReadableShape(data) {
+			return content, nil
+		}
+
+		role, err := roles.ReadableToGetShape(data)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(role)
+	})
+}
+
 /**
  * Prompts the user for the password before copying
  */
@@ -320,6 +373,21 @@ func (z *zipper) copyExternalDatabaseFileToZip(localPath string, zipPath string)
 	})
 }
 
+func (z *zipper) copyPortalFileToZip(localPath string, zipPath string, portalName string) {
+	assetsDir := filepath.Join(z.rootDir, "portals", portalName, portalassets.Dir)
+	z.err = z.copyFileToZipWithTransform(localPath, zipPath, func(content []byte) ([]byte, error) {
+		inlined, err := portalassets.Inline(string(content), assetsDir)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := inlined.(string)
+		if !ok {
+			return nil, fmt.Errorf("inlining the assets of %s didn't return a string", localPath)
+		}
+		return []byte(s), nil
+	})
+}
+
 func (z *zipper) copyFileToZip(localPath string, zipPath string) {
 	z.copyFileToZipWithTransformNoErr(localPath, zipPath, func(content []byte) ([]byte, error) {
 		return content, nil
@@ -330,7 +398,7 @@ type transformer func([]byte) ([]byte, error)
 
 func (z *zipper) copyFileToZipWithTransformNoErr(localPath string, zipPath string, transform transformer) {
 	if err := z.copyFileToZipWithTransform(localPath, zipPath, transform); err != nil {
-		fmt.Printf("Ignoring %q because it could not be copied to zip: %s", localPath, err)
+		fmt.Printf("Ignoring %q because it could not be copied to zip: %s\n", localPath, err)
 	}
 }
 
@@ -340,16 +408,26 @@ func (z *zipper) copyFileToZipWithTransform(localPath string, zipPath string, tr
 		return err
 	}
 
-	f, err := z.writer.Create(zipPath)
-	if err != nil {
-		return err
+	// the platform only understands JSON metadata
+	if metaformat.IsYAMLPath(localPath) {
+		content, err = metaformat.ToJSON(content)
+		if err != nil {
+			return fmt.Errorf("could not convert %s to JSON: %w", localPath, err)
+		}
+		zipPath = strings.TrimSuffix(zipPath, filepath.Ext(zipPath)) + ".json"
 	}
 
+	// transform before creating the entry, so that a failure leaves no empty entry
 	newContent, err := transform(content)
 	if err != nil {
 		return fmt.Errorf("could not transform %s: %w", localPath, err)
 	}
 
+	f, err := z.writer.Create(zipPath)
+	if err != nil {
+		return err
+	}
+
 	if _, err := f.Write(newContent); err != nil {
 		return err
 	}
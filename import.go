@@ -185,6 +185,20 @@ func importAllAssets(config ImportConfig, systemInfo *types.System_meta, users [
 		return importAllAssetsLegacy(config, systemInfo, users, cli)
 	}
 
+	opts := makeImportZipOptions(config)
+	if err := pushSystemZip(systemInfo, cli, opts); err != nil {
+		return err
+	}
+
+	fmt.Printf(" Done\n")
+	logInfo(fmt.Sprintf("Success! New system key is: %s", systemInfo.Key))
+	logInfo(fmt.Sprintf("New system secret is: %s", systemInfo.Secret))
+	return nil
+}
+
+// makeImportZipOptions returns zip options that select every asset of the
+// system, honoring the users and rows settings of the given config.
+func makeImportZipOptions(config ImportConfig) *fs.ZipOptions {
 	opts := fs.NewZipOptions(&mapper{})
 	opts.AllAdaptors = true
 	opts.AllBucketSets = true
@@ -211,15 +225,7 @@ func importAllAssets(config ImportConfig, systemInfo *types.System_meta, users [
 	opts.PushMessageHistoryStorage = true
 	opts.PushMessageTypeTriggers = true
 	opts.PushUserSchema = true
-
-	if err := pushSystemZip(systemInfo, cli, opts); err != nil {
-		return err
-	}
-
-	fmt.Printf(" Done\n")
-	logInfo(fmt.Sprintf("Success! New system key is: %s", systemInfo.Key))
-	logInfo(fmt.Sprintf("New system secret is: %s", systemInfo.Secret))
-	return nil
+	return opts
 }
 
 // --------------------------------
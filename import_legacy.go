@@ -8,7 +8,6 @@ import (
 	cb "github.com/clearblade/Go-SDK"
 
 	"github.com/clearblade/cblib/models/bucketSetFiles"
-	libPkg "github.com/clearblade/cblib/models/libraries"
 	"github.com/clearblade/cblib/models/roles"
 	"github.com/clearblade/cblib/types"
 )
@@ -273,13 +272,11 @@ func createLibraries(systemInfo *types.System_meta, client *cb.DevClient) error
 		return err
 	}
 
-	libraries := make([]libPkg.Library, 0)
-	for _, rawLib := range rawLibraries {
-		libraries = append(libraries, libPkg.NewLibraryFromMap(rawLib))
+	orderedLibraries, err := orderLibraries(rawLibraries)
+	if err != nil {
+		return err
 	}
 
-	orderedLibraries := libPkg.PostorderLibraries(libraries)
-
 	for _, library := range orderedLibraries {
 		fmt.Printf(" %s", library.GetName())
 		if err := createLibrary(systemInfo.Key, library.GetMap(), client); err != nil {
@@ -42,6 +42,7 @@ func init() {
 	myInitCommand.flags.StringVar(&DevToken, "dev-token", "", "Developer token to use instead of email/password")
 	myInitCommand.flags.BoolVar(&SkipUpdateMapNameToIdFiles, "skip-update-map-name-to-id", false, "Set this to true to skip pulling the IDs for roles, collections, and users. This is useful if the system has lots of these types of assets and the goal is to retrieve the schema for the tables after initialization.")
 	myInitCommand.flags.StringVar(&flagInitRemoteName, "name", "init", "Name of the initial remote")
+	setMetadataFormatFlag(&myInitCommand.flags)
 	AddCommand("init", myInitCommand)
 }
 
@@ -66,6 +67,10 @@ func reallyInit(cmd *SubCommand, cli *cb.DevClient, sysKey string) error {
 		return err
 	}
 
+	if err := applyMetadataFormatFlag(); err != nil {
+		return err
+	}
+
 	systemMeta, err := pullSystemMeta(sysKey, cli)
 	if err != nil {
 		return err
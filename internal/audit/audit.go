@@ -0,0 +1,248 @@
+// Package audit builds permission reports from the roles of a system and the
+// users and devices they are assigned to.
+package audit
+
+import (
+	"sort"
+
+	"github.com/clearblade/cblib/models/roles"
+)
+
+// Default roles every system has. Anyone holding a session (or no session at
+// all for Anonymous) gets their permissions, so writes granted to them are
+// flagged.
+const (
+	AnonymousRole     = "Anonymous"
+	AuthenticatedRole = "Authenticated"
+	AdministratorRole = "Administrator"
+)
+
+// writeLevels are the levels that modify an asset.
+const writeLevels = roles.Create | roles.Update | roles.Delete
+
+// escalationKinds are kinds that let a role change permissions, its own
+// included.
+var escalationKinds = []string{"roles", "manage_users"}
+
+// Input is what the audit is built from.
+type Input struct {
+	Roles []*roles.Role
+	// UserRoles maps user emails to the names of their roles.
+	UserRoles map[string][]string
+	// DeviceRoles maps device names to the names of their roles.
+	DeviceRoles map[string][]string
+}
+
+// Row is a permission granted by a role on an asset, or on a whole kind of
+// asset when Asset is empty.
+type Row struct {
+	Kind     string   `json:"kind"`
+	Asset    string   `json:"asset,omitempty"`
+	Role     string   `json:"role"`
+	Verbs    []string `json:"verbs"`
+	Users    []string `json:"users"`
+	Devices  []string `json:"devices"`
+	Findings []string `json:"findings,omitempty"`
+
+	level roles.Level
+}
+
+// Finding lists the reasons a role is considered over-privileged.
+type Finding struct {
+	Role    string   `json:"role"`
+	Reasons []string `json:"reasons"`
+}
+
+// Report is the permission matrix of a system.
+type Report struct {
+	Matrix         []*Row     `json:"matrix"`
+	OverPrivileged []*Finding `json:"over_privileged"`
+}
+
+// Build builds the report. Rows are sorted by kind (in roles.Kinds order),
+// asset and role.
+func Build(in Input) (*Report, error) {
+	holders := invert(in.UserRoles)
+	deviceHolders := invert(in.DeviceRoles)
+
+	kindOrder := map[string]int{}
+	for i, kind := range roles.Kinds {
+		kindOrder[kind.Readable] = i
+	}
+
+	report := &Report{Matrix: []*Row{}, OverPrivileged: []*Finding{}}
+	for _, role := range in.Roles {
+		add := func(kind, asset string, level roles.Level) error {
+			if level == 0 {
+				return nil
+			}
+			verbs, err := level.Verbs()
+			if err != nil {
+				return err
+			}
+			row := &Row{
+				Kind:    kind,
+				Asset:   asset,
+				Role:    role.Name,
+				Verbs:   verbs,
+				Users:   orEmpty(holders[role.Name]),
+				Devices: orEmpty(deviceHolders[role.Name]),
+				level:   level,
+			}
+			row.Findings = findings(row)
+			report.Matrix = append(report.Matrix, row)
+			return nil
+		}
+
+		for kind, assets := range role.Permissions.Assets {
+			for _, a := range assets {
+				if err := add(kind, a.Name, a.Level); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for kind, level := range role.Permissions.Kinds {
+			if err := add(kind, "", level); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.SliceStable(report.Matrix, func(i, j int) bool {
+		a, b := report.Matrix[i], report.Matrix[j]
+		if a.Kind != b.Kind {
+			return kindOrder[a.Kind] < kindOrder[b.Kind]
+		}
+		if a.Asset != b.Asset {
+			return a.Asset < b.Asset
+		}
+		return a.Role < b.Role
+	})
+
+	byRole := map[string]*Finding{}
+	for _, row := range report.Matrix {
+		if len(row.Findings) == 0 {
+			continue
+		}
+		finding, ok := byRole[row.Role]
+		if !ok {
+			finding = &Finding{Role: row.Role}
+			byRole[row.Role] = finding
+			report.OverPrivileged = append(report.OverPrivileged, finding)
+		}
+		finding.Reasons = append(finding.Reasons, row.Findings...)
+	}
+	sort.Slice(report.OverPrivileged, func(i, j int) bool {
+		return report.OverPrivileged[i].Role < report.OverPrivileged[j].Role
+	})
+
+	return report, nil
+}
+
+func findings(row *Row) []string {
+	rtn := []string{}
+	writes := row.level & writeLevels
+	if writes == 0 {
+		return rtn
+	}
+
+	target := row.Kind
+	if row.Asset != "" {
+		target += " '" + row.Asset + "'"
+	}
+
+	writeVerbs, _ := writes.Verbs()
+	switch row.Role {
+	case AnonymousRole:
+		rtn = append(rtn, "unauthenticated clients can "+joinVerbs(writeVerbs)+" "+target)
+	case AuthenticatedRole:
+		rtn = append(rtn, "every authenticated user can "+joinVerbs(writeVerbs)+" "+target)
+	}
+
+	if row.Role != AdministratorRole && row.Asset == "" {
+		for _, kind := range escalationKinds {
+			if row.Kind == kind {
+				rtn = append(rtn, "can "+joinVerbs(writeVerbs)+" "+target+" and so escalate its own permissions")
+			}
+		}
+	}
+	return rtn
+}
+
+func joinVerbs(verbs []string) string {
+	rtn := ""
+	for i, v := range verbs {
+		switch {
+		case i == 0:
+		case i == len(verbs)-1:
+			rtn += " and "
+		default:
+			rtn += ", "
+		}
+		rtn += v
+	}
+	return rtn
+}
+
+// invert maps role names to the sorted holders of the role.
+func invert(assignments map[string][]string) map[string][]string {
+	rtn := map[string][]string{}
+	for holder, roleNames := range assignments {
+		for _, name := range roleNames {
+			rtn[name] = append(rtn[name], holder)
+		}
+	}
+	for _, holders := range rtn {
+		sort.Strings(holders)
+	}
+	return rtn
+}
+
+func orEmpty(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// Filter returns the rows matching the given kind, asset and level; empty
+// values match everything. A row matches the level if it grants any of its
+// verbs, so Filter("collections", "orders", Create|Update|Delete) answers
+// "who can write to the orders collection?". Kind-level rows (all
+// collections, ...) are kept when filtering on an asset since they apply to it
+// as well.
+func (r *Report) Filter(kind, asset string, level roles.Level) *Report {
+	rtn := &Report{Matrix: []*Row{}, OverPrivileged: []*Finding{}}
+	rolesKept := map[string]bool{}
+	for _, row := range r.Matrix {
+		if kind != "" && row.Kind != kind && !appliesToEveryAsset(row, kind) {
+			continue
+		}
+		if asset != "" && row.Asset != "" && row.Asset != asset {
+			continue
+		}
+		if level != 0 && row.level&level == 0 {
+			continue
+		}
+		rtn.Matrix = append(rtn.Matrix, row)
+		rolesKept[row.Role] = true
+	}
+	for _, f := range r.OverPrivileged {
+		if rolesKept[f.Role] {
+			rtn.OverPrivileged = append(rtn.OverPrivileged, f)
+		}
+	}
+	return rtn
+}
+
+// kindWideKinds maps per-asset kinds to the kind granting access to all of
+// their assets.
+var kindWideKinds = map[string]string{
+	"collections":        "all_collections",
+	"services":           "all_services",
+	"external_databases": "all_external_databases",
+}
+
+func appliesToEveryAsset(row *Row, kind string) bool {
+	return kindWideKinds[kind] == row.Kind
+}
@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/clearblade/cblib/models/roles"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeRole(name string, assets map[string][]roles.AssetPermission, kinds map[string]roles.Level) *roles.Role {
+	if assets == nil {
+		assets = map[string][]roles.AssetPermission{}
+	}
+	if kinds == nil {
+		kinds = map[string]roles.Level{}
+	}
+	return &roles.Role{Name: name, Permissions: roles.Permissions{Assets: assets, Kinds: kinds}}
+}
+
+func testInput() Input {
+	return Input{
+		Roles: []*roles.Role{
+			makeRole(AuthenticatedRole, map[string][]roles.AssetPermission{
+				"collections": {{Name: "orders", Level: roles.Read | roles.Update}},
+				"services":    {{Name: "report", Level: roles.Read}},
+			}, nil),
+			makeRole(AnonymousRole, nil, map[string]roles.Level{"devices": roles.Read}),
+			makeRole("Operator", map[string][]roles.AssetPermission{
+				"collections": {{Name: "orders", Level: roles.Read | roles.Create}, {Name: "audit", Level: 0}},
+			}, map[string]roles.Level{"roles": roles.Update}),
+			makeRole(AdministratorRole, nil, map[string]roles.Level{"roles": 15, "all_collections": 15}),
+		},
+		UserRoles: map[string][]string{
+			"b@example.com": {AuthenticatedRole, "Operator"},
+			"a@example.com": {AuthenticatedRole},
+		},
+		DeviceRoles: map[string][]string{
+			"sensor": {"Operator"},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	report, err := Build(testInput())
+	require.NoError(t, err)
+
+	summary := []string{}
+	for _, row := range report.Matrix {
+		summary = append(summary, row.Kind+"/"+row.Asset+"/"+row.Role)
+	}
+	assert.Equal(t, []string{
+		"services/report/Authenticated",
+		"collections/orders/Authenticated",
+		"collections/orders/Operator",
+		"devices//Anonymous",
+		"roles//Administrator",
+		"roles//Operator",
+		"all_collections//Administrator",
+	}, summary)
+
+	orders := report.Matrix[1]
+	assert.Equal(t, []string{"read", "update"}, orders.Verbs)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, orders.Users)
+	assert.Equal(t, []string{}, orders.Devices)
+	assert.Equal(t, []string{"every authenticated user can update collections 'orders'"}, orders.Findings)
+
+	assert.Equal(t, []string{"sensor"}, report.Matrix[2].Devices)
+
+	require.Len(t, report.OverPrivileged, 2)
+	assert.Equal(t, AuthenticatedRole, report.OverPrivileged[0].Role)
+	assert.Equal(t, "Operator", report.OverPrivileged[1].Role)
+	assert.Equal(t, []string{"can update roles and so escalate its own permissions"}, report.OverPrivileged[1].Reasons)
+}
+
+func TestFilterWhoCanWrite(t *testing.T) {
+	report, err := Build(testInput())
+	require.NoError(t, err)
+
+	filtered := report.Filter("collections", "orders", roles.Create|roles.Update|roles.Delete)
+	summary := []string{}
+	for _, row := range filtered.Matrix {
+		summary = append(summary, row.Kind+"/"+row.Asset+"/"+row.Role)
+	}
+	assert.Equal(t, []string{
+		"collections/orders/Authenticated",
+		"collections/orders/Operator",
+		"all_collections//Administrator",
+	}, summary)
+	require.Len(t, filtered.OverPrivileged, 2)
+
+	filtered = report.Filter("services", "", roles.Read)
+	require.Len(t, filtered.Matrix, 1)
+	assert.Equal(t, "report", filtered.Matrix[0].Asset)
+}
+
+func TestJoinVerbs(t *testing.T) {
+	assert.Equal(t, "create", joinVerbs([]string{"create"}))
+	assert.Equal(t, "create and delete", joinVerbs([]string{"create", "delete"}))
+	assert.Equal(t, "create, update and delete", joinVerbs([]string{"create", "update", "delete"}))
+}
+
+func TestWriteCSV(t *testing.T) {
+	report, err := Build(testInput())
+	require.NoError(t, err)
+
+	buf := bytes.Buffer{}
+	require.NoError(t, report.Filter("collections", "orders", 0).Write(&buf, FormatCSV))
+	assert.Equal(t, `kind,asset,role,read,create,update,delete,users,devices,findings
+collections,orders,Authenticated,true,false,true,false,a@example.com;b@example.com,,every authenticated user can update collections 'orders'
+collections,orders,Operator,true,true,false,false,b@example.com,sensor,
+all_collections,,Administrator,true,true,true,true,,,
+`, buf.String())
+}
+
+func TestWriteJSONAndText(t *testing.T) {
+	report, err := Build(testInput())
+	require.NoError(t, err)
+
+	buf := bytes.Buffer{}
+	require.NoError(t, report.Filter("devices", "", 0).Write(&buf, FormatJSON))
+	assert.JSONEq(t, `{
+		"matrix": [{"kind": "devices", "role": "Anonymous", "verbs": ["read"], "users": [], "devices": []}],
+		"over_privileged": []
+	}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, report.Filter("devices", "", 0).Write(&buf, FormatText))
+	assert.Contains(t, buf.String(), "No over-privileged roles found")
+
+	assert.Error(t, report.Write(&buf, "xml"))
+}
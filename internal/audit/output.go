@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/clearblade/cblib/internal/reportout"
+)
+
+// Output formats supported by Write.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Formats lists the output formats, the default first.
+var Formats = []string{FormatText, FormatJSON, FormatCSV}
+
+// Write writes the report in the given format.
+func (r *Report) Write(w io.Writer, format string) error {
+	return reportout.Write(w, format, []reportout.Format{
+		{Name: FormatText, Write: r.WriteText},
+		{Name: FormatJSON, Write: r.WriteJSON},
+		{Name: FormatCSV, Write: r.WriteCSV},
+	})
+}
+
+// WriteText writes the matrix as an aligned table followed by the list of
+// over-privileged roles.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "KIND\tASSET\tROLE\tVERBS\tUSERS\tDEVICES\n")
+	for _, row := range r.Matrix {
+		asset := row.Asset
+		if asset == "" {
+			asset = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\n", row.Kind, asset, row.Role,
+			strings.Join(row.Verbs, ","), len(row.Users), len(row.Devices))
+	}
+	err := tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	if len(r.OverPrivileged) == 0 {
+		_, err = fmt.Fprintf(w, "\nNo over-privileged roles found\n")
+		return err
+	}
+
+	fmt.Fprintf(w, "\nOver-privileged roles:\n")
+	for _, f := range r.OverPrivileged {
+		fmt.Fprintf(w, "  %s\n", f.Role)
+		for _, reason := range f.Reasons {
+			fmt.Fprintf(w, "    - %s\n", reason)
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the report as JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	return reportout.WriteJSON(w, r)
+}
+
+// WriteCSV writes one line per row of the matrix. Lists are joined with
+// semicolons and the findings column is empty unless the row makes its role
+// over-privileged.
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"kind", "asset", "role", "read", "create", "update", "delete", "users", "devices", "findings"})
+	if err != nil {
+		return err
+	}
+
+	for _, row := range r.Matrix {
+		record := []string{row.Kind, row.Asset, row.Role}
+		for _, verb := range []string{"read", "create", "update", "delete"} {
+			record = append(record, fmt.Sprint(contains(row.Verbs, verb)))
+		}
+		record = append(record,
+			strings.Join(row.Users, ";"),
+			strings.Join(row.Devices, ";"),
+			strings.Join(row.Findings, ";"))
+
+		err = writer.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+// Package browserlogin implements a browser based login that does not depend
+// on a particular browser being installed. The CLI starts a short lived HTTP
+// listener on the loopback interface and asks the platform login page to
+// redirect the developer token back to it once the user has logged in.
+package browserlogin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// CallbackPath is the path the platform redirects to after a successful
+	// login.
+	CallbackPath = "/callback"
+
+	// DefaultTimeout is how long Login waits for the platform to call back.
+	DefaultTimeout = 5 * time.Minute
+
+	tokenParam    = "token"
+	stateParam    = "state"
+	redirectParam = "redirect_uri"
+)
+
+// ErrStateMismatch answers callbacks carrying a state that does not match the
+// one generated for the login attempt. Anyone can send those, so the login
+// ignores them and keeps waiting.
+var ErrStateMismatch = errors.New("login callback state mismatch")
+
+// Options configures a login attempt.
+type Options struct {
+	// PlatformURL is the base url of the ClearBlade platform.
+	PlatformURL string
+
+	// ListenAddr is the loopback address to listen on. Defaults to
+	// 127.0.0.1:0 (random port).
+	ListenAddr string
+
+	// Timeout bounds the whole login attempt. Defaults to DefaultTimeout.
+	Timeout time.Duration
+
+	// Open is used to open the login url. When nil, or when it fails, the url
+	// is only printed so that the user can open it manually.
+	Open func(loginURL string) error
+
+	// Printf is used to print instructions to the user. Defaults to a no-op.
+	Printf func(format string, args ...interface{})
+}
+
+// LoginURL returns the platform login url that redirects the token to the
+// given callback url.
+func LoginURL(platformURL, callbackURL, state string) string {
+	query := url.Values{}
+	query.Set(redirectParam, callbackURL)
+	query.Set(stateParam, state)
+	return fmt.Sprintf("%s/login?%s", strings.TrimSuffix(platformURL, "/"), query.Encode())
+}
+
+// Login runs a complete loopback login and returns the developer token sent
+// back by the platform.
+func Login(ctx context.Context, opts Options) (string, error) {
+	if opts.PlatformURL == "" {
+		return "", fmt.Errorf("platform url is required for browser login")
+	}
+
+	if opts.ListenAddr == "" {
+		opts.ListenAddr = "127.0.0.1:0"
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	if opts.Printf == nil {
+		opts.Printf = func(string, ...interface{}) {}
+	}
+
+	state, err := newState()
+	if err != nil {
+		return "", err
+	}
+
+	listener, err := net.Listen("tcp", opts.ListenAddr)
+	if err != nil {
+		return "", fmt.Errorf("could not start login listener: %w", err)
+	}
+
+	results := make(chan result, 1)
+	server := &http.Server{Handler: callbackHandler(state, results)}
+	go server.Serve(listener)
+	defer server.Close()
+
+	callbackURL := fmt.Sprintf("http://%s%s", listener.Addr().String(), CallbackPath)
+	loginURL := LoginURL(opts.PlatformURL, callbackURL, state)
+
+	opened := false
+	if opts.Open != nil {
+		opened = opts.Open(loginURL) == nil
+	}
+
+	if opened {
+		opts.Printf("Login in the browser window that was opened. If no window opened, visit:\n\n    %s\n\n", loginURL)
+	} else {
+		opts.Printf("Open the following url in a browser to login:\n\n    %s\n\n", loginURL)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+		return "", fmt.Errorf("login timeout reached before token was received: %w", ctx.Err())
+	case r := <-results:
+		return r.token, r.err
+	}
+}
+
+type result struct {
+	token string
+	err   error
+}
+
+// callbackHandler returns the handler serving CallbackPath. Only the first
+// callback with the right state is reported; other requests are answered but
+// otherwise ignored.
+func callbackHandler(state string, results chan<- result) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(CallbackPath, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Form.Get(stateParam) != state {
+			http.Error(w, ErrStateMismatch.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var res result
+		switch {
+		case r.Form.Get(tokenParam) == "":
+			res.err = fmt.Errorf("login callback did not contain a token")
+		default:
+			res.token = r.Form.Get(tokenParam)
+		}
+
+		select {
+		case results <- res:
+		default:
+		}
+
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", html.EscapeString("Logged in. You can close this window and return to the terminal."))
+	})
+	return mux
+}
+
+func newState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate login state: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
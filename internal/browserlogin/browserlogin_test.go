@@ -0,0 +1,105 @@
+package browserlogin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakePlatform returns a stand-in for the platform login page. It redirects
+// every login request back to the callback with the given token.
+func newFakePlatform(t *testing.T, token string, tamperState bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/login", r.URL.Path)
+
+		redirect, err := url.Parse(r.URL.Query().Get(redirectParam))
+		require.NoError(t, err)
+
+		state := r.URL.Query().Get(stateParam)
+		if tamperState {
+			state = "not-the-state"
+		}
+
+		query := url.Values{}
+		query.Set(tokenParam, token)
+		query.Set(stateParam, state)
+		redirect.RawQuery = query.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	}))
+}
+
+func openWithHTTPGet(loginURL string) error {
+	go func() {
+		resp, err := http.Get(loginURL)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	return nil
+}
+
+func TestLoginReceivesToken(t *testing.T) {
+	platform := newFakePlatform(t, "my-token", false)
+	defer platform.Close()
+
+	token, err := Login(context.Background(), Options{
+		PlatformURL: platform.URL,
+		Timeout:     5 * time.Second,
+		Open:        openWithHTTPGet,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "my-token", token)
+}
+
+func TestLoginIgnoresStateMismatch(t *testing.T) {
+	platform := newFakePlatform(t, "my-token", true)
+	defer platform.Close()
+
+	_, err := Login(context.Background(), Options{
+		PlatformURL: platform.URL,
+		Timeout:     200 * time.Millisecond,
+		Open:        openWithHTTPGet,
+	})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestCallbackHandlerWaitsForState(t *testing.T) {
+	results := make(chan result, 1)
+	handler := callbackHandler("the-state", results)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", CallbackPath+"?state=not-the-state&token=bad", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Empty(t, results)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", CallbackPath+"?state=the-state&token=good", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, results, 1)
+	assert.Equal(t, result{token: "good"}, <-results)
+}
+
+func TestLoginTimeout(t *testing.T) {
+	_, err := Login(context.Background(), Options{
+		PlatformURL: "http://example.invalid",
+		Timeout:     50 * time.Millisecond,
+	})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestLoginRequiresPlatformURL(t *testing.T) {
+	_, err := Login(context.Background(), Options{})
+	assert.Error(t, err)
+}
+
+func TestLoginURL(t *testing.T) {
+	got := LoginURL("https://platform.example.com/", "http://127.0.0.1:1234/callback", "abc")
+	assert.Equal(t, "https://platform.example.com/login?redirect_uri=http%3A%2F%2F127.0.0.1%3A1234%2Fcallback&state=abc", got)
+}
@@ -0,0 +1,20 @@
+package browserlogin
+
+import (
+	"os/exec"
+	"runtime"
+)
+
+// OpenBrowser opens the given url with the default browser of the system.
+func OpenBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
@@ -0,0 +1,269 @@
+// Package build compiles the sources of services kept under src/ into the
+// code/services layout that is pushed to the platform. Each service has an
+// entry point in src/services/<name>/ which is bundled into
+// code/services/<name>/<name>.js and <name>.js.map by a Builder, by default an
+// external bundler command.
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// SrcDir is the directory of the sources, relative to the system root.
+	SrcDir = "src"
+
+	configFile = "build.json"
+	cacheFile  = ".cb-cli/build-cache.json"
+)
+
+// entryNames are the file names tried, in order, to find the entry point of a
+// service. {name} is replaced with the name of the service.
+var entryNames = []string{"{name}.ts", "{name}.js", "index.ts", "index.js"}
+
+// Job is the build of a single service.
+type Job struct {
+	// Name is the name of the service.
+	Name string
+	// Entry is the path of the entry point.
+	Entry string
+	// OutFile is the path of the script to write. Its source map is expected
+	// next to it, at OutFile + ".map".
+	OutFile string
+}
+
+// Builder compiles the entry point of a job into a single script and its
+// source map.
+type Builder interface {
+	Build(job Job) error
+	// Key identifies the builder and its settings. Changing it invalidates
+	// the cache.
+	Key() string
+}
+
+// Options control a build.
+type Options struct {
+	// Force rebuilds services whose inputs didn't change.
+	Force bool
+	// Services limits the build to the services with these names. Every
+	// service is built when empty.
+	Services []string
+}
+
+// Result lists the services that were built and the ones that were up to
+// date.
+type Result struct {
+	Built  []string
+	Cached []string
+}
+
+// HasSources returns true if the system has a src/services directory.
+func HasSources(rootDir string) bool {
+	stat, err := os.Stat(filepath.Join(rootDir, SrcDir, "services"))
+	return err == nil && stat.IsDir()
+}
+
+// HasServiceSources returns true if the service has a src/services/<name>
+// directory.
+func HasServiceSources(rootDir, name string) bool {
+	stat, err := os.Stat(filepath.Join(rootDir, SrcDir, "services", name))
+	return err == nil && stat.IsDir()
+}
+
+// Build builds the services of the system rooted at rootDir. Services whose
+// sources (anything under src/ other than node_modules) and builder didn't
+// change since their last build are skipped, unless opts.Force is set.
+func Build(rootDir string, builder Builder, opts Options) (*Result, error) {
+	result := &Result{Built: []string{}, Cached: []string{}}
+	if !HasSources(rootDir) {
+		return result, nil
+	}
+
+	jobs, err := findJobs(rootDir, opts.Services)
+	if err != nil {
+		return nil, err
+	}
+
+	srcHash, err := hashDir(filepath.Join(rootDir, SrcDir))
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := loadCache(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, job := range jobs {
+		hash := hashStrings(srcHash, builder.Key(), job.Name)
+		if !opts.Force && cache[job.Name] == hash && outputsExist(job) {
+			result.Cached = append(result.Cached, job.Name)
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(job.OutFile), 0777); err != nil {
+			return result, err
+		}
+		if err := builder.Build(job); err != nil {
+			saveCache(rootDir, cache)
+			return result, fmt.Errorf("could not build service '%s': %s", job.Name, err)
+		}
+		if _, err := os.Stat(job.OutFile); err != nil {
+			saveCache(rootDir, cache)
+			return result, fmt.Errorf("could not build service '%s': builder did not write %s", job.Name, job.OutFile)
+		}
+
+		cache[job.Name] = hash
+		result.Built = append(result.Built, job.Name)
+	}
+
+	return result, saveCache(rootDir, cache)
+}
+
+// findJobs returns a job for every service directory under src/services,
+// sorted by name.
+func findJobs(rootDir string, only []string) ([]Job, error) {
+	servicesDir := filepath.Join(rootDir, SrcDir, "services")
+	entries, err := os.ReadDir(servicesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]bool{}
+	jobs := []Job{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		found[name] = true
+		if len(only) > 0 && !slices.Contains(only, name) {
+			continue
+		}
+
+		entryPath, err := findEntry(filepath.Join(servicesDir, name), name)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, Job{
+			Name:    name,
+			Entry:   entryPath,
+			OutFile: filepath.Join(rootDir, "code", "services", name, name+".js"),
+		})
+	}
+
+	for _, name := range only {
+		if !found[name] {
+			return nil, fmt.Errorf("no sources for service '%s' in %s", name, servicesDir)
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs, nil
+}
+
+func findEntry(dir, name string) (string, error) {
+	tried := []string{}
+	for _, entryName := range entryNames {
+		entryName = strings.ReplaceAll(entryName, "{name}", name)
+		candidate := filepath.Join(dir, entryName)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+		tried = append(tried, entryName)
+	}
+	return "", fmt.Errorf("no entry point for service '%s' in %s; expected one of %s", name, dir, strings.Join(tried, ", "))
+}
+
+func outputsExist(job Job) bool {
+	for _, file := range []string{job.OutFile, job.OutFile + ".map"} {
+		if _, err := os.Stat(file); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// hashDir hashes the relative paths and contents of the files in dir,
+// ignoring node_modules directories.
+func hashDir(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00", filepath.ToSlash(rel))
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(h, f)
+		h.Write([]byte{0})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashStrings(strs ...string) string {
+	h := sha256.New()
+	for _, s := range strs {
+		fmt.Fprintf(h, "%s\x00", s)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// loadCache returns the input hashes of the last successful build of each
+// service.
+func loadCache(rootDir string) (map[string]string, error) {
+	cache := map[string]string{}
+	data, err := os.ReadFile(filepath.Join(rootDir, cacheFile))
+	if os.IsNotExist(err) {
+		return cache, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &cache); err != nil {
+		// a corrupt cache only costs a rebuild
+		return map[string]string{}, nil
+	}
+	return cache, nil
+}
+
+func saveCache(rootDir string, cache map[string]string) error {
+	data, err := json.MarshalIndent(cache, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Join(rootDir, cacheFile)
+	if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0666)
+}
@@ -0,0 +1,154 @@
+package build
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBuilder struct {
+	key   string
+	built []string
+}
+
+func (b *fakeBuilder) Key() string { return b.key }
+
+func (b *fakeBuilder) Build(job Job) error {
+	b.built = append(b.built, job.Name)
+	src, err := os.ReadFile(job.Entry)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(job.OutFile, src, 0666); err != nil {
+		return err
+	}
+	return os.WriteFile(job.OutFile+".map", []byte("{}"), 0666)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0777))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0666))
+}
+
+func TestBuildWithoutSources(t *testing.T) {
+	root := t.TempDir()
+	result, err := Build(root, &fakeBuilder{}, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, result.Built)
+	assert.False(t, HasSources(root))
+}
+
+func TestHasServiceSources(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "src/services/a/index.ts"), "export default function a() {}")
+	assert.True(t, HasServiceSources(root, "a"))
+	assert.False(t, HasServiceSources(root, "b"))
+}
+
+func TestBuildCaches(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "src/services/a/index.ts"), "export default function a() {}")
+	writeFile(t, filepath.Join(root, "src/services/b/b.js"), "function b() {}")
+	writeFile(t, filepath.Join(root, "src/node_modules/x/index.js"), "ignored")
+
+	builder := &fakeBuilder{key: "v1"}
+	result, err := Build(root, builder, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, result.Built)
+
+	out, err := os.ReadFile(filepath.Join(root, "code/services/a/a.js"))
+	require.NoError(t, err)
+	assert.Equal(t, "export default function a() {}", string(out))
+
+	// nothing changed
+	result, err = Build(root, builder, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, result.Built)
+	assert.Equal(t, []string{"a", "b"}, result.Cached)
+
+	// node_modules isn't an input
+	writeFile(t, filepath.Join(root, "src/node_modules/x/index.js"), "changed")
+	result, err = Build(root, builder, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, result.Built)
+
+	// sources changed
+	writeFile(t, filepath.Join(root, "src/shared/util.ts"), "export const x = 1")
+	result, err = Build(root, builder, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, result.Built)
+
+	// builder changed
+	builder.key = "v2"
+	result, err = Build(root, builder, Options{Services: []string{"b"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, result.Built)
+
+	// output removed
+	require.NoError(t, os.Remove(filepath.Join(root, "code/services/b/b.js.map")))
+	result, err = Build(root, builder, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, result.Built)
+
+	result, err = Build(root, builder, Options{Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, result.Built)
+}
+
+func TestBuildErrors(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "src/services/a/main.ts"), "")
+
+	_, err := Build(root, &fakeBuilder{}, Options{})
+	assert.Error(t, err)
+
+	writeFile(t, filepath.Join(root, "src/services/a/a.ts"), "")
+	_, err = Build(root, &fakeBuilder{}, Options{Services: []string{"missing"}})
+	assert.Error(t, err)
+}
+
+func TestLoadConfig(t *testing.T) {
+	root := t.TempDir()
+	config, err := LoadConfig(root)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultCommand, NewCommandBuilder(config, root).Args)
+
+	writeFile(t, filepath.Join(root, "src/build.json"), `{"command": ["tsc", "{entry}"]}`)
+	config, err = LoadConfig(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tsc", "{entry}"}, config.Command)
+
+	writeFile(t, filepath.Join(root, "src/build.json"), `{"command": "tsc"}`)
+	_, err = LoadConfig(root)
+	assert.Error(t, err)
+}
+
+func TestCommandBuilder(t *testing.T) {
+	if _, err := exec.LookPath("cp"); err != nil {
+		t.Skip("cp not available")
+	}
+
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "src/services/svc/svc.js"), "function svc() {}")
+	var stderr bytes.Buffer
+	builder := &CommandBuilder{Args: []string{"cp", "{entry}", "{outfile}"}, Dir: root, Stderr: &stderr}
+
+	// the copy has no source map, so it is rebuilt every time
+	result, err := Build(root, builder, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"svc"}, result.Built)
+
+	out, err := os.ReadFile(filepath.Join(root, "code/services/svc/svc.js"))
+	require.NoError(t, err)
+	assert.Equal(t, "function svc() {}", string(out))
+
+	builder.Args = []string{"does-not-exist-bundler", "{entry}"}
+	_, err = Build(root, builder, Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bundler 'does-not-exist-bundler' not found")
+}
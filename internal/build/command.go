@@ -0,0 +1,110 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/clearblade/cblib/internal/fsutil"
+)
+
+// DefaultCommand bundles a service with esbuild. The entry point is expected to
+// export the service function, either as default or under the service's name;
+// the footer makes it a global function as the platform expects.
+var DefaultCommand = []string{
+	"esbuild", "{entry}",
+	"--bundle",
+	"--sourcemap",
+	"--format=iife",
+	"--target=es2015",
+	"--global-name=__{name}",
+	"--footer:js=var {name} = __{name}.default || __{name}.{name};",
+	"--outfile={outfile}",
+}
+
+// Config is the build configuration, read from src/build.json.
+type Config struct {
+	// Command is the bundler command line. {entry}, {outfile} and {name} are
+	// replaced with the entry point, the script to write and the name of the
+	// service. Defaults to DefaultCommand.
+	Command []string `json:"command,omitempty"`
+}
+
+// LoadConfig reads src/build.json. The file is optional.
+func LoadConfig(rootDir string) (*Config, error) {
+	config := &Config{}
+	file := filepath.Join(rootDir, SrcDir, configFile)
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", file, err)
+	}
+	return config, nil
+}
+
+// CommandBuilder builds services by running an external command.
+type CommandBuilder struct {
+	Args []string
+	// Dir is the directory the command runs in, usually the system root so
+	// that node_modules/.bin tools resolve.
+	Dir    string
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// NewCommandBuilder returns the builder of the configuration.
+func NewCommandBuilder(config *Config, dir string) *CommandBuilder {
+	args := config.Command
+	if len(args) == 0 {
+		args = DefaultCommand
+	}
+	return &CommandBuilder{Args: args, Dir: dir, Stdout: os.Stdout, Stderr: os.Stderr}
+}
+
+func (b *CommandBuilder) Key() string {
+	return strings.Join(b.Args, "\x00")
+}
+
+func (b *CommandBuilder) Build(job Job) error {
+	args := b.expand(job)
+	program, err := b.lookPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(program, args[1:]...)
+	cmd.Dir = b.Dir
+	cmd.Stdout = b.Stdout
+	cmd.Stderr = b.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %s", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+func (b *CommandBuilder) expand(job Job) []string {
+	replacer := strings.NewReplacer("{entry}", job.Entry, "{outfile}", job.OutFile, "{name}", job.Name)
+	args := make([]string, len(b.Args))
+	for i, arg := range b.Args {
+		args[i] = replacer.Replace(arg)
+	}
+	return args
+}
+
+// lookPath finds the program in node_modules/.bin first, then in PATH.
+func (b *CommandBuilder) lookPath(program string) (string, error) {
+	path, err := fsutil.LookPath(b.Dir, program)
+	if err != nil {
+		return "", fmt.Errorf("bundler '%s' not found; install it or set \"command\" in %s", program, filepath.Join(SrcDir, configFile))
+	}
+	return path, nil
+}
@@ -0,0 +1,220 @@
+// Package drift compares the asset metadata of two systems (usually the same
+// system deployed on different remotes) and reports how far they have drifted.
+package drift
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/clearblade/cblib/diff"
+)
+
+// Status describes how an asset compares between system A and system B.
+type Status string
+
+const (
+	OnlyInA   Status = "only-in-a"
+	OnlyInB   Status = "only-in-b"
+	Differs   Status = "differs"
+	Identical Status = "identical"
+)
+
+// Categories lists the asset categories known to the drift report, in the
+// order they are reported.
+var Categories = []string{
+	"services",
+	"libraries",
+	"collections",
+	"roles",
+	"triggers",
+	"timers",
+	"webhooks",
+	"deployments",
+	"portals",
+	"plugins",
+	"shared-caches",
+	"external-databases",
+}
+
+// volatileKeys holds metadata keys that are expected to differ between systems
+// (ids, keys, timestamps) and are ignored when comparing assets.
+var volatileKeys = map[string]struct{}{
+	"id":            {},
+	"ID":            {},
+	"item_id":       {},
+	"collectionID":  {},
+	"collection_id": {},
+	"appID":         {},
+	"system_key":    {},
+	"systemKey":     {},
+	"system_secret": {},
+	"systemSecret":  {},
+	"created_at":    {},
+	"updated_at":    {},
+	"last_updated":  {},
+	"user_id":       {},
+}
+
+// Snapshot holds the metadata of a system, indexed by category and asset name.
+type Snapshot map[string]map[string]interface{}
+
+// NewSnapshot returns an empty snapshot.
+func NewSnapshot() Snapshot {
+	return make(Snapshot)
+}
+
+// Add stores the metadata of a single asset.
+func (s Snapshot) Add(category, name string, meta interface{}) {
+	if _, ok := s[category]; !ok {
+		s[category] = make(map[string]interface{})
+	}
+	s[category][name] = meta
+}
+
+// AddList stores a list of assets as returned by the platform. The name of
+// each asset is read from nameKey.
+func (s Snapshot) AddList(category, nameKey string, items []interface{}) error {
+	if _, ok := s[category]; !ok {
+		s[category] = make(map[string]interface{})
+	}
+
+	for _, item := range items {
+		asMap, ok := item.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected asset to be a map, got %T", category, item)
+		}
+
+		name, ok := asMap[nameKey].(string)
+		if !ok {
+			return fmt.Errorf("%s: asset has no string key '%s'", category, nameKey)
+		}
+
+		s[category][name] = asMap
+	}
+
+	return nil
+}
+
+func (s Snapshot) names(category string) []string {
+	names := make([]string, 0, len(s[category]))
+	for name := range s[category] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Entry is a single row of the drift matrix.
+type Entry struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	Status   Status `json:"status"`
+}
+
+// Report is the drift matrix between system A and system B.
+type Report struct {
+	A       string  `json:"a"`
+	B       string  `json:"b"`
+	Entries []Entry `json:"entries"`
+}
+
+// Compare builds the drift report between the given snapshots. When
+// categories is empty every known category is compared.
+func Compare(nameA string, a Snapshot, nameB string, b Snapshot, categories []string) *Report {
+	if len(categories) == 0 {
+		categories = Categories
+	}
+
+	report := &Report{A: nameA, B: nameB, Entries: make([]Entry, 0)}
+
+	for _, category := range categories {
+		namesA := a.names(category)
+		namesB := b.names(category)
+
+		d := &diff.StringDiff{After: namesA, Before: namesB}
+		diff.Diff(d)
+
+		onlyInA := toSet(d.Added)
+		onlyInB := toSet(d.Removed)
+
+		entries := make([]Entry, 0, len(namesA)+len(d.Removed))
+		for _, name := range namesA {
+			entry := Entry{Category: category, Name: name}
+			if _, ok := onlyInA[name]; ok {
+				entry.Status = OnlyInA
+			} else if Same(a[category][name], b[category][name]) {
+				entry.Status = Identical
+			} else {
+				entry.Status = Differs
+			}
+			entries = append(entries, entry)
+		}
+
+		for name := range onlyInB {
+			entries = append(entries, Entry{Category: category, Name: name, Status: OnlyInB})
+		}
+
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Name < entries[j].Name
+		})
+
+		report.Entries = append(report.Entries, entries...)
+	}
+
+	return report
+}
+
+// Same returns true if both metadata values are equal, ignoring volatile keys.
+func Same(a, b interface{}) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func normalize(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		rtn := make(map[string]interface{}, len(value))
+		for key, inner := range value {
+			if _, ok := volatileKeys[key]; ok {
+				continue
+			}
+			rtn[key] = normalize(inner)
+		}
+		return rtn
+	case []interface{}:
+		rtn := make([]interface{}, len(value))
+		for i, inner := range value {
+			rtn[i] = normalize(inner)
+		}
+		return rtn
+	default:
+		return v
+	}
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+// Counts returns the number of entries per status.
+func (r *Report) Counts() map[Status]int {
+	counts := make(map[Status]int, 4)
+	for _, e := range r.Entries {
+		counts[e.Status]++
+	}
+	return counts
+}
+
+// HasDrift returns true if any asset isn't identical in both systems.
+func (r *Report) HasDrift() bool {
+	for _, e := range r.Entries {
+		if e.Status != Identical {
+			return true
+		}
+	}
+	return false
+}
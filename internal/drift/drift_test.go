@@ -0,0 +1,103 @@
+package drift
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeSnapshots(t *testing.T) (Snapshot, Snapshot) {
+	a := NewSnapshot()
+	require.NoError(t, a.AddList("services", "name", []interface{}{
+		map[string]interface{}{"name": "same", "code": "x", "system_key": "aaa"},
+		map[string]interface{}{"name": "changed", "code": "new"},
+		map[string]interface{}{"name": "onlyA", "code": "x"},
+	}))
+	a.Add("roles", "Admin", map[string]interface{}{"Name": "Admin", "ID": "1"})
+
+	b := NewSnapshot()
+	require.NoError(t, b.AddList("services", "name", []interface{}{
+		map[string]interface{}{"name": "same", "code": "x", "system_key": "bbb"},
+		map[string]interface{}{"name": "changed", "code": "old"},
+		map[string]interface{}{"name": "onlyB", "code": "x"},
+	}))
+	b.Add("roles", "Admin", map[string]interface{}{"Name": "Admin", "ID": "2"})
+
+	return a, b
+}
+
+func TestCompare(t *testing.T) {
+	a, b := makeSnapshots(t)
+
+	report := Compare("dev", a, "prod", b, []string{"services", "roles"})
+
+	assert.Equal(t, []Entry{
+		{Category: "services", Name: "changed", Status: Differs},
+		{Category: "services", Name: "onlyA", Status: OnlyInA},
+		{Category: "services", Name: "onlyB", Status: OnlyInB},
+		{Category: "services", Name: "same", Status: Identical},
+		{Category: "roles", Name: "Admin", Status: Identical},
+	}, report.Entries)
+	assert.True(t, report.HasDrift())
+	assert.Equal(t, map[Status]int{Differs: 1, OnlyInA: 1, OnlyInB: 1, Identical: 2}, report.Counts())
+}
+
+func TestCompareCategoryFilter(t *testing.T) {
+	a, b := makeSnapshots(t)
+
+	report := Compare("dev", a, "prod", b, []string{"roles"})
+	require.Len(t, report.Entries, 1)
+	assert.False(t, report.HasDrift())
+}
+
+func TestCompareAllCategories(t *testing.T) {
+	a, b := makeSnapshots(t)
+
+	report := Compare("dev", a, "prod", b, nil)
+	assert.Len(t, report.Entries, 5)
+}
+
+func TestSameIgnoresVolatileKeysRecursively(t *testing.T) {
+	a := map[string]interface{}{"name": "x", "items": []interface{}{map[string]interface{}{"id": "1", "v": 1.0}}}
+	b := map[string]interface{}{"name": "x", "items": []interface{}{map[string]interface{}{"id": "2", "v": 1.0}}}
+	assert.True(t, Same(a, b))
+
+	b["items"].([]interface{})[0].(map[string]interface{})["v"] = 2.0
+	assert.False(t, Same(a, b))
+}
+
+func TestAddListErrors(t *testing.T) {
+	s := NewSnapshot()
+	assert.Error(t, s.AddList("services", "name", []interface{}{"not a map"}))
+	assert.Error(t, s.AddList("services", "name", []interface{}{map[string]interface{}{"other": "x"}}))
+}
+
+func TestValidateCategories(t *testing.T) {
+	assert.NoError(t, ValidateCategories([]string{"services", "roles"}))
+	assert.Error(t, ValidateCategories([]string{"services", "nope"}))
+}
+
+func TestWrite(t *testing.T) {
+	a, b := makeSnapshots(t)
+	report := Compare("dev", a, "prod", b, []string{"services"})
+
+	var text bytes.Buffer
+	require.NoError(t, report.Write(&text, FormatText))
+	assert.Contains(t, text.String(), "only in dev")
+	assert.Contains(t, text.String(), "1 only in dev, 1 only in prod, 1 differ, 1 identical")
+
+	var jsonOut bytes.Buffer
+	require.NoError(t, report.Write(&jsonOut, FormatJSON))
+	decoded := Report{}
+	require.NoError(t, json.Unmarshal(jsonOut.Bytes(), &decoded))
+	assert.Equal(t, report, &decoded)
+
+	var html bytes.Buffer
+	require.NoError(t, report.Write(&html, FormatHTML))
+	assert.Contains(t, html.String(), `<tr class="differs"><td>services</td><td>changed</td><td>differs</td></tr>`)
+
+	assert.Error(t, report.Write(&text, "xml"))
+}
@@ -0,0 +1,117 @@
+package drift
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"text/tabwriter"
+
+	"github.com/clearblade/cblib/internal/reportout"
+)
+
+// Output formats supported by Write.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatHTML = "html"
+)
+
+// Formats lists the output formats, the default first.
+var Formats = []string{FormatText, FormatJSON, FormatHTML}
+
+// ValidateCategories returns an error if any of the given categories is unknown.
+func ValidateCategories(categories []string) error {
+	known := toSet(Categories)
+	for _, c := range categories {
+		if _, ok := known[c]; !ok {
+			return fmt.Errorf("unknown asset category '%s', expected one of %v", c, Categories)
+		}
+	}
+	return nil
+}
+
+// Write writes the report in the given format.
+func (r *Report) Write(w io.Writer, format string) error {
+	return reportout.Write(w, format, []reportout.Format{
+		{Name: FormatText, Write: r.WriteText},
+		{Name: FormatJSON, Write: r.WriteJSON},
+		{Name: FormatHTML, Write: r.WriteHTML},
+	})
+}
+
+// WriteText writes the report as an aligned table followed by a summary.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "CATEGORY\tNAME\tSTATUS\n")
+	for _, e := range r.Entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Category, e.Name, r.describe(e.Status))
+	}
+	err := tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	counts := r.Counts()
+	_, err = fmt.Fprintf(w, "\n%d only in %s, %d only in %s, %d differ, %d identical\n",
+		counts[OnlyInA], r.A, counts[OnlyInB], r.B, counts[Differs], counts[Identical])
+	return err
+}
+
+// WriteJSON writes the report as JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	return reportout.WriteJSON(w, r)
+}
+
+var htmlTemplate = template.Must(template.New("drift").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Drift report: {{.A}} vs {{.B}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.only-in-a { background: #e6f0ff; }
+.only-in-b { background: #fff4e0; }
+.differs { background: #ffe6e6; }
+.identical { background: #e9f9e9; }
+</style>
+</head>
+<body>
+<h1>Drift report: {{.A}} vs {{.B}}</h1>
+<table>
+<tr><th>Category</th><th>Name</th><th>Status</th></tr>
+{{range .Rows}}<tr class="{{.Status}}"><td>{{.Category}}</td><td>{{.Name}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type htmlRow struct {
+	Entry
+	Description string
+}
+
+// WriteHTML writes the report as a standalone HTML page.
+func (r *Report) WriteHTML(w io.Writer) error {
+	rows := make([]htmlRow, 0, len(r.Entries))
+	for _, e := range r.Entries {
+		rows = append(rows, htmlRow{Entry: e, Description: r.describe(e.Status)})
+	}
+
+	return htmlTemplate.Execute(w, struct {
+		A, B string
+		Rows []htmlRow
+	}{r.A, r.B, rows})
+}
+
+func (r *Report) describe(status Status) string {
+	switch status {
+	case OnlyInA:
+		return "only in " + r.A
+	case OnlyInB:
+		return "only in " + r.B
+	default:
+		return string(status)
+	}
+}
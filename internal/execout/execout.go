@@ -0,0 +1,192 @@
+// Package execout formats the result of a code service call made by the exec
+// and test commands.
+package execout
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/clearblade/cblib/internal/reportout"
+)
+
+// Output formats.
+const (
+	// FormatText is a human readable summary.
+	FormatText = "text"
+	// FormatPretty is the whole result as indented JSON.
+	FormatPretty = "pretty"
+	// FormatRaw is the results of the service only, as compact JSON.
+	FormatRaw = "raw"
+)
+
+// Formats lists the output formats, the default first.
+var Formats = []string{FormatText, FormatPretty, FormatRaw}
+
+// Result is the outcome of a service call.
+type Result struct {
+	Service    string      `json:"service"`
+	Success    bool        `json:"success"`
+	Results    interface{} `json:"results"`
+	Logs       []string    `json:"logs,omitempty"`
+	DurationMs int64       `json:"duration_ms"`
+}
+
+// FromResponse builds the result of the platform's response to a service
+// call. Results holding a JSON document are decoded.
+func FromResponse(service string, resp map[string]interface{}, duration time.Duration) *Result {
+	success, _ := resp["success"].(bool)
+	return &Result{
+		Service:    service,
+		Success:    success,
+		Results:    decodeResults(resp["results"]),
+		Logs:       logLines(resp["logs"]),
+		DurationMs: duration.Milliseconds(),
+	}
+}
+
+// Write writes the result in the given format.
+func Write(w io.Writer, result *Result, format string) error {
+	return reportout.Write(w, format, []reportout.Format{
+		{Name: FormatText, Write: result.writeText},
+		{Name: FormatPretty, Write: func(w io.Writer) error { return reportout.WriteJSON(w, result) }},
+		{Name: FormatRaw, Write: result.writeRaw},
+	})
+}
+
+func (result *Result) writeText(w io.Writer) error {
+	status := "succeeded"
+	if !result.Success {
+		status = "failed"
+	}
+	fmt.Fprintf(w, "Service '%s' %s in %dms\n", result.Service, status, result.DurationMs)
+	fmt.Fprintf(w, "Results:\n%s\n", indent(textValue(result.Results)))
+	if len(result.Logs) > 0 {
+		fmt.Fprintf(w, "Logs:\n%s\n", indent(strings.Join(result.Logs, "\n")))
+	}
+	return nil
+}
+
+func (result *Result) writeRaw(w io.Writer) error {
+	data, err := json.Marshal(result.Results)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// decodeResults returns the JSON document held by string results, or the
+// results themselves.
+func decodeResults(results interface{}) interface{} {
+	s, ok := results.(string)
+	if !ok {
+		return results
+	}
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return results
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(trimmed), &decoded); err != nil {
+		return results
+	}
+	return decoded
+}
+
+// logLines returns the lines of the logs of a response, which are either a
+// string or a list of entries.
+func logLines(logs interface{}) []string {
+	switch l := logs.(type) {
+	case nil:
+		return nil
+	case string:
+		if l == "" {
+			return nil
+		}
+		return strings.Split(strings.TrimRight(l, "\n"), "\n")
+	case []interface{}:
+		lines := []string{}
+		for _, entry := range l {
+			lines = append(lines, FormatLogEntry(entry))
+		}
+		return lines
+	default:
+		return []string{fmt.Sprint(l)}
+	}
+}
+
+// FormatLogEntry returns a log entry of the platform's log endpoints as a
+// single line: its time and message when it has them, JSON otherwise.
+func FormatLogEntry(entry interface{}) string {
+	switch e := entry.(type) {
+	case string:
+		return e
+	case map[string]interface{}:
+		message, hasMessage := firstString(e, "log", "message", "msg")
+		if !hasMessage {
+			break
+		}
+		if timestamp, ok := firstString(e, "timestamp", "time", "created_at"); ok {
+			return timestamp + " " + message
+		}
+		return message
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Sprint(entry)
+	}
+	return string(data)
+}
+
+// NewLogEntries returns the entries not seen before, as lines, and marks
+// them as seen.
+func NewLogEntries(entries []interface{}, seen map[string]bool) []string {
+	lines := []string{}
+	for _, entry := range entries {
+		key, err := json.Marshal(entry)
+		if err != nil {
+			key = []byte(fmt.Sprint(entry))
+		}
+		if seen[string(key)] {
+			continue
+		}
+		seen[string(key)] = true
+		lines = append(lines, FormatLogEntry(entry))
+	}
+	return lines
+}
+
+func firstString(m map[string]interface{}, keys ...string) (string, bool) {
+	for _, key := range keys {
+		if s, ok := m[key].(string); ok {
+			return s, true
+		}
+		if f, ok := m[key].(float64); ok {
+			return fmt.Sprint(int64(f)), true
+		}
+	}
+	return "", false
+}
+
+func textValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func indent(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = "    " + line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,57 @@
+package execout
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromResponse(t *testing.T) {
+	result := FromResponse("svc", map[string]interface{}{
+		"success": true,
+		"results": `{"count": 2}`,
+		"logs":    []interface{}{"started", map[string]interface{}{"timestamp": "t1", "log": "done"}},
+	}, 1500*time.Millisecond)
+
+	assert.True(t, result.Success)
+	assert.Equal(t, map[string]interface{}{"count": float64(2)}, result.Results)
+	assert.Equal(t, []string{"started", "t1 done"}, result.Logs)
+	assert.Equal(t, int64(1500), result.DurationMs)
+
+	result = FromResponse("svc", map[string]interface{}{"success": false, "results": "not json", "logs": "a\nb\n"}, 0)
+	assert.False(t, result.Success)
+	assert.Equal(t, "not json", result.Results)
+	assert.Equal(t, []string{"a", "b"}, result.Logs)
+}
+
+func TestWrite(t *testing.T) {
+	result := &Result{Service: "svc", Success: false, Results: map[string]interface{}{"error": "bad"}, Logs: []string{"line"}, DurationMs: 12}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, result, FormatText))
+	assert.Equal(t, "Service 'svc' failed in 12ms\nResults:\n    {\n        \"error\": \"bad\"\n    }\nLogs:\n    line\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, result, FormatRaw))
+	assert.Equal(t, "{\"error\":\"bad\"}\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, result, FormatPretty))
+	assert.Contains(t, buf.String(), "\"duration_ms\": 12")
+	assert.Contains(t, buf.String(), "\"success\": false")
+
+	assert.Error(t, Write(&buf, result, "xml"))
+	assert.EqualError(t, Write(&buf, result, "xml"), "unknown output format 'xml'; must be one of text, pretty, raw")
+}
+
+func TestNewLogEntries(t *testing.T) {
+	seen := map[string]bool{}
+	entries := []interface{}{map[string]interface{}{"log": "a"}, map[string]interface{}{"other": 1}}
+	assert.Equal(t, []string{"a", `{"other":1}`}, NewLogEntries(entries, seen))
+
+	entries = append(entries, "b")
+	assert.Equal(t, []string{"b"}, NewLogEntries(entries, seen))
+}
@@ -0,0 +1,20 @@
+package fsutil
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// LookPath finds the program in the node_modules/.bin directory of dir first,
+// where npm installs the tools of a project, then in PATH.
+func LookPath(dir, program string) (string, error) {
+	if !strings.ContainsRune(program, filepath.Separator) {
+		local := filepath.Join(dir, "node_modules", ".bin", program)
+		if _, err := os.Stat(local); err == nil {
+			return local, nil
+		}
+	}
+	return exec.LookPath(program)
+}
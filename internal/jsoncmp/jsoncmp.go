@@ -0,0 +1,143 @@
+// Package jsoncmp reports the semantic differences between two JSON
+// documents: object keys are unordered and numbers compare by value, so only
+// changes of content are reported.
+package jsoncmp
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Kinds of difference.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Difference is a value which differs between the two documents. Path is a
+// JSON pointer to the value.
+type Difference struct {
+	Path   string
+	Kind   string
+	Before interface{}
+	After  interface{}
+}
+
+func (d Difference) String() string {
+	switch d.Kind {
+	case Added:
+		return fmt.Sprintf("%s: added %s", d.Path, Compact(d.After))
+	case Removed:
+		return fmt.Sprintf("%s: removed %s", d.Path, Compact(d.Before))
+	default:
+		return fmt.Sprintf("%s: changed from %s to %s", d.Path, Compact(d.Before), Compact(d.After))
+	}
+}
+
+// Compare returns the differences between before and after, sorted by path.
+// Values of any Go type are compared as their JSON encoding.
+func Compare(before, after interface{}) ([]Difference, error) {
+	normalizedBefore, err := normalize(before)
+	if err != nil {
+		return nil, err
+	}
+	normalizedAfter, err := normalize(after)
+	if err != nil {
+		return nil, err
+	}
+
+	differences := []Difference{}
+	compare("", normalizedBefore, normalizedAfter, &differences)
+	sort.SliceStable(differences, func(i, j int) bool { return differences[i].Path < differences[j].Path })
+	return differences, nil
+}
+
+func compare(path string, before, after interface{}, differences *[]Difference) {
+	switch b := before.(type) {
+	case map[string]interface{}:
+		a, ok := after.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for key, value := range b {
+			if afterValue, found := a[key]; found {
+				compare(path+"/"+escape(key), value, afterValue, differences)
+			} else {
+				*differences = append(*differences, Difference{Path: path + "/" + escape(key), Kind: Removed, Before: value})
+			}
+		}
+		for key, value := range a {
+			if _, found := b[key]; !found {
+				*differences = append(*differences, Difference{Path: path + "/" + escape(key), Kind: Added, After: value})
+			}
+		}
+		return
+
+	case []interface{}:
+		a, ok := after.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(b) || i < len(a); i++ {
+			elementPath := path + "/" + strconv.Itoa(i)
+			switch {
+			case i >= len(a):
+				*differences = append(*differences, Difference{Path: elementPath, Kind: Removed, Before: b[i]})
+			case i >= len(b):
+				*differences = append(*differences, Difference{Path: elementPath, Kind: Added, After: a[i]})
+			default:
+				compare(elementPath, b[i], a[i], differences)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*differences = append(*differences, Difference{Path: path, Kind: Changed, Before: before, After: after})
+	}
+}
+
+// escape escapes a key for a JSON pointer.
+func escape(key string) string {
+	escaped := []rune{}
+	for _, r := range key {
+		switch r {
+		case '~':
+			escaped = append(escaped, '~', '0')
+		case '/':
+			escaped = append(escaped, '~', '1')
+		default:
+			escaped = append(escaped, r)
+		}
+	}
+	return string(escaped)
+}
+
+func normalize(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var rtn interface{}
+	if err := json.Unmarshal(data, &rtn); err != nil {
+		return nil, err
+	}
+	return rtn, nil
+}
+
+// Compact returns the value as compact JSON, shortened if long.
+func Compact(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	const max = 120
+	if len(data) > max {
+		return string(data[:max]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,43 @@
+package jsoncmp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, s string) interface{} {
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func TestCompare(t *testing.T) {
+	differences, err := Compare(
+		parse(t, `{"a": 1, "b": {"c": [1, 2, 3], "d/e": "x"}, "f": null, "g": "same"}`),
+		map[string]interface{}{"g": "same", "a": 1.0, "b": map[string]interface{}{"c": []int{1, 5}, "d/e": "y"}, "h": true},
+	)
+	require.NoError(t, err)
+
+	strings := []string{}
+	for _, difference := range differences {
+		strings = append(strings, difference.String())
+	}
+	assert.Equal(t, []string{
+		"/b/c/1: changed from 2 to 5",
+		"/b/c/2: removed 3",
+		`/b/d~1e: changed from "x" to "y"`,
+		"/f: removed null",
+		"/h: added true",
+	}, strings)
+
+	differences, err = Compare(parse(t, `{"a": {"b": 1}}`), parse(t, `{"a": [1]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []Difference{{Path: "/a", Kind: Changed, Before: map[string]interface{}{"b": 1.0}, After: []interface{}{1.0}}}, differences)
+
+	differences, err = Compare(parse(t, `{"b": 1, "a": [{"x": 1}]}`), parse(t, `{"a": [{"x": 1}], "b": 1}`))
+	require.NoError(t, err)
+	assert.Empty(t, differences)
+}
@@ -0,0 +1,153 @@
+// Mock of the ClearBlade server-side API for run-local. Collections are
+// served by the Go store through __store, which takes and returns JSON.
+(function (global, store) {
+	function call(fn, args, callback) {
+		var data;
+		try {
+			data = JSON.parse(fn.apply(null, args));
+		} catch (e) {
+			var message = e && e.message ? e.message : String(e);
+			if (callback) {
+				callback(true, message);
+				return;
+			}
+			throw e;
+		}
+		if (callback) {
+			callback(false, data);
+		}
+		return data;
+	}
+
+	function collectionName(options) {
+		if (typeof options === "string") {
+			return options;
+		}
+		if (options && (options.collectionName || options.collection)) {
+			return options.collectionName || options.collection;
+		}
+		throw new Error("run-local only supports collections referenced by name; pass {collectionName: ...}");
+	}
+
+	function Query(options) {
+		this.collection = options ? collectionName(options) : "";
+		this.filters = [[]];
+		this.sort = [];
+		this.pageSize = 0;
+		this.pageNum = 1;
+	}
+
+	function addFilter(op) {
+		return function (field, value) {
+			this.filters[this.filters.length - 1].push({ field: field, op: op, value: value });
+			return this;
+		};
+	}
+
+	Query.prototype.equalTo = addFilter("=");
+	Query.prototype.notEqualTo = addFilter("!=");
+	Query.prototype.greaterThan = addFilter(">");
+	Query.prototype.greaterThanEqualTo = addFilter(">=");
+	Query.prototype.lessThan = addFilter("<");
+	Query.prototype.lessThanEqualTo = addFilter("<=");
+	Query.prototype.matches = addFilter("matches");
+
+	Query.prototype.or = function (other) {
+		this.filters = this.filters.concat(other.filters);
+		return this;
+	};
+	Query.prototype.ascending = function (field) {
+		this.sort.push({ field: field, descending: false });
+		return this;
+	};
+	Query.prototype.descending = function (field) {
+		this.sort.push({ field: field, descending: true });
+		return this;
+	};
+	Query.prototype.setPage = function (pageSize, pageNum) {
+		this.pageSize = pageSize;
+		this.pageNum = pageNum;
+		return this;
+	};
+	Query.prototype.toJSON = function () {
+		return {
+			collection: this.collection,
+			filters: this.filters.filter(function (group) { return group.length > 0; }),
+			sort: this.sort,
+			pageSize: this.pageSize,
+			pageNum: this.pageNum
+		};
+	};
+	Query.prototype.fetch = function (callback) {
+		return call(store.fetch, [JSON.stringify(this)], callback);
+	};
+	Query.prototype.update = function (changes, callback) {
+		return call(store.update, [JSON.stringify(this), JSON.stringify(changes)], callback);
+	};
+	Query.prototype.remove = function (callback) {
+		return call(store.remove, [JSON.stringify(this)], callback);
+	};
+
+	function queryFor(name, query) {
+		var q = new Query(name);
+		if (query) {
+			q.filters = query.filters;
+			q.sort = query.sort;
+			q.pageSize = query.pageSize;
+			q.pageNum = query.pageNum;
+		}
+		return q;
+	}
+
+	function Collection(options) {
+		this.name = collectionName(options);
+	}
+
+	Collection.prototype.fetch = function (query, callback) {
+		if (typeof query === "function") {
+			callback = query;
+			query = undefined;
+		}
+		return queryFor(this.name, query).fetch(callback);
+	};
+	Collection.prototype.create = function (items, callback) {
+		if (!Array.isArray(items)) {
+			items = [items];
+		}
+		return call(store.create, [this.name, JSON.stringify(items)], callback);
+	};
+	Collection.prototype.update = function (query, changes, callback) {
+		return queryFor(this.name, query).update(changes, callback);
+	};
+	Collection.prototype.remove = function (query, callback) {
+		return queryFor(this.name, query).remove(callback);
+	};
+	Collection.prototype.count = function (query, callback) {
+		if (typeof query === "function") {
+			callback = query;
+			query = undefined;
+		}
+		var q = queryFor(this.name, query);
+		try {
+			var result = JSON.parse(store.fetch(JSON.stringify(q)));
+		} catch (e) {
+			if (callback) {
+				callback(true, e.message);
+				return;
+			}
+			throw e;
+		}
+		var data = { count: result.TOTAL };
+		if (callback) {
+			callback(false, data);
+		}
+		return data;
+	};
+
+	global.ClearBlade = {
+		init: function () {},
+		Query: function (options) { return new Query(options); },
+		Collection: function (options) { return new Collection(options); },
+		isObjectEmpty: function (obj) { return Object.keys(obj || {}).length === 0; }
+	};
+})(this, __store);
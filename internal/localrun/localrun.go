@@ -0,0 +1,275 @@
+// Package localrun executes code services in an embedded JavaScript engine,
+// with a mock of the ClearBlade API backed by local collection data, so that
+// services can be run and tested without a platform.
+package localrun
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+//go:embed clearblade.js
+var clearbladeJS string
+
+// DefaultTimeout is the time a service may run before it's stopped.
+const DefaultTimeout = 60 * time.Second
+
+// Script is a named piece of code, e.g. a library.
+type Script struct {
+	Name string
+	Code string
+}
+
+// Options describe a run of a service.
+type Options struct {
+	// Name is the name of the service, which is also the name of the function
+	// called.
+	Name string
+	Code string
+	// Libraries are evaluated before the service, in order.
+	Libraries []Script
+	Params    map[string]interface{}
+	// Collections loads the items of the collections the service uses.
+	Collections CollectionLoader
+	// Log receives the output of log().
+	Log     io.Writer
+	Timeout time.Duration
+	// Request holds more fields of the req object, e.g. systemKey.
+	Request map[string]interface{}
+}
+
+// Result is the outcome of a run: the value given to resp.success or
+// resp.error.
+type Result struct {
+	Success  bool          `json:"success"`
+	Results  interface{}   `json:"results"`
+	Duration time.Duration `json:"-"`
+}
+
+type finished struct{}
+
+type timedOut struct{}
+
+// Run runs the service. An error is returned if the service can't run or
+// ends without calling resp.success or resp.error; a service calling
+// resp.error isn't an error.
+func Run(opts Options) (*Result, error) {
+	start := time.Now()
+	vm := goja.New()
+	store := NewStore(opts.Collections)
+
+	var result *Result
+	respond := func(success bool) func(goja.Value) {
+		return func(v goja.Value) {
+			if result != nil {
+				return
+			}
+			result = &Result{Success: success, Results: export(v)}
+			vm.Interrupt(finished{})
+		}
+	}
+
+	log := opts.Log
+	if log == nil {
+		log = io.Discard
+	}
+
+	if err := setGlobals(vm, store, log); err != nil {
+		return nil, err
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	timer := time.AfterFunc(timeout, func() { vm.Interrupt(timedOut{}) })
+	defer timer.Stop()
+
+	for _, lib := range opts.Libraries {
+		if _, err := vm.RunScript(lib.Name+".js", lib.Code); err != nil {
+			return nil, describe(err, fmt.Sprintf("library '%s'", lib.Name), timeout)
+		}
+	}
+	if _, err := vm.RunScript(opts.Name+".js", opts.Code); err != nil {
+		return nil, describe(err, fmt.Sprintf("service '%s'", opts.Name), timeout)
+	}
+
+	fn, ok := goja.AssertFunction(vm.Get(opts.Name))
+	if !ok {
+		return nil, fmt.Errorf("service '%s' doesn't define a function named %s", opts.Name, opts.Name)
+	}
+
+	req := map[string]interface{}{}
+	for key, value := range opts.Request {
+		req[key] = value
+	}
+	params := opts.Params
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	req["params"] = params
+	reqValue, err := jsonValue(vm, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := vm.NewObject()
+	resp.Set("success", respond(true))
+	resp.Set("error", respond(false))
+
+	_, err = fn(goja.Undefined(), reqValue, resp)
+	if result != nil {
+		result.Duration = time.Since(start)
+		return result, nil
+	}
+	if err != nil {
+		return nil, describe(err, fmt.Sprintf("service '%s'", opts.Name), timeout)
+	}
+	return nil, fmt.Errorf("service '%s' returned without calling resp.success or resp.error", opts.Name)
+}
+
+func setGlobals(vm *goja.Runtime, store *Store, log io.Writer) error {
+	vm.Set("log", func(call goja.FunctionCall) goja.Value {
+		parts := []string{}
+		for _, arg := range call.Arguments {
+			parts = append(parts, logString(arg))
+		}
+		fmt.Fprintln(log, strings.Join(parts, " "))
+		return goja.Undefined()
+	})
+
+	vm.Set("__store", map[string]interface{}{
+		"fetch": func(query string) (string, error) {
+			q, err := parseQuery(query)
+			if err != nil {
+				return "", err
+			}
+			items, total, err := store.Fetch(q)
+			if err != nil {
+				return "", err
+			}
+			page := q.PageNum
+			if page < 1 {
+				page = 1
+			}
+			return toJSON(map[string]interface{}{
+				"DATA":        items,
+				"TOTAL":       total,
+				"CURRENTPAGE": page,
+				"NEXTPAGEURL": nil,
+				"PREVPAGEURL": nil,
+			})
+		},
+		"create": func(collection, items string) (string, error) {
+			parsed := []map[string]interface{}{}
+			if err := json.Unmarshal([]byte(items), &parsed); err != nil {
+				return "", err
+			}
+			ids, err := store.Create(collection, parsed)
+			if err != nil {
+				return "", err
+			}
+			rtn := []map[string]interface{}{}
+			for _, id := range ids {
+				rtn = append(rtn, map[string]interface{}{"item_id": id})
+			}
+			return toJSON(rtn)
+		},
+		"update": func(query, changes string) (string, error) {
+			q, err := parseQuery(query)
+			if err != nil {
+				return "", err
+			}
+			parsed := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(changes), &parsed); err != nil {
+				return "", err
+			}
+			count, err := store.Update(q, parsed)
+			if err != nil {
+				return "", err
+			}
+			return toJSON(map[string]interface{}{"count": count})
+		},
+		"remove": func(query string) (string, error) {
+			q, err := parseQuery(query)
+			if err != nil {
+				return "", err
+			}
+			count, err := store.Remove(q)
+			if err != nil {
+				return "", err
+			}
+			return toJSON(map[string]interface{}{"count": count})
+		},
+	})
+
+	_, err := vm.RunScript("clearblade.js", clearbladeJS)
+	return err
+}
+
+func parseQuery(s string) (Query, error) {
+	q := Query{}
+	err := json.Unmarshal([]byte(s), &q)
+	return q, err
+}
+
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// jsonValue returns v as a plain JavaScript value, so that the service can't
+// modify Go values.
+func jsonValue(vm *goja.Runtime, v interface{}) (goja.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	parse, _ := goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get("parse"))
+	return parse(goja.Undefined(), vm.ToValue(string(data)))
+}
+
+// export returns the Go value of a JavaScript value, with numbers as float64.
+func export(v goja.Value) interface{} {
+	if v == nil || goja.IsUndefined(v) {
+		return nil
+	}
+	var rtn interface{}
+	if err := convert(v.Export(), &rtn); err != nil {
+		return v.String()
+	}
+	return rtn
+}
+
+func logString(v goja.Value) string {
+	if v == nil || goja.IsUndefined(v) {
+		return "undefined"
+	}
+	if _, isObject := v.(*goja.Object); isObject {
+		if data, err := json.Marshal(v.Export()); err == nil {
+			return string(data)
+		}
+	}
+	return v.String()
+}
+
+func describe(err error, what string, timeout time.Duration) error {
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		if _, ok := interrupted.Value().(timedOut); ok {
+			return fmt.Errorf("%s timed out after %s", what, timeout)
+		}
+	}
+	var exception *goja.Exception
+	if errors.As(err, &exception) {
+		return fmt.Errorf("%s threw: %s", what, exception.String())
+	}
+	return fmt.Errorf("%s: %s", what, err)
+}
@@ -0,0 +1,149 @@
+package localrun
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func orders(name string) ([]map[string]interface{}, error) {
+	if name != "orders" {
+		return nil, fmt.Errorf("collection '%s' not found", name)
+	}
+	return []map[string]interface{}{
+		{"item_id": "1", "customer": "ann", "total": 10, "open": true},
+		{"item_id": "2", "customer": "bob", "total": 25, "open": false},
+		{"item_id": "3", "customer": "ann", "total": 40, "open": true},
+	}, nil
+}
+
+func run(t *testing.T, code string, params map[string]interface{}) (*Result, string, error) {
+	var log bytes.Buffer
+	result, err := Run(Options{
+		Name:        "svc",
+		Code:        code,
+		Params:      params,
+		Collections: orders,
+		Log:         &log,
+		Timeout:     time.Second,
+	})
+	return result, log.String(), err
+}
+
+func TestRunSuccessAndError(t *testing.T) {
+	result, log, err := run(t, `function svc(req, resp) { log("hello", req.params.name, {a: 1}); resp.success("hi " + req.params.name); log("not reached"); }`, map[string]interface{}{"name": "ann"})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "hi ann", result.Results)
+	assert.Equal(t, "hello ann {\"a\":1}\n", log)
+
+	result, _, err = run(t, `function svc(req, resp) { resp.error({reason: "bad"}); }`, nil)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, map[string]interface{}{"reason": "bad"}, result.Results)
+}
+
+func TestRunFailures(t *testing.T) {
+	_, _, err := run(t, `function svc(req, resp) {}`, nil)
+	assert.EqualError(t, err, "service 'svc' returned without calling resp.success or resp.error")
+
+	_, _, err = run(t, `function other(req, resp) {}`, nil)
+	assert.EqualError(t, err, "service 'svc' doesn't define a function named svc")
+
+	_, _, err = run(t, `function svc(req, resp) { throw new Error("boom"); }`, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "boom")
+
+	_, _, err = run(t, `function svc(req, resp) { while (true) {} }`, nil)
+	assert.EqualError(t, err, "service 'svc' timed out after 1s")
+
+	_, _, err = run(t, `function svc(req, resp) {`, nil)
+	assert.Error(t, err)
+}
+
+func TestRunLibraries(t *testing.T) {
+	result, err := Run(Options{
+		Name: "svc",
+		Code: `function svc(req, resp) { resp.success(double(inc(1))); }`,
+		Libraries: []Script{
+			{Name: "inc", Code: `function inc(x) { return x + 1; }`},
+			{Name: "double", Code: `function double(x) { return inc(x) * 2 - 2; }`},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, float64(4), result.Results)
+
+	_, err = Run(Options{Name: "svc", Code: `function svc() {}`, Libraries: []Script{{Name: "bad", Code: `throw "x"`}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "library 'bad'")
+}
+
+func TestRunCollections(t *testing.T) {
+	code := `
+function svc(req, resp) {
+	ClearBlade.init({request: req});
+	var col = ClearBlade.Collection({collectionName: "orders"});
+	var q = ClearBlade.Query({collectionName: "orders"}).equalTo("customer", "ann").descending("total");
+	q.fetch(function (err, data) {
+		if (err) { resp.error(data); }
+		var totals = data.DATA.map(function (item) { return item.total; });
+		col.create({customer: "cy", total: 5, open: true}, function (err, created) {
+			if (err) { resp.error(created); }
+			var open = ClearBlade.Query().equalTo("open", true);
+			col.update(open, {open: false}, function (err, updated) {
+				col.count(function (err, count) {
+					var missing = ClearBlade.Collection("missing");
+					missing.fetch(function (err, message) {
+						resp.success({totals: totals, created: created.length, updated: updated.count, count: count.count, missingErr: err, message: message});
+					});
+				});
+			});
+		});
+	});
+}`
+	result, _, err := run(t, code, nil)
+	require.NoError(t, err)
+	require.True(t, result.Success, "%v", result.Results)
+	assert.Equal(t, map[string]interface{}{
+		"totals":     []interface{}{float64(40), float64(10)},
+		"created":    float64(1),
+		"updated":    float64(3),
+		"count":      float64(4),
+		"missingErr": true,
+		"message":    "collection 'missing' not found",
+	}, result.Results)
+}
+
+func TestStoreQueries(t *testing.T) {
+	store := NewStore(orders)
+
+	items, total, err := store.Fetch(Query{Collection: "orders", Filters: [][]Filter{
+		{{Field: "total", Op: ">", Value: float64(20)}, {Field: "open", Op: "=", Value: true}},
+		{{Field: "customer", Op: "matches", Value: "^b"}},
+	}, Sort: []SortField{{Field: "total"}}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "2", items[0]["item_id"])
+	assert.Equal(t, "3", items[1]["item_id"])
+
+	items, total, err = store.Fetch(Query{Collection: "orders", PageSize: 2, PageNum: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, items, 1)
+	assert.Equal(t, "3", items[0]["item_id"])
+
+	count, err := store.Remove(Query{Collection: "orders", Filters: [][]Filter{{{Field: "customer", Op: "!=", Value: "ann"}}}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	_, total, err = store.Fetch(Query{Collection: "orders"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	_, _, err = store.Fetch(Query{Collection: "orders", Filters: [][]Filter{{{Field: "total", Op: "~", Value: 1}}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,314 @@
+package localrun
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// CollectionLoader returns the items of the collection with the given name.
+type CollectionLoader func(name string) ([]map[string]interface{}, error)
+
+// Filter is a condition on a column of an item.
+type Filter struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value"`
+}
+
+// SortField orders fetched items by a column.
+type SortField struct {
+	Field      string `json:"field"`
+	Descending bool   `json:"descending"`
+}
+
+// Query selects items of a collection. Filters holds groups of conditions:
+// an item matches if it matches every condition of any group. An empty
+// Filters matches every item.
+type Query struct {
+	Collection string      `json:"collection"`
+	Filters    [][]Filter  `json:"filters"`
+	Sort       []SortField `json:"sort"`
+	PageSize   int         `json:"pageSize"`
+	PageNum    int         `json:"pageNum"`
+}
+
+// Store keeps the collections used by a run in memory. Collections are
+// loaded on first use and changes are never written back.
+type Store struct {
+	load        CollectionLoader
+	collections map[string][]map[string]interface{}
+}
+
+// NewStore returns a store loading collections with the given loader.
+func NewStore(load CollectionLoader) *Store {
+	return &Store{load: load, collections: map[string][]map[string]interface{}{}}
+}
+
+func (s *Store) collection(name string) ([]map[string]interface{}, error) {
+	if name == "" {
+		return nil, fmt.Errorf("missing collection name")
+	}
+	if items, ok := s.collections[name]; ok {
+		return items, nil
+	}
+	if s.load == nil {
+		return nil, fmt.Errorf("collection '%s' not found", name)
+	}
+
+	items, err := s.load(name)
+	if err != nil {
+		return nil, err
+	}
+	// copy through JSON so numbers are float64 as they are for items created
+	// by the service
+	copied := []map[string]interface{}{}
+	if err := convert(items, &copied); err != nil {
+		return nil, err
+	}
+	s.collections[name] = copied
+	return copied, nil
+}
+
+// Fetch returns the page of matching items and the total number of matching
+// items.
+func (s *Store) Fetch(q Query) ([]map[string]interface{}, int, error) {
+	items, err := s.collection(q.Collection)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	matching := []map[string]interface{}{}
+	for _, item := range items {
+		ok, err := q.matches(item)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ok {
+			matching = append(matching, item)
+		}
+	}
+
+	if len(q.Sort) > 0 {
+		sort.SliceStable(matching, func(i, j int) bool {
+			for _, field := range q.Sort {
+				c, ok := compare(matching[i][field.Field], matching[j][field.Field])
+				if !ok || c == 0 {
+					continue
+				}
+				if field.Descending {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+	}
+
+	total := len(matching)
+	if q.PageSize > 0 {
+		page := q.PageNum
+		if page < 1 {
+			page = 1
+		}
+		start := (page - 1) * q.PageSize
+		if start > total {
+			start = total
+		}
+		end := start + q.PageSize
+		if end > total {
+			end = total
+		}
+		matching = matching[start:end]
+	}
+	return matching, total, nil
+}
+
+// Create adds the items to the collection and returns their item ids. Items
+// without an item_id get a new one.
+func (s *Store) Create(collection string, items []map[string]interface{}) ([]string, error) {
+	existing, err := s.collection(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, item := range items {
+		id, ok := item["item_id"].(string)
+		if !ok || id == "" {
+			id = newItemID()
+			item["item_id"] = id
+		}
+		existing = append(existing, item)
+		ids = append(ids, id)
+	}
+	s.collections[collection] = existing
+	return ids, nil
+}
+
+// Update sets the given columns of every matching item and returns the
+// number of updated items.
+func (s *Store) Update(q Query, changes map[string]interface{}) (int, error) {
+	items, err := s.collection(q.Collection)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, item := range items {
+		ok, err := q.matches(item)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			for key, value := range changes {
+				item[key] = value
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Remove deletes every matching item and returns the number of deleted items.
+func (s *Store) Remove(q Query) (int, error) {
+	items, err := s.collection(q.Collection)
+	if err != nil {
+		return 0, err
+	}
+
+	kept := []map[string]interface{}{}
+	for _, item := range items {
+		ok, err := q.matches(item)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			kept = append(kept, item)
+		}
+	}
+	s.collections[q.Collection] = kept
+	return len(items) - len(kept), nil
+}
+
+func (q *Query) matches(item map[string]interface{}) (bool, error) {
+	if len(q.Filters) == 0 {
+		return true, nil
+	}
+
+	for _, group := range q.Filters {
+		all := true
+		for _, filter := range group {
+			ok, err := filter.matches(item)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *Filter) matches(item map[string]interface{}) (bool, error) {
+	value := item[f.Field]
+
+	if f.Op == "matches" {
+		pattern, ok := f.Value.(string)
+		if !ok {
+			return false, fmt.Errorf("matches on '%s' needs a string pattern", f.Field)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, err
+		}
+		s, ok := value.(string)
+		return ok && re.MatchString(s), nil
+	}
+
+	c, comparable := compare(value, f.Value)
+	switch f.Op {
+	case "=":
+		return comparable && c == 0, nil
+	case "!=":
+		return !comparable || c != 0, nil
+	case ">":
+		return comparable && c > 0, nil
+	case ">=":
+		return comparable && c >= 0, nil
+	case "<":
+		return comparable && c < 0, nil
+	case "<=":
+		return comparable && c <= 0, nil
+	default:
+		return false, fmt.Errorf("unknown query operator '%s'", f.Op)
+	}
+}
+
+// compare returns -1, 0 or 1 if a is less than, equal to or greater than b.
+// The second value is false if the values can't be compared.
+func compare(a, b interface{}) (int, bool) {
+	switch av := a.(type) {
+	case nil:
+		return 0, b == nil
+	case float64:
+		bv, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case av < bv:
+			return -1, true
+		case av > bv:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case av < bv:
+			return -1, true
+		case av > bv:
+			return 1, true
+		}
+		return 0, true
+	case bool:
+		bv, ok := b.(bool)
+		if !ok || av == bv {
+			return 0, ok
+		}
+		if bv {
+			return -1, true
+		}
+		return 1, true
+	default:
+		return 0, false
+	}
+}
+
+// convert copies from into to through JSON.
+func convert(from, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
+
+func newItemID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
@@ -0,0 +1,72 @@
+package metaformat
+
+import (
+	"os"
+	"path/filepath"
+)
+
+var extensions = []string{".json", ".yaml", ".yml"}
+
+// FindFile returns the path of the metadata file <dir>/<name>.{json,yaml,yml}
+// that exists on disk. When none exists, the JSON path is returned so that
+// callers report the usual "no such file" error.
+func FindFile(dir, name string) string {
+	for _, ext := range extensions {
+		p := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return filepath.Join(dir, name+".json")
+}
+
+// ReadAsJSON reads the given metadata file, converting it to JSON if it is a
+// YAML file.
+func ReadAsJSON(p string) ([]byte, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsYAMLPath(p) {
+		return data, nil
+	}
+
+	return ToJSON(data)
+}
+
+// WriteFile writes the given value to <dir>/<name>.<ext> in the given format
+// and removes the file of the other format if present, so that a single copy
+// of the metadata remains on disk. JSON is marshalled by the caller (marshalled
+// is used as is) while YAML is encoded here, preserving the comments of the
+// previous YAML file.
+func WriteFile(dir, name string, format Format, v interface{}, marshalledJSON []byte) error {
+	target := filepath.Join(dir, name+"."+format.Extension())
+
+	var data []byte
+	if format == YAML {
+		previous, _ := os.ReadFile(target)
+
+		var err error
+		data, err = EncodeYAML(v, previous)
+		if err != nil {
+			return err
+		}
+	} else {
+		data = marshalledJSON
+	}
+
+	err := os.WriteFile(target, data, 0666)
+	if err != nil {
+		return err
+	}
+
+	for _, ext := range extensions {
+		p := filepath.Join(dir, name+ext)
+		if p != target {
+			os.Remove(p)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package metaformat
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSettingsDefaults(t *testing.T) {
+	settings, err := LoadSettings(t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, JSON, settings.MetadataFormat)
+	assert.Equal(t, PlatformRoles, settings.RoleFormat)
+}
+
+func TestSaveAndLoadSettings(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, SaveSettings(root, &Settings{MetadataFormat: YAML, RoleFormat: ReadableRoles}))
+
+	settings, err := LoadSettings(root)
+	require.NoError(t, err)
+	assert.Equal(t, YAML, settings.MetadataFormat)
+	assert.Equal(t, ReadableRoles, settings.RoleFormat)
+
+	assert.Error(t, SaveSettings(root, &Settings{MetadataFormat: "toml"}))
+	assert.Error(t, SaveSettings(root, &Settings{RoleFormat: "pretty"}))
+	assert.Nil(t, settings.PortalCode)
+}
+
+func TestPortalCodeSettings(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, hiddenDir), 0777))
+	require.NoError(t, os.WriteFile(makeSettingsPath(root), []byte(`{"portal_code": {"lint": [{"command": ["eslint", "{file}"], "extensions": [".js"]}]}}`), 0666))
+
+	settings, err := LoadSettings(root)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"lint": [{"command": ["eslint", "{file}"], "extensions": [".js"]}]}`, string(settings.PortalCode))
+
+	// kept when other settings change
+	settings.MetadataFormat = YAML
+	require.NoError(t, SaveSettings(root, settings))
+	settings, err = LoadSettings(root)
+	require.NoError(t, err)
+	assert.Equal(t, YAML, settings.MetadataFormat)
+	assert.JSONEq(t, `{"lint": [{"command": ["eslint", "{file}"], "extensions": [".js"]}]}`, string(settings.PortalCode))
+}
+
+func TestEncodeYAMLPreservesComments(t *testing.T) {
+	previous := []byte(`# Role used by the field technicians
+name: tech
+# keep in sync with the mobile app
+services:
+  - name: a # read only
+    level: 1
+  - name: b
+    level: 2
+`)
+
+	v := map[string]interface{}{
+		"name": "tech",
+		"services": []interface{}{
+			map[string]interface{}{"name": "b", "level": 4},
+			map[string]interface{}{"name": "a", "level": 1},
+			map[string]interface{}{"name": "c", "level": 1},
+		},
+	}
+
+	out, err := EncodeYAML(v, previous)
+	require.NoError(t, err)
+
+	assert.Equal(t, `# Role used by the field technicians
+name: tech
+# keep in sync with the mobile app
+services:
+- level: 4
+  name: b
+- level: 1
+  name: a # read only
+- level: 1
+  name: c
+`, string(out))
+}
+
+func TestEncodeYAMLWithoutPrevious(t *testing.T) {
+	out, err := EncodeYAML(map[string]interface{}{"b": 1, "a": "x"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "a: x\nb: 1\n", string(out))
+}
+
+func TestToJSONRoundTrip(t *testing.T) {
+	original := map[string]interface{}{
+		"name":       "timer",
+		"start_time": "2020-01-01T00:00:00Z",
+		"repeats":    -1.0,
+		"disabled":   false,
+		"nested":     map[string]interface{}{"list": []interface{}{"a", 1.5, nil}},
+	}
+
+	encoded, err := EncodeYAML(original, nil)
+	require.NoError(t, err)
+
+	asJSON, err := ToJSON(encoded)
+	require.NoError(t, err)
+
+	decoded := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(asJSON, &decoded))
+	assert.Equal(t, original, decoded)
+}
+
+func TestToJSONNonStringKeys(t *testing.T) {
+	asJSON, err := ToJSON([]byte("1: one\ntrue: yes\n"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"1": "one", "true": "yes"}`, string(asJSON))
+}
+
+func TestWriteFileSwitchesFormat(t *testing.T) {
+	dir := t.TempDir()
+	v := map[string]interface{}{"name": "foo"}
+
+	require.NoError(t, WriteFile(dir, "foo", JSON, v, []byte(`{"name": "foo"}`)))
+	assert.Equal(t, filepath.Join(dir, "foo.json"), FindFile(dir, "foo"))
+
+	require.NoError(t, WriteFile(dir, "foo", YAML, v, nil))
+	assert.Equal(t, filepath.Join(dir, "foo.yaml"), FindFile(dir, "foo"))
+	assert.NoFileExists(t, filepath.Join(dir, "foo.json"))
+
+	data, err := ReadAsJSON(FindFile(dir, "foo"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name": "foo"}`, string(data))
+}
+
+func TestFindFileMissing(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, filepath.Join(dir, "foo.json"), FindFile(dir, "foo"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo.yml"), []byte("name: foo\n"), 0666))
+	assert.Equal(t, filepath.Join(dir, "foo.yml"), FindFile(dir, "foo"))
+}
@@ -0,0 +1,132 @@
+// Package metaformat controls the on-disk format of asset metadata files. By
+// default metadata is stored as indented JSON; a workspace can opt into YAML,
+// which is converted back to JSON before anything is sent to the platform.
+package metaformat
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+)
+
+// Format is an on-disk metadata format.
+type Format string
+
+const (
+	JSON Format = "json"
+	YAML Format = "yaml"
+)
+
+const (
+	hiddenDir    = ".cb-cli"
+	settingsFile = "settings.json"
+)
+
+// RoleFormat is the shape of role files: the platform's own Permissions
+// structure or the readable format of the roles model.
+type RoleFormat string
+
+const (
+	PlatformRoles RoleFormat = "platform"
+	ReadableRoles RoleFormat = "readable"
+)
+
+// Settings holds the per-workspace settings stored in .cb-cli/settings.json.
+type Settings struct {
+	MetadataFormat Format     `json:"metadata_format,omitempty"`
+	RoleFormat     RoleFormat `json:"role_format,omitempty"`
+	// PortalCode holds the formatters and linters run over the code of
+	// portals, if any. It is kept as is, for the portal code package to
+	// decode, and written back unchanged.
+	PortalCode json.RawMessage `json:"portal_code,omitempty"`
+}
+
+// Extension returns the file extension (without the dot) for the format.
+func (f Format) Extension() string {
+	if f == YAML {
+		return "yaml"
+	}
+	return "json"
+}
+
+// Validate returns an error if the format is unknown.
+func (f Format) Validate() error {
+	switch f {
+	case JSON, YAML, "":
+		return nil
+	default:
+		return fmt.Errorf("unknown metadata format '%s', expected '%s' or '%s'", f, JSON, YAML)
+	}
+}
+
+// Validate returns an error if the role format is unknown.
+func (f RoleFormat) Validate() error {
+	switch f {
+	case PlatformRoles, ReadableRoles, "":
+		return nil
+	default:
+		return fmt.Errorf("unknown role format '%s', expected '%s' or '%s'", f, PlatformRoles, ReadableRoles)
+	}
+}
+
+func (s *Settings) validate() error {
+	err := s.MetadataFormat.Validate()
+	if err != nil {
+		return err
+	}
+	return s.RoleFormat.Validate()
+}
+
+func makeSettingsPath(rootDir string) string {
+	return path.Join(rootDir, hiddenDir, settingsFile)
+}
+
+// LoadSettings reads the settings of the workspace rooted at the given
+// directory. Missing settings yield the defaults.
+func LoadSettings(rootDir string) (*Settings, error) {
+	settings := &Settings{MetadataFormat: JSON, RoleFormat: PlatformRoles}
+
+	data, err := os.ReadFile(makeSettingsPath(rootDir))
+	if os.IsNotExist(err) {
+		return settings, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, settings)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", makeSettingsPath(rootDir), err)
+	}
+
+	if settings.MetadataFormat == "" {
+		settings.MetadataFormat = JSON
+	}
+
+	if settings.RoleFormat == "" {
+		settings.RoleFormat = PlatformRoles
+	}
+
+	return settings, settings.validate()
+}
+
+// SaveSettings writes the settings of the workspace rooted at the given
+// directory.
+func SaveSettings(rootDir string, settings *Settings) error {
+	err := settings.validate()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(settings, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(path.Join(rootDir, hiddenDir), 0777)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(makeSettingsPath(rootDir), data, 0666)
+}
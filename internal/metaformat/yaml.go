@@ -0,0 +1,198 @@
+package metaformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IsYAMLPath returns true if the given path has a YAML extension.
+func IsYAMLPath(p string) bool {
+	ext := strings.ToLower(filepath.Ext(p))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// EncodeYAML encodes the given value as YAML. When previous holds the former
+// content of the file, comments attached to keys (and list items) that still
+// exist are carried over, so hand-written notes survive a pull.
+func EncodeYAML(v interface{}, previous []byte) ([]byte, error) {
+	encoded, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &yaml.Node{}
+	err = yaml.Unmarshal(encoded, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(doc.Content) == 0 {
+		return encoded, nil
+	}
+	node := doc.Content[0]
+
+	if len(bytes.TrimSpace(previous)) > 0 {
+		old := &yaml.Node{}
+		if err := yaml.Unmarshal(previous, old); err == nil && old.Kind == yaml.DocumentNode {
+			copyComments(old, doc)
+			if len(old.Content) > 0 {
+				copyComments(old.Content[0], node)
+			}
+		}
+	}
+
+	buf := bytes.Buffer{}
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	err = encoder.Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// copyComments copies the comments of the old node into the new one, recursing
+// into mappings by key and into sequences by item name (or position).
+func copyComments(old, new *yaml.Node) {
+	if old.Kind != new.Kind {
+		return
+	}
+
+	new.HeadComment = old.HeadComment
+	new.LineComment = old.LineComment
+	new.FootComment = old.FootComment
+
+	switch new.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(new.Content); i += 2 {
+			oldKey, oldValue := findMappingValue(old, new.Content[i].Value)
+			if oldKey == nil {
+				continue
+			}
+			copyComments(oldKey, new.Content[i])
+			copyComments(oldValue, new.Content[i+1])
+		}
+
+	case yaml.SequenceNode:
+		for i, item := range new.Content {
+			oldItem := findSequenceItem(old, item, i)
+			if oldItem != nil {
+				copyComments(oldItem, item)
+			}
+		}
+	}
+}
+
+func findMappingValue(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// findSequenceItem finds the item of the old sequence matching the given item.
+// Mappings with a name are matched by name since lists coming from the
+// platform are not guaranteed to keep their order.
+func findSequenceItem(old *yaml.Node, item *yaml.Node, idx int) *yaml.Node {
+	if name, ok := nodeName(item); ok {
+		for _, candidate := range old.Content {
+			if candidateName, ok := nodeName(candidate); ok && candidateName == name {
+				return candidate
+			}
+		}
+		return nil
+	}
+
+	if idx < len(old.Content) {
+		return old.Content[idx]
+	}
+
+	return nil
+}
+
+func nodeName(node *yaml.Node) (string, bool) {
+	if node.Kind != yaml.MappingNode {
+		return "", false
+	}
+
+	for _, key := range []string{"name", "Name"} {
+		if _, value := findMappingValue(node, key); value != nil && value.Kind == yaml.ScalarNode {
+			return value.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// ToJSON converts a YAML document to its JSON equivalent.
+func ToJSON(data []byte) ([]byte, error) {
+	var v interface{}
+	err := yaml.Unmarshal(data, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(normalized)
+}
+
+// normalize converts values decoded from YAML into values that encoding/json
+// can marshal (maps with string keys only).
+func normalize(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, inner := range value {
+			normalized, err := normalize(inner)
+			if err != nil {
+				return nil, err
+			}
+			value[key] = normalized
+		}
+		return value, nil
+
+	case map[interface{}]interface{}:
+		rtn := make(map[string]interface{}, len(value))
+		for key, inner := range value {
+			normalized, err := normalize(inner)
+			if err != nil {
+				return nil, err
+			}
+			rtn[fmt.Sprint(key)] = normalized
+		}
+		return rtn, nil
+
+	case []interface{}:
+		for i, inner := range value {
+			normalized, err := normalize(inner)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = normalized
+		}
+		return value, nil
+
+	case time.Time:
+		return value.Format(time.RFC3339Nano), nil
+
+	default:
+		return v, nil
+	}
+}
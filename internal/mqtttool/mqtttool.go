@@ -0,0 +1,279 @@
+// Package mqtttool publishes, records and replays MQTT messages for the mqtt
+// command. A recording is a file of newline delimited JSON, one Record per
+// message received:
+//
+//	{"time":"2024-05-01T10:00:00.5Z","offset_ms":500,"topic":"devices/a/state","payload":"on","qos":0}
+package mqtttool
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Message is an MQTT message.
+type Message struct {
+	Topic   string
+	Payload []byte
+	QoS     int
+	Retain  bool
+}
+
+// Publisher publishes messages to a broker.
+type Publisher interface {
+	Publish(msg Message) error
+}
+
+// CheckQoS returns an error if qos isn't 0, 1 or 2.
+func CheckQoS(qos int) error {
+	if qos < 0 || qos > 2 {
+		return fmt.Errorf("invalid QoS %d; must be 0, 1 or 2", qos)
+	}
+	return nil
+}
+
+// CheckTopic returns an error if the topic isn't valid. Wildcards are only
+// valid in subscriptions: + matches a single level and # the remaining
+// levels, e.g. devices/+/state or devices/#.
+func CheckTopic(topic string, wildcards bool) error {
+	if topic == "" {
+		return fmt.Errorf("topic is empty")
+	}
+	levels := strings.Split(topic, "/")
+	for i, level := range levels {
+		if !strings.ContainsAny(level, "+#") {
+			continue
+		}
+		if !wildcards {
+			return fmt.Errorf("invalid topic '%s': wildcards can only be used to subscribe", topic)
+		}
+		if level == "#" && i == len(levels)-1 || level == "+" {
+			continue
+		}
+		return fmt.Errorf("invalid topic '%s': a wildcard must be a whole level and # the last one", topic)
+	}
+	return nil
+}
+
+// Match returns true if the topic matches the subscription filter.
+func Match(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
+
+// FormatPayload returns the payload as text, or as base64 prefixed with
+// 'base64:' if it isn't valid UTF-8.
+func FormatPayload(payload []byte) string {
+	if utf8.Valid(payload) {
+		return string(payload)
+	}
+	return "base64:" + base64.StdEncoding.EncodeToString(payload)
+}
+
+// Record is a recorded message. OffsetMs is the time since the first message
+// of the recording. Payloads which aren't valid UTF-8 are kept in
+// PayloadBase64.
+type Record struct {
+	Time          time.Time `json:"time"`
+	OffsetMs      int64     `json:"offset_ms"`
+	Topic         string    `json:"topic"`
+	Payload       string    `json:"payload,omitempty"`
+	PayloadBase64 string    `json:"payload_base64,omitempty"`
+	QoS           int       `json:"qos"`
+	Retain        bool      `json:"retain,omitempty"`
+}
+
+// NewRecord returns the record of a message received at the given time.
+func NewRecord(msg Message, at, start time.Time) Record {
+	record := Record{
+		Time:     at.UTC(),
+		OffsetMs: at.Sub(start).Milliseconds(),
+		Topic:    msg.Topic,
+		QoS:      msg.QoS,
+		Retain:   msg.Retain,
+	}
+	if utf8.Valid(msg.Payload) {
+		record.Payload = string(msg.Payload)
+	} else {
+		record.PayloadBase64 = base64.StdEncoding.EncodeToString(msg.Payload)
+	}
+	return record
+}
+
+// Message returns the recorded message.
+func (r *Record) Message() (Message, error) {
+	msg := Message{Topic: r.Topic, Payload: []byte(r.Payload), QoS: r.QoS, Retain: r.Retain}
+	if r.PayloadBase64 != "" {
+		payload, err := base64.StdEncoding.DecodeString(r.PayloadBase64)
+		if err != nil {
+			return Message{}, fmt.Errorf("invalid base64 payload: %s", err)
+		}
+		msg.Payload = payload
+	}
+	return msg, nil
+}
+
+// Recorder writes the messages it's given as a recording.
+type Recorder struct {
+	w     io.Writer
+	start time.Time
+	count int
+}
+
+// NewRecorder returns a recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// Write records a message received at the given time. Offsets are relative to
+// the first message written.
+func (r *Recorder) Write(msg Message, at time.Time) error {
+	if r.count == 0 {
+		r.start = at
+	}
+	data, err := json.Marshal(NewRecord(msg, at, r.start))
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(r.w, string(data)); err != nil {
+		return err
+	}
+	r.count++
+	return nil
+}
+
+// Count returns the number of messages recorded.
+func (r *Recorder) Count() int {
+	return r.count
+}
+
+// ReadRecording reads the records of a recording. Blank lines are skipped.
+func ReadRecording(r io.Reader) ([]Record, error) {
+	records := []Record{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		record := Record{}
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		if err := CheckTopic(record.Topic, false); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		if err := CheckQoS(record.QoS); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// RepeatOptions controls how often a message is published. Count is the
+// number of times to publish it, forever if 0. Rate is the number of messages
+// per second, as fast as possible if 0.
+type RepeatOptions struct {
+	Count int
+	Rate  float64
+	// Sleep defaults to time.Sleep.
+	Sleep func(time.Duration)
+	// Published, if set, is called after each publish with the number of
+	// messages published so far.
+	Published func(count int)
+}
+
+// Repeat publishes the message as the options say and returns the number of
+// messages published.
+func Repeat(p Publisher, msg Message, opts RepeatOptions) (int, error) {
+	sleep := opts.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	var interval time.Duration
+	if opts.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / opts.Rate)
+	}
+
+	count := 0
+	for opts.Count == 0 || count < opts.Count {
+		if count > 0 && interval > 0 {
+			sleep(interval)
+		}
+		if err := p.Publish(msg); err != nil {
+			return count, err
+		}
+		count++
+		if opts.Published != nil {
+			opts.Published(count)
+		}
+	}
+	return count, nil
+}
+
+// ReplayOptions controls a replay. Speed scales the original timing: 1 keeps
+// it, 2 replays twice as fast and 0 publishes without waiting. Only the
+// messages matching Topic are replayed if it's set.
+type ReplayOptions struct {
+	Speed float64
+	Topic string
+	// Sleep defaults to time.Sleep.
+	Sleep func(time.Duration)
+}
+
+// Replay publishes the records in order, waiting between messages as long as
+// they were apart when recorded. It returns the number of messages published.
+func Replay(p Publisher, records []Record, opts ReplayOptions) (int, error) {
+	sleep := opts.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	count := 0
+	var lastOffset int64
+	for i, record := range records {
+		if opts.Topic != "" && !Match(opts.Topic, record.Topic) {
+			continue
+		}
+		msg, err := record.Message()
+		if err != nil {
+			return count, fmt.Errorf("record %d: %s", i+1, err)
+		}
+
+		if count > 0 && opts.Speed > 0 && record.OffsetMs > lastOffset {
+			wait := time.Duration(float64(record.OffsetMs-lastOffset) * float64(time.Millisecond) / opts.Speed)
+			sleep(wait)
+		}
+		lastOffset = record.OffsetMs
+
+		if err := p.Publish(msg); err != nil {
+			return count, fmt.Errorf("record %d: %s", i+1, err)
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,134 @@
+package mqtttool
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePublisher struct {
+	published []Message
+	failAt    int
+}
+
+func (f *fakePublisher) Publish(msg Message) error {
+	if f.failAt > 0 && len(f.published)+1 == f.failAt {
+		return errors.New("connection lost")
+	}
+	f.published = append(f.published, msg)
+	return nil
+}
+
+func TestCheckTopic(t *testing.T) {
+	for _, valid := range []string{"a", "a/b", "devices/+/state", "devices/#", "#", "+/+", "a//b"} {
+		assert.NoError(t, CheckTopic(valid, true), valid)
+	}
+	for _, invalid := range []string{"", "a/#/b", "a/b#", "a/+b", "a+/c"} {
+		assert.Error(t, CheckTopic(invalid, true), invalid)
+	}
+	assert.NoError(t, CheckTopic("a/b", false))
+	assert.EqualError(t, CheckTopic("a/+", false), "invalid topic 'a/+': wildcards can only be used to subscribe")
+
+	assert.NoError(t, CheckQoS(2))
+	assert.Error(t, CheckQoS(3))
+}
+
+func TestMatch(t *testing.T) {
+	assert.True(t, Match("a/b", "a/b"))
+	assert.False(t, Match("a/b", "a/b/c"))
+	assert.True(t, Match("a/+/c", "a/b/c"))
+	assert.False(t, Match("a/+", "a/b/c"))
+	assert.True(t, Match("a/#", "a/b/c"))
+	assert.True(t, Match("a/#", "a"))
+	assert.True(t, Match("#", "x/y"))
+	assert.False(t, Match("a/b/c", "a/b"))
+}
+
+func TestRecording(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+	require.NoError(t, recorder.Write(Message{Topic: "a/b", Payload: []byte("on")}, start))
+	require.NoError(t, recorder.Write(Message{Topic: "a/c", Payload: []byte{0xff, 0x00}, QoS: 1, Retain: true}, start.Add(1500*time.Millisecond)))
+	assert.Equal(t, 2, recorder.Count())
+
+	assert.Equal(t, `{"time":"2024-05-01T10:00:00Z","offset_ms":0,"topic":"a/b","payload":"on","qos":0}
+{"time":"2024-05-01T10:00:01.5Z","offset_ms":1500,"topic":"a/c","payload_base64":"/wA=","qos":1,"retain":true}
+`, buf.String())
+
+	records, err := ReadRecording(strings.NewReader(buf.String() + "\n"))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	msg, err := records[1].Message()
+	require.NoError(t, err)
+	assert.Equal(t, Message{Topic: "a/c", Payload: []byte{0xff, 0x00}, QoS: 1, Retain: true}, msg)
+
+	_, err = ReadRecording(strings.NewReader("{\"topic\":\"a\"}\n{not json}\n"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+
+	_, err = ReadRecording(strings.NewReader(`{"topic":"a/#"}`))
+	assert.Error(t, err)
+
+	assert.Equal(t, "base64:/wA=", FormatPayload([]byte{0xff, 0x00}))
+	assert.Equal(t, "on", FormatPayload([]byte("on")))
+}
+
+func TestRepeat(t *testing.T) {
+	slept := []time.Duration{}
+	sleep := func(d time.Duration) { slept = append(slept, d) }
+	publisher := &fakePublisher{}
+	msg := Message{Topic: "a", Payload: []byte("x")}
+
+	count, err := Repeat(publisher, msg, RepeatOptions{Count: 3, Rate: 4, Sleep: sleep})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Len(t, publisher.published, 3)
+	assert.Equal(t, []time.Duration{250 * time.Millisecond, 250 * time.Millisecond}, slept)
+
+	slept = []time.Duration{}
+	publisher = &fakePublisher{failAt: 5}
+	count, err = Repeat(publisher, msg, RepeatOptions{Sleep: sleep})
+	assert.EqualError(t, err, "connection lost")
+	assert.Equal(t, 4, count)
+	assert.Empty(t, slept)
+}
+
+func TestReplay(t *testing.T) {
+	records := []Record{
+		{OffsetMs: 0, Topic: "a/1", Payload: "one"},
+		{OffsetMs: 100, Topic: "b/1", Payload: "skipped"},
+		{OffsetMs: 1000, Topic: "a/2", Payload: "two"},
+		{OffsetMs: 1000, Topic: "a/3", PayloadBase64: "/wA="},
+	}
+
+	slept := []time.Duration{}
+	sleep := func(d time.Duration) { slept = append(slept, d) }
+	publisher := &fakePublisher{}
+	count, err := Replay(publisher, records, ReplayOptions{Speed: 2, Topic: "a/+", Sleep: sleep})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, []time.Duration{500 * time.Millisecond}, slept)
+	assert.Equal(t, []byte{0xff, 0x00}, publisher.published[2].Payload)
+
+	slept = []time.Duration{}
+	publisher = &fakePublisher{}
+	count, err = Replay(publisher, records, ReplayOptions{Speed: 1, Sleep: sleep})
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 900 * time.Millisecond}, slept)
+
+	slept = []time.Duration{}
+	count, err = Replay(&fakePublisher{}, records, ReplayOptions{Sleep: sleep})
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+	assert.Empty(t, slept)
+
+	_, err = Replay(&fakePublisher{failAt: 2}, records, ReplayOptions{Sleep: sleep})
+	assert.EqualError(t, err, "record 2: connection lost")
+}
@@ -0,0 +1,188 @@
+// Package pluginfiles stores plugins decompressed, like portals: the code of a
+// plugin is written to files of their own, so that it can be edited and
+// diffed, and the rest of the plugin to a meta file. The directory of a
+// plugin, plugins/<name>/, holds meta.json and a file per code field, named
+// after the field, e.g. render.js or config/template.html for the template
+// field of the config object.
+package pluginfiles
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MetaFileName is the name of the meta file, in the directory of a plugin.
+const MetaFileName = "meta.json"
+
+// metaFields are the fields which always stay in the meta file.
+var metaFields = map[string]bool{"name": true, "description": true, "version": true}
+
+// codeWords are the words of the names of fields holding code.
+var codeWords = []string{"code", "script", "javascript", "html", "css", "style", "template"}
+
+// Extensions are the extensions of the code files.
+var Extensions = []string{".js", ".html", ".css"}
+
+// extension returns the extension of the file of a code field.
+func extension(key string) string {
+	lower := strings.ToLower(key)
+	switch {
+	case strings.Contains(lower, "html") || strings.Contains(lower, "template"):
+		return ".html"
+	case strings.Contains(lower, "css") || strings.Contains(lower, "style"):
+		return ".css"
+	default:
+		return ".js"
+	}
+}
+
+// IsCodeField returns true if the field of a plugin is written to a file: a
+// string field named after code, or holding several lines. The path is the
+// path of the field from the plugin, e.g. ["config", "template"].
+func IsCodeField(path []string, value interface{}) bool {
+	s, ok := value.(string)
+	if !ok || (len(path) == 1 && metaFields[path[0]]) {
+		return false
+	}
+	for _, key := range path {
+		if key == "" || key == "." || key == ".." || strings.ContainsAny(key, `/\`) {
+			return false
+		}
+	}
+	if strings.Contains(s, "\n") {
+		return true
+	}
+	lower := strings.ToLower(path[len(path)-1])
+	for _, word := range codeWords {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Decompress writes the plugin to dir, which is emptied first.
+func Decompress(plugin map[string]interface{}, dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	meta, err := extract(plugin, nil, dir)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(meta, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, MetaFileName), data, 0666)
+}
+
+// extract writes the code fields of the object to files and returns the
+// object without them.
+func extract(object map[string]interface{}, path []string, dir string) (map[string]interface{}, error) {
+	rtn := make(map[string]interface{}, len(object))
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := object[key]
+		fieldPath := append(append([]string{}, path...), key)
+		if IsCodeField(fieldPath, value) {
+			file := filepath.Join(dir, filepath.Join(fieldPath...)+extension(key))
+			if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(file, []byte(value.(string)), 0666); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			extracted, err := extract(nested, fieldPath, dir)
+			if err != nil {
+				return nil, err
+			}
+			rtn[key] = extracted
+			continue
+		}
+		rtn[key] = value
+	}
+	return rtn, nil
+}
+
+// Compress reads the plugin decompressed in dir.
+func Compress(dir string) (map[string]interface{}, error) {
+	metaFile := filepath.Join(dir, MetaFileName)
+	data, err := os.ReadFile(metaFile)
+	if err != nil {
+		return nil, err
+	}
+	plugin := map[string]interface{}{}
+	if err := json.Unmarshal(data, &plugin); err != nil {
+		return nil, fmt.Errorf("invalid plugin meta file %s: %s", metaFile, err)
+	}
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !IsCodeFile(path) {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		code, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fieldPath := strings.Split(strings.TrimSuffix(filepath.ToSlash(relPath), filepath.Ext(relPath)), "/")
+		return set(plugin, fieldPath, string(code))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plugin, nil
+}
+
+// IsCodeFile returns true if the file of a plugin directory holds code.
+func IsCodeFile(path string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+func set(object map[string]interface{}, path []string, value string) error {
+	for _, key := range path[:len(path)-1] {
+		nested, ok := object[key]
+		if !ok {
+			nested = map[string]interface{}{}
+			object[key] = nested
+		}
+		object, ok = nested.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("plugin field %s isn't an object", strings.Join(path, "."))
+		}
+	}
+	key := path[len(path)-1]
+	if _, ok := object[key]; ok {
+		return fmt.Errorf("plugin field %s is both in %s and a file", strings.Join(path, "."), MetaFileName)
+	}
+	object[key] = value
+	return nil
+}
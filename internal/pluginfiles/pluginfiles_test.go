@@ -0,0 +1,104 @@
+package pluginfiles
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, s string) map[string]interface{} {
+	v := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func TestDecompressAndCompress(t *testing.T) {
+	plugin := parse(t, `{
+		"name": "gauge",
+		"description": "A gauge\nwith a needle",
+		"version": "2",
+		"code": "function render() {}",
+		"enabled": true,
+		"settings": [{"name": "min", "type": "number"}],
+		"config": {
+			"template": "<div class=\"gauge\"></div>",
+			"Style": "",
+			"notes": "line one\nline two",
+			"label": "Gauge",
+			"a/b": "x\ny"
+		}
+	}`)
+
+	dir := filepath.Join(t.TempDir(), "gauge")
+	require.NoError(t, os.MkdirAll(dir, 0777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stale.js"), []byte("stale"), 0666))
+	require.NoError(t, Decompress(plugin, dir))
+
+	files := []string{}
+	require.NoError(t, filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if !info.IsDir() {
+			relPath, _ := filepath.Rel(dir, path)
+			files = append(files, filepath.ToSlash(relPath))
+		}
+		return err
+	}))
+	assert.Equal(t, []string{"code.js", "config/Style.css", "config/notes.js", "config/template.html", "meta.json"}, files)
+
+	code, err := os.ReadFile(filepath.Join(dir, "config", "template.html"))
+	require.NoError(t, err)
+	assert.Equal(t, `<div class="gauge"></div>`, string(code))
+
+	meta, err := os.ReadFile(filepath.Join(dir, MetaFileName))
+	require.NoError(t, err)
+	assert.Equal(t, parse(t, `{
+		"name": "gauge",
+		"description": "A gauge\nwith a needle",
+		"version": "2",
+		"enabled": true,
+		"settings": [{"name": "min", "type": "number"}],
+		"config": {"label": "Gauge", "a/b": "x\ny"}
+	}`), parse(t, string(meta)))
+
+	compressed, err := Compress(dir)
+	require.NoError(t, err)
+	assert.Equal(t, plugin, compressed)
+
+	// new files add fields
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "init.js"), []byte("init()"), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0666))
+	compressed, err = Compress(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "init()", compressed["init"])
+	assert.NotContains(t, compressed, "README")
+}
+
+func TestCompressConflicts(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, MetaFileName), []byte(`{"name": "p", "code": "x", "settings": []}`), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "code.js"), []byte("y"), 0666))
+	_, err := Compress(dir)
+	assert.EqualError(t, err, "plugin field code is both in meta.json and a file")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "code.js")))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "settings"), 0777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "settings", "render.js"), []byte("y"), 0666))
+	_, err = Compress(dir)
+	assert.EqualError(t, err, "plugin field settings.render isn't an object")
+
+	_, err = Compress(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestIsCodeField(t *testing.T) {
+	assert.True(t, IsCodeField([]string{"javascript"}, "x"))
+	assert.True(t, IsCodeField([]string{"config", "renderCode"}, ""))
+	assert.True(t, IsCodeField([]string{"text"}, "a\nb"))
+	assert.False(t, IsCodeField([]string{"text"}, "a"))
+	assert.False(t, IsCodeField([]string{"code"}, 1.0))
+	assert.False(t, IsCodeField([]string{"description"}, "a\nb"))
+	assert.False(t, IsCodeField([]string{".."}, "a\nb"))
+}
@@ -0,0 +1,230 @@
+// Package portalassets moves the assets portals embed as base64 data URIs,
+// e.g. the images of HTML widgets and the fonts of internal resources, out of
+// the portal config and into files of their own. Each data URI is replaced by
+// a reference, cb-asset:<file>, which is replaced by the data URI of the file
+// again when the portal is pushed.
+package portalassets
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/clearblade/cblib/syspath"
+)
+
+// Dir is the name of the assets directory, in the directory of the portal.
+const Dir = "assets"
+
+// MapFileName is the name of the file, in the assets directory, holding the
+// media type of each asset. It is a sidecar file; see
+// syspath.PortalSidecarExt.
+const MapFileName = "assets" + syspath.PortalSidecarExt
+
+// ReferencePrefix starts the reference to an asset.
+const ReferencePrefix = "cb-asset:"
+
+// dataURIPattern matches base64 data URIs with a media type, e.g.
+// data:image/png;base64,iVBORw0KGgo...
+var dataURIPattern = regexp.MustCompile(`data:([A-Za-z0-9.+-]+/[A-Za-z0-9.+-]+(?:;[A-Za-z0-9._+-]+=[A-Za-z0-9._+-]+)*);base64,([A-Za-z0-9+/]+={0,2})`)
+
+var referencePattern = regexp.MustCompile(regexp.QuoteMeta(ReferencePrefix) + `([A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9_-])?)`)
+
+// extensions are the file extensions of the common media types of assets.
+var extensions = map[string]string{
+	"application/font-woff":         ".woff",
+	"application/pdf":               ".pdf",
+	"application/vnd.ms-fontobject": ".eot",
+	"audio/mpeg":                    ".mp3",
+	"audio/wav":                     ".wav",
+	"font/otf":                      ".otf",
+	"font/ttf":                      ".ttf",
+	"font/woff":                     ".woff",
+	"font/woff2":                    ".woff2",
+	"image/bmp":                     ".bmp",
+	"image/gif":                     ".gif",
+	"image/jpeg":                    ".jpg",
+	"image/png":                     ".png",
+	"image/svg+xml":                 ".svg",
+	"image/webp":                    ".webp",
+	"image/x-icon":                  ".ico",
+	"video/mp4":                     ".mp4",
+}
+
+// Extract replaces the data URIs in the strings of value by references, and
+// writes the assets to dir, which is emptied first. value is left unchanged;
+// the value with references is returned. Data URIs whose base64 isn't
+// canonical are left in place, as they couldn't be restored byte for byte.
+func Extract(value interface{}, dir string) (interface{}, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	mediaTypes := map[string]string{}
+	var writeErr error
+	rtn := mapStrings(value, func(s string) string {
+		return dataURIPattern.ReplaceAllStringFunc(s, func(uri string) string {
+			match := dataURIPattern.FindStringSubmatch(uri)
+			mediaType, encoded := match[1], match[2]
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil || base64.StdEncoding.EncodeToString(data) != encoded {
+				return uri
+			}
+			name := FileName(mediaType, data)
+			if _, ok := mediaTypes[name]; !ok {
+				if err := writeAsset(dir, name, data); err != nil && writeErr == nil {
+					writeErr = err
+				}
+				mediaTypes[name] = mediaType
+			}
+			return ReferencePrefix + name
+		})
+	})
+	if writeErr != nil {
+		return nil, writeErr
+	}
+	if len(mediaTypes) == 0 {
+		return rtn, nil
+	}
+	return rtn, writeMap(filepath.Join(dir, MapFileName), mediaTypes)
+}
+
+// Inline replaces the references in the strings of value by the data URIs of
+// the assets in dir. value is left unchanged; the value with data URIs is
+// returned. The media type of an asset missing from the map file, e.g. one
+// added by hand, is the one of its extension.
+func Inline(value interface{}, dir string) (interface{}, error) {
+	mediaTypes, err := readMap(filepath.Join(dir, MapFileName))
+	if err != nil {
+		return nil, err
+	}
+	uris := map[string]string{}
+	var inlineErr error
+	rtn := mapStrings(value, func(s string) string {
+		return referencePattern.ReplaceAllStringFunc(s, func(reference string) string {
+			name := strings.TrimPrefix(reference, ReferencePrefix)
+			if uri, ok := uris[name]; ok {
+				return uri
+			}
+			uri, err := dataURI(dir, name, mediaTypes[name])
+			if err != nil {
+				if inlineErr == nil {
+					inlineErr = err
+				}
+				return reference
+			}
+			uris[name] = uri
+			return uri
+		})
+	})
+	if inlineErr != nil {
+		return nil, inlineErr
+	}
+	return rtn, nil
+}
+
+// FileName returns the name of the file of an asset: a hash of its content
+// and media type, so that an asset used in several places is written once,
+// with the extension of the media type.
+func FileName(mediaType string, data []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(mediaType + "\n"))
+	hash.Write(data)
+	return hex.EncodeToString(hash.Sum(nil))[:16] + extension(mediaType)
+}
+
+func extension(mediaType string) string {
+	base := strings.ToLower(strings.SplitN(mediaType, ";", 2)[0])
+	if ext, ok := extensions[base]; ok {
+		return ext
+	}
+	return ".bin"
+}
+
+func mediaTypeOf(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	found := []string{}
+	for mediaType, e := range extensions {
+		if e == ext {
+			found = append(found, mediaType)
+		}
+	}
+	if len(found) == 0 {
+		return ""
+	}
+	// prefer the standard font/woff to application/font-woff
+	sort.Strings(found)
+	return found[len(found)-1]
+}
+
+func dataURI(dir, name, mediaType string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", fmt.Errorf("could not read asset %s: %s", name, err)
+	}
+	if mediaType == "" {
+		mediaType = mediaTypeOf(name)
+	}
+	if mediaType == "" {
+		return "", fmt.Errorf("unknown media type of asset %s; add it to %s", name, MapFileName)
+	}
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// mapStrings returns a copy of value, a decoded JSON value, with f applied
+// to its strings.
+func mapStrings(value interface{}, f func(string) string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return f(v)
+	case map[string]interface{}:
+		rtn := make(map[string]interface{}, len(v))
+		for key, element := range v {
+			rtn[key] = mapStrings(element, f)
+		}
+		return rtn
+	case []interface{}:
+		rtn := make([]interface{}, len(v))
+		for i, element := range v {
+			rtn[i] = mapStrings(element, f)
+		}
+		return rtn
+	default:
+		return value
+	}
+}
+
+func writeAsset(dir, name string, data []byte) error {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, name), data, 0666)
+}
+
+func readMap(path string) (map[string]string, error) {
+	mediaTypes := map[string]string{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return mediaTypes, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &mediaTypes); err != nil {
+		return nil, fmt.Errorf("invalid asset map %s: %s", path, err)
+	}
+	return mediaTypes, nil
+}
+
+func writeMap(path string, mediaTypes map[string]string) error {
+	data, err := json.MarshalIndent(mediaTypes, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0666)
+}
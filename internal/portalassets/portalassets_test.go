@@ -0,0 +1,97 @@
+package portalassets
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractAndInline(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nnot really a png")
+	font := []byte("wOF2 font bytes")
+	pngURI := "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+	fontURI := "data:font/woff2;charset=binary;base64," + base64.StdEncoding.EncodeToString(font)
+	nonCanonical := "data:image/gif;base64,QR=="
+
+	config := map[string]interface{}{
+		"widgets": map[string]interface{}{
+			"w1": map[string]interface{}{"html": `<img src="` + pngURI + `"><img src='` + pngURI + `'>`, "size": 3.0},
+		},
+		"internalResources": map[string]interface{}{
+			"r1": map[string]interface{}{"file": "@font-face { src: url(" + fontURI + ") }"},
+		},
+		"list": []interface{}{nonCanonical, "data:text/plain,not base64", true},
+	}
+
+	dir := filepath.Join(t.TempDir(), Dir)
+	require.NoError(t, os.MkdirAll(dir, 0777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stale.png"), []byte("stale"), 0666))
+
+	extracted, err := Extract(config, dir)
+	require.NoError(t, err)
+
+	pngName, fontName := FileName("image/png", png), FileName("font/woff2;charset=binary", font)
+	assert.Regexp(t, `^[0-9a-f]{16}\.png$`, pngName)
+	assert.Regexp(t, `^[0-9a-f]{16}\.woff2$`, fontName)
+	assert.Equal(t, map[string]interface{}{
+		"widgets": map[string]interface{}{
+			"w1": map[string]interface{}{"html": `<img src="cb-asset:` + pngName + `"><img src='cb-asset:` + pngName + `'>`, "size": 3.0},
+		},
+		"internalResources": map[string]interface{}{
+			"r1": map[string]interface{}{"file": "@font-face { src: url(cb-asset:" + fontName + ") }"},
+		},
+		"list": []interface{}{nonCanonical, "data:text/plain,not base64", true},
+	}, extracted)
+	assert.Contains(t, config["widgets"].(map[string]interface{})["w1"].(map[string]interface{})["html"], pngURI)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{pngName, fontName, MapFileName}, names)
+	data, err := os.ReadFile(filepath.Join(dir, pngName))
+	require.NoError(t, err)
+	assert.Equal(t, png, data)
+
+	inlined, err := Inline(extracted, dir)
+	require.NoError(t, err)
+	assert.Equal(t, config, inlined)
+}
+
+func TestInline(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logo.png"), []byte("png"), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "font.woff"), []byte("woff"), 0666))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blob.xyz"), []byte("xyz"), 0666))
+
+	// assets added by hand get the media type of their extension
+	inlined, err := Inline("url(cb-asset:logo.png). cb-asset:font.woff", dir)
+	require.NoError(t, err)
+	assert.Equal(t, "url(data:image/png;base64,cG5n). data:font/woff;base64,d29mZg==", inlined)
+
+	_, err = Inline([]interface{}{"cb-asset:blob.xyz"}, dir)
+	assert.EqualError(t, err, "unknown media type of asset blob.xyz; add it to assets.map")
+
+	_, err = Inline(map[string]interface{}{"a": "cb-asset:missing.png"}, dir)
+	assert.Error(t, err)
+
+	// values without references are returned as they are
+	inlined, err = Inline(map[string]interface{}{"a": 1.0, "b": nil}, filepath.Join(dir, "none"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": 1.0, "b": nil}, inlined)
+}
+
+func TestExtractWithoutAssets(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), Dir)
+	extracted, err := Extract(map[string]interface{}{"a": "b"}, dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"a": "b"}, extracted)
+	_, err = os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,160 @@
+// Package portalcode runs external formatters and linters over the code of
+// decompressed portals: the parsers of widgets and datasources, the HTML,
+// JavaScript and CSS of HTML widgets, and internal resources. Formatters run
+// when a portal is pulled, linters before it is pushed, so that a push
+// fails on code which doesn't pass them.
+package portalcode
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/clearblade/cblib/internal/fsutil"
+)
+
+// Hook is a command run over each code file it applies to.
+type Hook struct {
+	// Command is the command line, run in the system root. {file} is
+	// replaced with the path of the file.
+	Command []string `json:"command"`
+	// Extensions are the extensions of the files the hook applies to, e.g.
+	// [".js"]. The hook applies to every code file when empty.
+	Extensions []string `json:"extensions,omitempty"`
+}
+
+// Config is the portal code configuration, the portal_code entry of the
+// workspace settings, e.g.
+//
+//	{"format": [{"command": ["npx", "prettier", "--write", "{file}"]}],
+//	 "lint": [{"command": ["npx", "eslint", "{file}"], "extensions": [".js"]}]}
+type Config struct {
+	// Format hooks run when a portal is pulled. A failure is reported,
+	// but the file is kept as it was pulled.
+	Format []Hook `json:"format,omitempty"`
+	// Lint hooks run before a portal is pushed. A failure fails the push.
+	Lint []Hook `json:"lint,omitempty"`
+}
+
+// ParseConfig decodes and validates the portal_code entry of the workspace
+// settings. It returns nil if the entry is missing.
+func ParseConfig(data json.RawMessage) (*Config, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid portal_code settings: %s", err)
+	}
+	return config, config.Validate()
+}
+
+// Validate returns an error if a hook has no command.
+func (c *Config) Validate() error {
+	for _, hooks := range [][]Hook{c.Format, c.Lint} {
+		for _, hook := range hooks {
+			if len(hook.Command) == 0 {
+				return fmt.Errorf("portal code hook without a command")
+			}
+		}
+	}
+	return nil
+}
+
+// CodeExtensions are the extensions of the files holding portal code.
+var CodeExtensions = []string{".js", ".html", ".css"}
+
+// IsCode returns true if the file holds portal code.
+func IsCode(path string) bool {
+	return hasExtension(path, CodeExtensions)
+}
+
+// File is a code file of a portal.
+type File struct {
+	// Path is the path of the file, absolute or relative to the system root.
+	Path string
+	// Owner describes what the code belongs to, e.g. widget 'Temperature'.
+	Owner string
+}
+
+// Runner runs the hooks of a configuration.
+type Runner struct {
+	Config *Config
+	// Dir is the directory the commands run in, the system root, so that
+	// node_modules/.bin tools resolve.
+	Dir string
+}
+
+// Format runs the format hooks over the files. Every file is formatted, and
+// the failures are returned together.
+func (r *Runner) Format(files []File) error {
+	return r.run(r.Config.Format, files, "format")
+}
+
+// Lint runs the lint hooks over the files. Every file is linted, and the
+// failures are returned together.
+func (r *Runner) Lint(files []File) error {
+	return r.run(r.Config.Lint, files, "lint")
+}
+
+func (r *Runner) run(hooks []Hook, files []File, step string) error {
+	errs := []error{}
+	for _, file := range files {
+		for _, hook := range hooks {
+			if len(hook.Extensions) > 0 && !hasExtension(file.Path, hook.Extensions) {
+				continue
+			}
+			if err := r.runHook(hook, file); err != nil {
+				errs = append(errs, fmt.Errorf("%s of %s, %s, failed: %s", step, file.Owner, file.Path, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Runner) runHook(hook Hook, file File) error {
+	args := make([]string, len(hook.Command))
+	for i, arg := range hook.Command {
+		args[i] = strings.ReplaceAll(arg, "{file}", file.Path)
+	}
+	program, err := r.lookPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	var output bytes.Buffer
+	cmd := exec.Command(program, args[1:]...)
+	cmd.Dir = r.Dir
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		if text := strings.TrimSpace(output.String()); text != "" {
+			return fmt.Errorf("%s\n%s", err, text)
+		}
+		return err
+	}
+	return nil
+}
+
+// lookPath finds the program in node_modules/.bin first, then in PATH.
+func (r *Runner) lookPath(program string) (string, error) {
+	path, err := fsutil.LookPath(r.Dir, program)
+	if err != nil {
+		return "", fmt.Errorf("'%s' not found", program)
+	}
+	return path, nil
+}
+
+func hasExtension(path string, extensions []string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range extensions {
+		if strings.ToLower(e) == ext {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package portalcode
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeScript(t *testing.T, path, script string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0777))
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0777))
+}
+
+func TestFormat(t *testing.T) {
+	root := t.TempDir()
+	// found in node_modules/.bin, as installed by npm
+	writeScript(t, filepath.Join(root, "node_modules", ".bin", "fmt"), `echo "formatted" >> "$1"`)
+	js := filepath.Join(root, "index.js")
+	css := filepath.Join(root, "index.css")
+	require.NoError(t, os.WriteFile(js, []byte("x\n"), 0666))
+	require.NoError(t, os.WriteFile(css, []byte("y\n"), 0666))
+
+	runner := &Runner{Dir: root, Config: &Config{Format: []Hook{{Command: []string{"fmt", "{file}"}, Extensions: []string{".JS"}}}}}
+	require.NoError(t, runner.Format([]File{{Path: js, Owner: "widget 'a'"}, {Path: css, Owner: "widget 'a'"}}))
+
+	data, err := os.ReadFile(js)
+	require.NoError(t, err)
+	assert.Equal(t, "x\nformatted\n", string(data))
+	data, err = os.ReadFile(css)
+	require.NoError(t, err)
+	assert.Equal(t, "y\n", string(data))
+}
+
+func TestLint(t *testing.T) {
+	root := t.TempDir()
+	lint := filepath.Join(root, "lint.sh")
+	writeScript(t, lint, `if grep -q bad "$1"; then echo "$1: syntax error"; exit 1; fi`)
+	good := filepath.Join(root, "good.js")
+	bad := filepath.Join(root, "bad.js")
+	require.NoError(t, os.WriteFile(good, []byte("ok"), 0666))
+	require.NoError(t, os.WriteFile(bad, []byte("bad"), 0666))
+
+	runner := &Runner{Dir: root, Config: &Config{Lint: []Hook{{Command: []string{lint, "{file}"}}}}}
+	require.NoError(t, runner.Lint([]File{{Path: good, Owner: "widget 'Gauge'"}}))
+
+	err := runner.Lint([]File{{Path: good, Owner: "widget 'Gauge'"}, {Path: bad, Owner: "datasource 'readings'"}})
+	assert.EqualError(t, err, "lint of datasource 'readings', "+bad+", failed: exit status 1\n"+bad+": syntax error")
+
+	runner.Config.Lint[0].Command = []string{"no-such-linter-installed", "{file}"}
+	err = runner.Lint([]File{{Path: good, Owner: "widget 'Gauge'"}})
+	assert.EqualError(t, err, "lint of widget 'Gauge', "+good+", failed: 'no-such-linter-installed' not found")
+}
+
+func TestConfig(t *testing.T) {
+	assert.NoError(t, (&Config{Lint: []Hook{{Command: []string{"eslint"}}}}).Validate())
+	assert.EqualError(t, (&Config{Format: []Hook{{Extensions: []string{".js"}}}}).Validate(), "portal code hook without a command")
+
+	assert.True(t, IsCode("portals/p/config/widgets/w/parsers/value/incoming_parser/index.js"))
+	assert.True(t, IsCode("index.HTML"))
+	assert.False(t, IsCode("portals/p/config/widgets/w/meta.json"))
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig([]byte(`{"lint": [{"command": ["eslint", "{file}"], "extensions": [".js"]}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, &Config{Lint: []Hook{{Command: []string{"eslint", "{file}"}, Extensions: []string{".js"}}}}, config)
+
+	config, err = ParseConfig(nil)
+	require.NoError(t, err)
+	assert.Nil(t, config)
+
+	_, err = ParseConfig([]byte(`{"format": [{"command": []}]}`))
+	assert.EqualError(t, err, "portal code hook without a command")
+}
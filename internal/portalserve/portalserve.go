@@ -0,0 +1,377 @@
+// Package portalserve serves a local preview of a portal: its compressed
+// config, the HTML, JavaScript and CSS of its widgets and their parsers. The
+// preview reloads itself whenever the portal is recompressed, and the
+// platform API calls of its datasources are proxied to the platform.
+package portalserve
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed preview.html widget.html
+var templates embed.FS
+
+var (
+	previewTemplate = template.Must(template.ParseFS(templates, "preview.html"))
+	widgetTemplate  = template.Must(template.ParseFS(templates, "widget.html"))
+)
+
+// Keys of the web content of a widget setting.
+const (
+	htmlKey       = "HTML"
+	javascriptKey = "JavaScript"
+	cssKey        = "CSS"
+)
+
+// ProxiedPrefixes are the paths proxied to the platform: the data, code and
+// messaging APIs the datasources call.
+var ProxiedPrefixes = []string{"/api/"}
+
+// loopbackHosts are the names of the local host.
+var loopbackHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// Compressor returns the compressed portal, as pushed to the platform.
+type Compressor func() (map[string]interface{}, error)
+
+// Server serves the preview of a portal.
+type Server struct {
+	Name     string
+	Compress Compressor
+	// Addr is the address the preview is served on, e.g. localhost:8090.
+	Addr string
+	// Platform, if set, is where the API calls of the preview are proxied
+	// to, with Token as the user token. Only calls of the preview itself are
+	// proxied: addressed to Addr, and not from another site.
+	Platform *url.URL
+	Token    string
+
+	mu        sync.Mutex
+	portal    map[string]interface{}
+	err       error
+	version   int
+	listeners map[chan int]bool
+}
+
+// Reload recompresses the portal and tells the open previews to reload. The
+// error of the compression is returned, and shown by the previews.
+func (s *Server) Reload() error {
+	portal, err := s.Compress()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		s.portal = portal
+	}
+	s.err = err
+	s.version++
+	for listener := range s.listeners {
+		select {
+		case listener <- s.version:
+		default:
+		}
+	}
+	return err
+}
+
+func (s *Server) state() (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.portal, s.err
+}
+
+// Handler returns the handler of the preview.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.servePreview)
+	mux.HandleFunc("/config.json", s.serveConfig)
+	mux.HandleFunc("/widgets/", s.serveWidget)
+	mux.HandleFunc("/events", s.serveEvents)
+	if s.Platform != nil {
+		proxy := s.proxy()
+		for _, prefix := range ProxiedPrefixes {
+			mux.Handle(prefix, proxy)
+		}
+	}
+	return mux
+}
+
+func (s *Server) proxy() http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(s.Platform)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = s.Platform.Host
+		if req.Header.Get("ClearBlade-UserToken") == "" && req.Header.Get("ClearBlade-DevToken") == "" {
+			req.Header.Set("ClearBlade-DevToken", s.Token)
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.isPreviewRequest(r) {
+			http.Error(w, "only the preview can call the platform", http.StatusForbidden)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	})
+}
+
+// isPreviewRequest returns true if the request comes from the preview: it is
+// addressed to the preview, which rebound DNS names aren't, and isn't sent by
+// another site.
+func (s *Server) isPreviewRequest(r *http.Request) bool {
+	if !s.isPreviewHost(r.Host) {
+		return false
+	}
+	if origin := r.Header.Get("Origin"); origin != "" && origin != "http://"+r.Host {
+		return false
+	}
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Server) isPreviewHost(hostPort string) bool {
+	if strings.EqualFold(hostPort, s.Addr) {
+		return true
+	}
+	addrHost, addrPort, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return false
+	}
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil || port != addrPort {
+		return false
+	}
+	// a preview served on a loopback address, or on every interface, is
+	// opened with any name of the local host
+	if !IsLoopback(host) {
+		return false
+	}
+	ip := net.ParseIP(addrHost)
+	return addrHost == "" || IsLoopback(addrHost) || ip != nil && ip.IsUnspecified()
+}
+
+// IsLoopback returns true if the host, a name or an IP address, is the local
+// host.
+func IsLoopback(host string) bool {
+	for _, loopback := range loopbackHosts {
+		if strings.EqualFold(host, loopback) {
+			return true
+		}
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *Server) servePreview(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	portal, err := s.state()
+	data := struct {
+		Name    string
+		Error   string
+		Widgets []*Widget
+	}{Name: s.Name}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	if portal != nil {
+		data.Widgets = Widgets(portal)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	previewTemplate.Execute(w, data)
+}
+
+func (s *Server) serveConfig(w http.ResponseWriter, r *http.Request) {
+	portal, err := s.state()
+	if portal == nil {
+		http.Error(w, fmt.Sprintf("portal '%s' could not be compressed: %v", s.Name, err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	encoder.Encode(portal)
+}
+
+// serveWidget serves /widgets/<id>/<setting>: a page of the HTML, CSS and
+// JavaScript of the setting.
+func (s *Server) serveWidget(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/widgets/"), "/")
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	portal, _ := s.state()
+	if portal == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	for _, widget := range Widgets(portal) {
+		if widget.ID != parts[0] {
+			continue
+		}
+		for _, content := range widget.Web {
+			if content.Setting == parts[1] {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				widgetTemplate.Execute(w, struct {
+					HTML       template.HTML
+					CSS        template.CSS
+					JavaScript template.JS
+				}{template.HTML(content.HTML), template.CSS(content.CSS), template.JS(content.JavaScript)})
+				return
+			}
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// serveEvents streams an event each time the portal is reloaded.
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	listener := make(chan int, 1)
+	s.mu.Lock()
+	if s.listeners == nil {
+		s.listeners = map[chan int]bool{}
+	}
+	s.listeners[listener] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, listener)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case version := <-listener:
+			fmt.Fprintf(w, "event: reload\ndata: %d\n\n", version)
+			flusher.Flush()
+		}
+	}
+}
+
+// Widget is a widget of a portal, with the content of its settings.
+type Widget struct {
+	ID   string
+	Type string
+	Name string
+	// Web are the settings holding HTML, JavaScript or CSS.
+	Web []*WebContent
+	// Parsers are the settings holding parser code.
+	Parsers []*Parser
+}
+
+// WebContent is the web content of a widget setting.
+type WebContent struct {
+	Setting    string
+	HTML       string
+	JavaScript string
+	CSS        string
+}
+
+// Parser is the code of a parser of a widget setting.
+type Parser struct {
+	Setting string
+	Kind    string
+	Code    string
+}
+
+// Widgets returns the widgets of a compressed portal, sorted by name and id.
+func Widgets(portal map[string]interface{}) []*Widget {
+	config, _ := portal["config"].(map[string]interface{})
+	raw, _ := config["widgets"].(map[string]interface{})
+
+	widgets := []*Widget{}
+	for id, value := range raw {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		widget := &Widget{ID: id}
+		widget.Type, _ = fields["type"].(string)
+		props, _ := fields["props"].(map[string]interface{})
+		widget.Name, _ = props["name"].(string)
+
+		for _, setting := range sortedKeys(props) {
+			collectContent(widget, setting, "", props[setting])
+		}
+		widgets = append(widgets, widget)
+	}
+	sort.Slice(widgets, func(i, j int) bool {
+		if widgets[i].Name != widgets[j].Name {
+			return widgets[i].Name < widgets[j].Name
+		}
+		return widgets[i].ID < widgets[j].ID
+	})
+	return widgets
+}
+
+// collectContent adds the web content and parsers found in the value of a
+// setting to the widget. kind is the parser the value belongs to, if any.
+func collectContent(widget *Widget, setting, kind string, value interface{}) {
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	html, hasHTML := fields[htmlKey].(string)
+	js, hasJS := fields[javascriptKey].(string)
+	css, hasCSS := fields[cssKey].(string)
+	if hasHTML || hasJS || hasCSS {
+		widget.Web = append(widget.Web, &WebContent{Setting: setting, HTML: html, JavaScript: js, CSS: css})
+		return
+	}
+
+	for _, key := range sortedKeys(fields) {
+		switch key {
+		case "incoming_parser", "outgoing_parser":
+			collectContent(widget, setting, key, fields[key])
+		case "value":
+			parserKind := kind
+			if _, ok := fields["dataType"]; ok && parserKind == "" {
+				// the value of a setting without parsers is its incoming parser
+				parserKind = "incoming_parser"
+			}
+			if code, ok := fields[key].(string); ok && parserKind != "" && strings.TrimSpace(code) != "" {
+				widget.Parsers = append(widget.Parsers, &Parser{Setting: setting, Kind: parserKind, Code: code})
+			} else {
+				collectContent(widget, setting, parserKind, fields[key])
+			}
+		}
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,179 @@
+package portalserve
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPortal() map[string]interface{} {
+	var portal map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"name": "dash",
+		"config": {
+			"widgets": {
+				"w1": {"type": "HTML_WIDGET", "props": {
+					"name": "Banner",
+					"html": {"dataType": "DYNAMIC_DATA_TYPE", "incoming_parser": {"value": {"HTML": "<h1 id=\"t\">Hi</h1>", "JavaScript": "document.getElementById('t').title = 'x';", "CSS": "h1 { color: red; }"}}}
+				}},
+				"w2": {"type": "LABEL_WIDGET", "props": {
+					"name": "Count",
+					"label": {"dataType": "DYNAMIC_DATA_TYPE", "incoming_parser": {"value": "return this.datasource.count;"}, "outgoing_parser": {"value": ""}},
+					"color": {"dataType": "STATIC_DATA_TYPE", "value": "return 'blue';"},
+					"size": 12
+				}}
+			},
+			"datasources": {}
+		}
+	}`), &portal)
+	return portal
+}
+
+func get(t *testing.T, handler http.Handler, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	return rec
+}
+
+func TestWidgets(t *testing.T) {
+	widgets := Widgets(testPortal())
+	require.Len(t, widgets, 2)
+
+	assert.Equal(t, "Banner", widgets[0].Name)
+	require.Len(t, widgets[0].Web, 1)
+	assert.Equal(t, "html", widgets[0].Web[0].Setting)
+	assert.Equal(t, "h1 { color: red; }", widgets[0].Web[0].CSS)
+
+	assert.Equal(t, "Count", widgets[1].Name)
+	assert.Empty(t, widgets[1].Web)
+	assert.Equal(t, []*Parser{
+		{Setting: "color", Kind: "incoming_parser", Code: "return 'blue';"},
+		{Setting: "label", Kind: "incoming_parser", Code: "return this.datasource.count;"},
+	}, widgets[1].Parsers)
+}
+
+func TestServer(t *testing.T) {
+	compressErr := error(nil)
+	server := &Server{Name: "dash", Compress: func() (map[string]interface{}, error) {
+		if compressErr != nil {
+			return nil, compressErr
+		}
+		return testPortal(), nil
+	}}
+	handler := server.Handler()
+
+	rec := get(t, handler, "/config.json")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	require.NoError(t, server.Reload())
+	rec = get(t, handler, "/")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `<iframe src="/widgets/w1/html"></iframe>`)
+	assert.Contains(t, rec.Body.String(), "return this.datasource.count;")
+
+	rec = get(t, handler, "/widgets/w1/html")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `<style>h1 { color: red; }</style>`)
+	assert.Contains(t, rec.Body.String(), `<h1 id="t">Hi</h1>`)
+	assert.Contains(t, rec.Body.String(), `<script>document.getElementById('t').title = 'x';</script>`)
+	assert.Equal(t, http.StatusNotFound, get(t, handler, "/widgets/w2/label").Code)
+
+	rec = get(t, handler, "/config.json")
+	config := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &config))
+	assert.Equal(t, "dash", config["name"])
+
+	// a failed compression keeps the last portal and shows the error
+	compressErr = errors.New("widgets/w3/meta.json: no such file")
+	assert.Error(t, server.Reload())
+	rec = get(t, handler, "/")
+	assert.Contains(t, rec.Body.String(), "Could not compress the portal: widgets/w3/meta.json: no such file")
+	assert.Contains(t, rec.Body.String(), "Banner")
+}
+
+func TestEvents(t *testing.T) {
+	server := &Server{Name: "dash", Compress: func() (map[string]interface{}, error) { return testPortal(), nil }}
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	require.NoError(t, server.Reload())
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: reload\n", line)
+}
+
+func TestProxy(t *testing.T) {
+	platform := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path+" "+r.Header.Get("ClearBlade-DevToken")+" "+r.Header.Get("ClearBlade-UserToken"))
+	}))
+	defer platform.Close()
+	platformURL, err := url.Parse(platform.URL)
+	require.NoError(t, err)
+
+	server := &Server{Name: "dash", Compress: func() (map[string]interface{}, error) { return testPortal(), nil }, Addr: "localhost:8090", Platform: platformURL, Token: "dev-token"}
+	handler := server.Handler()
+	call := func(method, host, path string, headers map[string]string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+		req.Host = host
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call("GET", "localhost:8090", "/api/v/1/collection/abc", nil)
+	assert.Equal(t, "/api/v/1/collection/abc dev-token ", rec.Body.String())
+
+	rec = call("POST", "127.0.0.1:8090", "/api/v/1/code/key/svc", map[string]string{"ClearBlade-UserToken": "user-token", "Origin": "http://127.0.0.1:8090", "Sec-Fetch-Site": "same-origin"})
+	assert.Equal(t, "/api/v/1/code/key/svc  user-token", rec.Body.String())
+
+	// other sites, rebound DNS names and other ports are rejected
+	for _, rejected := range []struct {
+		host    string
+		headers map[string]string
+	}{
+		{"localhost:8090", map[string]string{"Origin": "http://evil.example"}},
+		{"localhost:8090", map[string]string{"Sec-Fetch-Site": "cross-site"}},
+		{"evil.example:8090", nil},
+		{"localhost:9000", nil},
+	} {
+		rec = call("POST", rejected.host, "/api/v/1/code/key/svc", rejected.headers)
+		assert.Equal(t, http.StatusForbidden, rec.Code, "%v", rejected)
+	}
+
+	// only the APIs of the datasources are proxied
+	rec = call("GET", "localhost:8090", "/admin/settings", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// served on every interface, the preview is still only opened locally
+	server.Addr = ":8090"
+	handler = server.Handler()
+	assert.Equal(t, http.StatusOK, call("GET", "[::1]:8090", "/api/v/1/collection/abc", nil).Code)
+	assert.Equal(t, http.StatusForbidden, call("GET", "192.168.1.20:8090", "/api/v/1/collection/abc", nil).Code)
+}
+
+func TestIsLoopback(t *testing.T) {
+	assert.True(t, IsLoopback("localhost"))
+	assert.True(t, IsLoopback("127.0.0.2"))
+	assert.True(t, IsLoopback("::1"))
+	assert.False(t, IsLoopback(""))
+	assert.False(t, IsLoopback("0.0.0.0"))
+	assert.False(t, IsLoopback("example.com"))
+}
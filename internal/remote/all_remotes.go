@@ -12,15 +12,16 @@ const (
 // remotesData is a private structure that contains the "state" or "data" of
 // the remotes.
 type remotesData struct {
-	Remotes map[string]*Remote `json:"remotes" yaml:"remotes"`
-	Current string             `json:"current" yaml:"current"`
+	Remotes map[string]*Remote  `json:"remotes" yaml:"remotes"`
+	Current string              `json:"current" yaml:"current"`
+	Groups  map[string][]string `json:"groups,omitempty" yaml:"groups,omitempty"`
 }
 
 // makeRemotesData returns a new remotesData instance.
 func makeRemotesData() remotesData {
 	return remotesData{
-		make(map[string]*Remote),
-		"",
+		Remotes: make(map[string]*Remote),
+		Current: "",
 	}
 }
 
@@ -74,6 +75,7 @@ func (rs *Remotes) Remove(remote *Remote) error {
 		return fmt.Errorf("%s: %s", errRemoteNotPresent, remote.Name)
 	}
 	delete(rs.data.Remotes, remote.Name)
+	rs.removeFromGroups(remote.Name)
 	rs.updateCurrentIfNeeded()
 	return nil
 }
@@ -0,0 +1,114 @@
+package remote
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	errGroupNotPresent = "group not present"
+)
+
+// Group is a named set of remotes that can be targeted at once.
+type Group struct {
+	Name    string
+	Remotes []*Remote
+}
+
+// PutGroup creates or replaces the group with the given name. Every member
+// must be an existing remote.
+func (rs *Remotes) PutGroup(name string, members []string) error {
+	err := validateRemoteName(name)
+	if err != nil {
+		return fmt.Errorf("invalid group name: %w", err)
+	}
+
+	if len(members) == 0 {
+		return fmt.Errorf("group %s must have at least one remote", name)
+	}
+
+	seen := make(map[string]struct{}, len(members))
+	unique := make([]string, 0, len(members))
+	for _, member := range members {
+		if !rs.HasByName(member) {
+			return fmt.Errorf("%s: %s", errRemoteNotPresent, member)
+		}
+
+		if _, ok := seen[member]; ok {
+			continue
+		}
+		seen[member] = struct{}{}
+		unique = append(unique, member)
+	}
+
+	if rs.data.Groups == nil {
+		rs.data.Groups = make(map[string][]string)
+	}
+
+	rs.data.Groups[name] = unique
+	return nil
+}
+
+// RemoveGroup removes the group with the given name. The remotes themselves are
+// left untouched.
+func (rs *Remotes) RemoveGroup(name string) error {
+	if _, ok := rs.data.Groups[name]; !ok {
+		return fmt.Errorf("%s: %s", errGroupNotPresent, name)
+	}
+	delete(rs.data.Groups, name)
+	return nil
+}
+
+// FindGroup finds a group given the name. Remotes are returned in the order
+// they were declared.
+func (rs *Remotes) FindGroup(name string) (*Group, bool) {
+	members, ok := rs.data.Groups[name]
+	if !ok {
+		return nil, false
+	}
+
+	group := &Group{Name: name, Remotes: make([]*Remote, 0, len(members))}
+	for _, member := range members {
+		if r, ok := rs.FindByName(member); ok {
+			group.Remotes = append(group.Remotes, r)
+		}
+	}
+
+	return group, true
+}
+
+// ListGroups lists all the groups sorted by name.
+func (rs *Remotes) ListGroups() []*Group {
+	names := make([]string, 0, len(rs.data.Groups))
+	for name := range rs.data.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	groups := make([]*Group, 0, len(names))
+	for _, name := range names {
+		group, _ := rs.FindGroup(name)
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// removeFromGroups drops the given remote from every group, removing groups
+// that end up empty.
+func (rs *Remotes) removeFromGroups(remoteName string) {
+	for name, members := range rs.data.Groups {
+		kept := make([]string, 0, len(members))
+		for _, member := range members {
+			if member != remoteName {
+				kept = append(kept, member)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(rs.data.Groups, name)
+		} else {
+			rs.data.Groups[name] = kept
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package remote
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeStubRemotes(t *testing.T, names ...string) *Remotes {
+	remotes := NewRemotes()
+	for _, name := range names {
+		require.NoError(t, remotes.Put(makeStubRemote(name)))
+	}
+	return remotes
+}
+
+func TestPutGroup(t *testing.T) {
+	remotes := makeStubRemotes(t, "foo", "bar")
+
+	err := remotes.PutGroup("regions", []string{"foo", "bar", "foo"})
+	require.NoError(t, err)
+
+	group, ok := remotes.FindGroup("regions")
+	require.True(t, ok)
+	assert.Equal(t, "regions", group.Name)
+
+	foo, _ := remotes.FindByName("foo")
+	bar, _ := remotes.FindByName("bar")
+	assert.Equal(t, []*Remote{foo, bar}, group.Remotes)
+}
+
+func TestPutGroupErrors(t *testing.T) {
+	remotes := makeStubRemotes(t, "foo")
+
+	assert.Error(t, remotes.PutGroup("Bad Name", []string{"foo"}))
+	assert.Error(t, remotes.PutGroup("regions", nil))
+	assert.Error(t, remotes.PutGroup("regions", []string{"foo", "missing"}))
+
+	_, ok := remotes.FindGroup("regions")
+	assert.False(t, ok)
+}
+
+func TestRemoveGroup(t *testing.T) {
+	remotes := makeStubRemotes(t, "foo")
+	require.NoError(t, remotes.PutGroup("regions", []string{"foo"}))
+
+	require.NoError(t, remotes.RemoveGroup("regions"))
+	assert.Empty(t, remotes.ListGroups())
+
+	assert.Error(t, remotes.RemoveGroup("regions"))
+}
+
+func TestListGroups(t *testing.T) {
+	remotes := makeStubRemotes(t, "foo", "bar")
+	require.NoError(t, remotes.PutGroup("zeta", []string{"foo"}))
+	require.NoError(t, remotes.PutGroup("alpha", []string{"bar"}))
+
+	groups := remotes.ListGroups()
+	require.Len(t, groups, 2)
+	assert.Equal(t, "alpha", groups[0].Name)
+	assert.Equal(t, "zeta", groups[1].Name)
+}
+
+func TestRemoveRemoteUpdatesGroups(t *testing.T) {
+	remotes := makeStubRemotes(t, "foo", "bar")
+	require.NoError(t, remotes.PutGroup("both", []string{"foo", "bar"}))
+	require.NoError(t, remotes.PutGroup("only-foo", []string{"foo"}))
+
+	foo, _ := remotes.FindByName("foo")
+	require.NoError(t, remotes.Remove(foo))
+
+	group, ok := remotes.FindGroup("both")
+	require.True(t, ok)
+	assert.Len(t, group.Remotes, 1)
+
+	_, ok = remotes.FindGroup("only-foo")
+	assert.False(t, ok)
+}
+
+func TestGroupsArePersisted(t *testing.T) {
+	tempdir := t.TempDir()
+	os.MkdirAll(path.Join(tempdir, ".cb-cli"), os.ModePerm)
+
+	remotes := makeStubRemotes(t, "foo", "bar")
+	require.NoError(t, remotes.PutGroup("regions", []string{"bar", "foo"}))
+	require.NoError(t, SaveToDir(tempdir, remotes))
+
+	loaded, err := LoadFromDir(tempdir)
+	require.NoError(t, err)
+
+	group, ok := loaded.FindGroup("regions")
+	require.True(t, ok)
+	require.Len(t, group.Remotes, 2)
+	assert.Equal(t, "bar", group.Remotes[0].Name)
+	assert.Equal(t, "foo", group.Remotes[1].Name)
+}
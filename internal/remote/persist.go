@@ -24,12 +24,26 @@ func SaveToDir(rootDir string, remotes *Remotes) error {
 		return err
 	}
 
-	persistPath := makePersistPath(rootDir)
+	return saveToFile(makePersistPath(rootDir), remotes)
+}
+
+// LoadFromDir loads the remotes from the given directory root. If there's no
+// remotes, it returns empty remotes.
+func LoadFromDir(rootDir string) (*Remotes, error) {
+	err := fsutil.EnsureDirectory(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadFromFile(makePersistPath(rootDir))
+}
 
+func saveToFile(persistPath string, remotes *Remotes) error {
 	f, err := os.Create(persistPath)
 	if err != nil {
 		return err
 	}
+	defer f.Close()
 
 	encoder := json.NewEncoder(f)
 	encoder.SetIndent("", "    ")
@@ -41,24 +55,16 @@ func SaveToDir(rootDir string, remotes *Remotes) error {
 	return nil
 }
 
-// LoadFromDir loads the remotes from the given directory root. If there's no
-// remotes, it returns empty remotes.
-func LoadFromDir(rootDir string) (*Remotes, error) {
-	err := fsutil.EnsureDirectory(rootDir)
-	if err != nil {
-		return nil, err
-	}
-
+func loadFromFile(persistPath string) (*Remotes, error) {
 	remotes := NewRemotes()
 
-	persistPath := makePersistPath(rootDir)
-
 	f, err := os.Open(persistPath)
 	if os.IsNotExist(err) {
 		return remotes, nil
 	} else if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
 	err = json.NewDecoder(f).Decode(&remotes.data)
 	if err != nil {
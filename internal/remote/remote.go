@@ -16,6 +16,10 @@ type Remote struct {
 	SystemKey    string
 	SystemSecret string
 	Token        string
+
+	// Profile is the name of a user-level remote this remote inherits from.
+	// Non-empty fields of this remote override the inherited ones.
+	Profile string `json:",omitempty" yaml:",omitempty"`
 }
 
 func validateRemoteName(name string) error {
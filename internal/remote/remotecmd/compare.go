@@ -0,0 +1,243 @@
+package remotecmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cblib/internal/drift"
+	"github.com/clearblade/cblib/internal/remote"
+	"github.com/clearblade/cblib/internal/reportout"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	flagFormat string
+	flagOutput string
+)
+
+func (rc *remoteCommand) compareCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "compare",
+		Usage:     "Compare the assets of two remotes and report drift",
+		ArgsUsage: "<remote-a> <remote-b>",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "category",
+				Usage: fmt.Sprintf("asset category to compare, can be repeated (one of %v). Defaults to all", drift.Categories),
+			},
+			&cli.StringFlag{
+				Name:        "format",
+				Usage:       "report format: text, json or html",
+				Value:       drift.FormatText,
+				Destination: &flagFormat,
+			},
+			&cli.StringFlag{
+				Name:        "output",
+				Usage:       "file to write the report to. Defaults to stdout",
+				Destination: &flagOutput,
+			},
+		},
+		Action: rc.compare,
+	}
+}
+
+func (rc *remoteCommand) compare(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return fmt.Errorf("compare expects exactly two remote names")
+	}
+
+	categories := c.StringSlice("category")
+	err := drift.ValidateCategories(categories)
+	if err != nil {
+		return err
+	}
+
+	if len(categories) == 0 {
+		categories = drift.Categories
+	}
+
+	err = reportout.CheckFormat(flagFormat, drift.Formats)
+	if err != nil {
+		return err
+	}
+
+	remoteA, ok := rc.remotes.FindByName(c.Args().Get(0))
+	if !ok {
+		return fmt.Errorf("%s: %s", errRemoteNotFound, c.Args().Get(0))
+	}
+
+	remoteB, ok := rc.remotes.FindByName(c.Args().Get(1))
+	if !ok {
+		return fmt.Errorf("%s: %s", errRemoteNotFound, c.Args().Get(1))
+	}
+
+	remoteA, err = remote.Resolve(remoteA)
+	if err != nil {
+		return err
+	}
+
+	remoteB, err = remote.Resolve(remoteB)
+	if err != nil {
+		return err
+	}
+
+	snapshotA, err := fetchSnapshot(remoteA, categories)
+	if err != nil {
+		return err
+	}
+
+	snapshotB, err := fetchSnapshot(remoteB, categories)
+	if err != nil {
+		return err
+	}
+
+	report := drift.Compare(remoteA.Name, snapshotA, remoteB.Name, snapshotB, categories)
+
+	var out io.Writer = os.Stdout
+	if flagOutput != "" {
+		f, err := os.Create(flagOutput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return report.Write(out, flagFormat)
+}
+
+// fetchSnapshot pulls the metadata of the given categories from the remote,
+// keeping everything in memory.
+func fetchSnapshot(r *remote.Remote, categories []string) (drift.Snapshot, error) {
+	client := cb.NewDevClientWithTokenAndAddrs(r.PlatformURL, r.MessagingURL, r.Token, "")
+	snapshot := drift.NewSnapshot()
+
+	for _, category := range categories {
+		err := fetchCategory(client, r.SystemKey, category, snapshot)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch %s from remote '%s': %w", category, r.Name, err)
+		}
+	}
+
+	return snapshot, nil
+}
+
+func fetchCategory(client *cb.DevClient, systemKey, category string, snapshot drift.Snapshot) error {
+	switch category {
+	case "services":
+		names, err := client.GetServiceNames(systemKey)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			svc, err := client.GetServiceRaw(systemKey, name)
+			if err != nil {
+				return err
+			}
+			snapshot.Add(category, name, svc)
+		}
+		return nil
+
+	case "libraries":
+		libs, err := client.GetLibraries(systemKey)
+		if err != nil {
+			return err
+		}
+		for _, lib := range libs {
+			libMap, ok := lib.(map[string]interface{})
+			if !ok || libMap["visibility"] == "global" {
+				continue
+			}
+			name, _ := libMap["name"].(string)
+			full, err := client.GetLibrary(systemKey, name)
+			if err != nil {
+				return err
+			}
+			snapshot.Add(category, name, full)
+		}
+		return nil
+
+	case "collections":
+		collections, err := client.GetAllCollections(systemKey)
+		if err != nil {
+			return err
+		}
+		return snapshot.AddList(category, "name", collections)
+
+	case "roles":
+		roles, err := client.GetAllRoles(systemKey)
+		if err != nil {
+			return err
+		}
+		return snapshot.AddList(category, "Name", roles)
+
+	case "triggers":
+		triggers, err := client.GetEventHandlers(systemKey)
+		if err != nil {
+			return err
+		}
+		return snapshot.AddList(category, "name", triggers)
+
+	case "timers":
+		timers, err := client.GetTimers(systemKey)
+		if err != nil {
+			return err
+		}
+		return snapshot.AddList(category, "name", timers)
+
+	case "webhooks":
+		webhooks, err := client.GetAllWebhooks(systemKey)
+		if err != nil {
+			return err
+		}
+		return snapshot.AddList(category, "name", mapsToList(webhooks))
+
+	case "deployments":
+		deployments, err := client.GetAllDeployments(systemKey)
+		if err != nil {
+			return err
+		}
+		return snapshot.AddList(category, "name", deployments)
+
+	case "portals":
+		portals, err := client.GetPortals(systemKey)
+		if err != nil {
+			return err
+		}
+		return snapshot.AddList(category, "name", portals)
+
+	case "plugins":
+		plugins, err := client.GetPlugins(systemKey)
+		if err != nil {
+			return err
+		}
+		return snapshot.AddList(category, "name", plugins)
+
+	case "shared-caches":
+		caches, err := client.GetAllServiceCacheMeta(systemKey)
+		if err != nil {
+			return err
+		}
+		return snapshot.AddList(category, "name", mapsToList(caches))
+
+	case "external-databases":
+		databases, err := client.GetAllExternalDBConnections(systemKey)
+		if err != nil {
+			return err
+		}
+		return snapshot.AddList(category, "name", databases)
+
+	default:
+		return fmt.Errorf("unknown asset category '%s'", category)
+	}
+}
+
+func mapsToList(maps []map[string]interface{}) []interface{} {
+	list := make([]interface{}, 0, len(maps))
+	for _, m := range maps {
+		list = append(list, m)
+	}
+	return list
+}
@@ -0,0 +1,89 @@
+package remotecmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+func (rc *remoteCommand) groupCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "group",
+		Usage: "Manage named groups of remotes",
+		Subcommands: []*cli.Command{
+			{
+				Name:    "list",
+				Aliases: []string{"ls"},
+				Usage:   "List groups",
+				Action:  rc.listGroups,
+			},
+			{
+				Name:  "put",
+				Usage: "Create or update groups",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "name",
+						Usage:       "name of the group",
+						Required:    true,
+						Destination: &flagName,
+					},
+					&cli.StringSliceFlag{
+						Name:     "remote",
+						Usage:    "name of a remote in the group, can be repeated",
+						Required: true,
+					},
+				},
+				Action: rc.putGroup,
+			},
+			{
+				Name:    "remove",
+				Aliases: []string{"rm"},
+				Usage:   "Remove groups",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "name",
+						Usage:       "name of the group",
+						Required:    true,
+						Destination: &flagName,
+					},
+				},
+				Action: rc.removeGroup,
+			},
+		},
+	}
+}
+
+func (rc *remoteCommand) listGroups(c *cli.Context) error {
+	fmt.Printf("%s %s\n", "Name", "Remotes")
+
+	for _, g := range rc.remotes.ListGroups() {
+		names := make([]string, 0, len(g.Remotes))
+		for _, r := range g.Remotes {
+			names = append(names, r.Name)
+		}
+		fmt.Printf("%s %s\n", g.Name, strings.Join(names, ","))
+	}
+
+	return nil
+}
+
+func (rc *remoteCommand) putGroup(c *cli.Context) error {
+	err := rc.remotes.PutGroup(flagName, c.StringSlice("remote"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Group created")
+	return nil
+}
+
+func (rc *remoteCommand) removeGroup(c *cli.Context) error {
+	err := rc.remotes.RemoveGroup(flagName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Group removed")
+	return nil
+}
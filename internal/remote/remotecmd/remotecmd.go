@@ -125,6 +125,10 @@ func New(remotes *remote.Remotes) *cli.Command {
 
 				Action: cmd.setCurrent,
 			},
+			cmd.compareCommand(),
+			cmd.groupCommand(),
+			cmd.importCommand(),
+			cmd.exportCommand(),
 		},
 	}
 }
@@ -142,6 +146,10 @@ func (rc *remoteCommand) list(c *cli.Context) error {
 		if r == curr {
 			prefix = prefixIsCurrent
 		}
+		if r.Profile != "" {
+			fmt.Printf("%s%s %s %s (user: %s)\n", prefix, r.Name, r.PlatformURL, r.SystemKey, r.Profile)
+			continue
+		}
 		fmt.Printf("%s%s %s %s\n", prefix, r.Name, r.PlatformURL, r.SystemKey)
 	}
 
@@ -0,0 +1,130 @@
+package remotecmd
+
+import (
+	"fmt"
+
+	"github.com/clearblade/cblib/internal/remote"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	flagAs           string
+	flagSystemSecret string
+)
+
+func (rc *remoteCommand) importCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "import",
+		Usage: "Add a project remote that references a user-level remote",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "name",
+				Usage:       "name of the user-level remote",
+				Required:    true,
+				Destination: &flagName,
+			},
+			&cli.StringFlag{
+				Name:        "as",
+				Usage:       "name of the project remote. Defaults to the user-level name",
+				Destination: &flagAs,
+			},
+			&cli.StringFlag{
+				Name:        "system-key",
+				Usage:       "System key overriding the one of the user-level remote",
+				Destination: &flagSystemKey,
+			},
+			&cli.StringFlag{
+				Name:        "system-secret",
+				Usage:       "System secret matching -system-key",
+				Destination: &flagSystemSecret,
+			},
+		},
+		Action: rc.importRemote,
+	}
+}
+
+func (rc *remoteCommand) exportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "export",
+		Usage: "Copy a project remote to the user-level remotes",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "name",
+				Usage:       "name of the project remote",
+				Required:    true,
+				Destination: &flagName,
+			},
+			&cli.StringFlag{
+				Name:        "as",
+				Usage:       "name of the user-level remote. Defaults to the project name",
+				Destination: &flagAs,
+			},
+		},
+		Action: rc.exportRemote,
+	}
+}
+
+func (rc *remoteCommand) importRemote(c *cli.Context) error {
+	user, err := remote.LoadUserRemotes()
+	if err != nil {
+		return err
+	}
+
+	if !user.HasByName(flagName) {
+		return fmt.Errorf("%s: %s", errRemoteNotFound, flagName)
+	}
+
+	name := flagName
+	if flagAs != "" {
+		name = flagAs
+	}
+
+	err = rc.remotes.Put(&remote.Remote{
+		Name:         name,
+		Profile:      flagName,
+		SystemKey:    flagSystemKey,
+		SystemSecret: flagSystemSecret,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Remote imported")
+	return nil
+}
+
+func (rc *remoteCommand) exportRemote(c *cli.Context) error {
+	r, ok := rc.remotes.FindByName(flagName)
+	if !ok {
+		return fmt.Errorf("%s: %s", errRemoteNotFound, flagName)
+	}
+
+	resolved, err := remote.Resolve(r)
+	if err != nil {
+		return err
+	}
+
+	user, err := remote.LoadUserRemotes()
+	if err != nil {
+		return err
+	}
+
+	exported := *resolved
+	exported.Profile = ""
+	if flagAs != "" {
+		exported.Name = flagAs
+	}
+
+	err = user.Put(&exported)
+	if err != nil {
+		return err
+	}
+
+	err = remote.SaveUserRemotes(user)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Remote exported")
+	return nil
+}
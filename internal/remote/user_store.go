@@ -0,0 +1,116 @@
+package remote
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	userConfigDirName = "cb-cli"
+)
+
+// UserConfigDir returns the directory holding the user-level configuration,
+// $XDG_CONFIG_HOME/cb-cli (or the platform equivalent).
+func UserConfigDir() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		var err error
+		dir, err = os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, userConfigDirName), nil
+}
+
+func makeUserPersistPath() (string, error) {
+	dir, err := UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "remotes"), nil
+}
+
+// LoadUserRemotes loads the user-level remotes. If there's no remotes, it
+// returns empty remotes.
+func LoadUserRemotes() (*Remotes, error) {
+	persistPath, err := makeUserPersistPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return loadFromFile(persistPath)
+}
+
+// SaveUserRemotes writes the given remotes to the user-level store, creating
+// the configuration directory if needed.
+func SaveUserRemotes(remotes *Remotes) error {
+	persistPath, err := makeUserPersistPath()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(persistPath), 0700)
+	if err != nil {
+		return err
+	}
+
+	return saveToFile(persistPath, remotes)
+}
+
+// Merge returns the effective remote obtained by overlaying the non-empty
+// fields of the project remote on top of the user-level one. The name is
+// always the one of the project remote.
+func Merge(project, user *Remote) *Remote {
+	merged := *user
+	merged.Name = project.Name
+	merged.Profile = project.Profile
+
+	if project.PlatformURL != "" {
+		merged.PlatformURL = project.PlatformURL
+	}
+	if project.MessagingURL != "" {
+		merged.MessagingURL = project.MessagingURL
+	}
+	if project.SystemKey != "" {
+		merged.SystemKey = project.SystemKey
+		merged.SystemSecret = project.SystemSecret
+	}
+	if project.Token != "" {
+		merged.Token = project.Token
+	}
+
+	return &merged
+}
+
+// ResolveWith returns the effective remote for the given remote, using the
+// given user-level remotes when it references a profile. Remotes without a
+// profile are returned as is.
+func ResolveWith(r *Remote, user *Remotes) (*Remote, error) {
+	if r.Profile == "" {
+		return r, nil
+	}
+
+	profile, ok := user.FindByName(r.Profile)
+	if !ok {
+		return nil, fmt.Errorf("remote %s references unknown user-level remote %s", r.Name, r.Profile)
+	}
+
+	return Merge(r, profile), nil
+}
+
+// Resolve is like ResolveWith, but loads the user-level remotes from the user
+// configuration directory when needed.
+func Resolve(r *Remote) (*Remote, error) {
+	if r.Profile == "" {
+		return r, nil
+	}
+
+	user, err := LoadUserRemotes()
+	if err != nil {
+		return nil, err
+	}
+
+	return ResolveWith(r, user)
+}
@@ -0,0 +1,88 @@
+package remote
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserConfigDirUsesXDG(t *testing.T) {
+	tempdir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempdir)
+
+	dir, err := UserConfigDir()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(tempdir, "cb-cli"), dir)
+}
+
+func TestSaveAndLoadUserRemotes(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	empty, err := LoadUserRemotes()
+	require.NoError(t, err)
+	assert.Equal(t, 0, empty.Len())
+
+	remotes := NewRemotes()
+	require.NoError(t, remotes.Put(makeStubRemote("foo")))
+	require.NoError(t, SaveUserRemotes(remotes))
+
+	loaded, err := LoadUserRemotes()
+	require.NoError(t, err)
+	assert.Equal(t, remotes.List(), loaded.List())
+}
+
+func TestMerge(t *testing.T) {
+	user := makeStubRemote("prod")
+	project := &Remote{
+		Name:         "prod-eu",
+		Profile:      "prod",
+		SystemKey:    "eu-system-key",
+		SystemSecret: "eu-system-secret",
+	}
+
+	merged := Merge(project, user)
+	assert.Equal(t, &Remote{
+		Name:         "prod-eu",
+		PlatformURL:  "https://prod.remote",
+		MessagingURL: "prod.remote:1883",
+		SystemKey:    "eu-system-key",
+		SystemSecret: "eu-system-secret",
+		Token:        "prod-token",
+		Profile:      "prod",
+	}, merged)
+
+	// inputs are left untouched
+	assert.Equal(t, "prod-system-key", user.SystemKey)
+}
+
+func TestResolveWith(t *testing.T) {
+	user := NewRemotes()
+	require.NoError(t, user.Put(makeStubRemote("prod")))
+
+	plain := makeStubRemote("foo")
+	resolved, err := ResolveWith(plain, user)
+	require.NoError(t, err)
+	assert.Same(t, plain, resolved)
+
+	resolved, err = ResolveWith(&Remote{Name: "bar", Profile: "prod"}, user)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", resolved.Name)
+	assert.Equal(t, "prod-system-key", resolved.SystemKey)
+
+	_, err = ResolveWith(&Remote{Name: "bar", Profile: "missing"}, user)
+	assert.Error(t, err)
+}
+
+func TestResolveLoadsUserRemotes(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	user := NewRemotes()
+	require.NoError(t, user.Put(makeStubRemote("prod")))
+	require.NoError(t, SaveUserRemotes(user))
+
+	resolved, err := Resolve(&Remote{Name: "bar", Profile: "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, "prod-token", resolved.Token)
+}
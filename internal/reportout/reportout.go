@@ -0,0 +1,47 @@
+// Package reportout holds what the outputs of the reports of the commands
+// share: the validation of the format asked for, and the JSON output. The
+// packages of the reports only write the formats of their own.
+package reportout
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is an output format of a report.
+type Format struct {
+	Name  string
+	Write func(w io.Writer) error
+}
+
+// CheckFormat returns an error if the format isn't one of the known ones.
+func CheckFormat(format string, known []string) error {
+	for _, name := range known {
+		if format == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format '%s'; must be one of %s", format, strings.Join(known, ", "))
+}
+
+// Write writes the report in the given format, the first of the formats if
+// none is given.
+func Write(w io.Writer, format string, formats []Format) error {
+	names := make([]string, 0, len(formats))
+	for _, f := range formats {
+		if f.Name == format || (format == "" && len(names) == 0) {
+			return f.Write(w)
+		}
+		names = append(names, f.Name)
+	}
+	return CheckFormat(format, names)
+}
+
+// WriteJSON writes the value as indented JSON.
+func WriteJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,32 @@
+package reportout
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	formats := []Format{
+		{"text", func(w io.Writer) error { _, err := io.WriteString(w, "text"); return err }},
+		{"json", func(w io.Writer) error { return WriteJSON(w, map[string]int{"a": 1}) }},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, "", formats))
+	assert.Equal(t, "text", buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, "json", formats))
+	assert.Equal(t, "{\n    \"a\": 1\n}\n", buf.String())
+
+	assert.EqualError(t, Write(&buf, "xml", formats), "unknown output format 'xml'; must be one of text, json")
+}
+
+func TestCheckFormat(t *testing.T) {
+	assert.NoError(t, CheckFormat("csv", []string{"text", "csv"}))
+	assert.EqualError(t, CheckFormat("", []string{"text", "csv"}), "unknown output format ''; must be one of text, csv")
+}
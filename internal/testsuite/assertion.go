@@ -0,0 +1,269 @@
+package testsuite
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Assertion checks the value at a path of the results. Exactly one check
+// must be given.
+type Assertion struct {
+	// Path selects a value of the results: $ is the results, .name a field
+	// and [n] an element of a list, e.g. $.items[0].id. The leading $ is
+	// optional.
+	Path string `json:"path"`
+
+	Equals      json.RawMessage `json:"equals,omitempty"`
+	NotEquals   json.RawMessage `json:"notEquals,omitempty"`
+	Contains    json.RawMessage `json:"contains,omitempty"`
+	Exists      *bool           `json:"exists,omitempty"`
+	Matches     *string         `json:"matches,omitempty"`
+	GreaterThan *float64        `json:"greaterThan,omitempty"`
+	LessThan    *float64        `json:"lessThan,omitempty"`
+	Length      *int            `json:"length,omitempty"`
+}
+
+func (a *Assertion) checks() int {
+	count := 0
+	for _, set := range []bool{
+		a.Equals != nil, a.NotEquals != nil, a.Contains != nil, a.Exists != nil,
+		a.Matches != nil, a.GreaterThan != nil, a.LessThan != nil, a.Length != nil,
+	} {
+		if set {
+			count++
+		}
+	}
+	return count
+}
+
+func (a *Assertion) validate() error {
+	if a.Path == "" {
+		return fmt.Errorf("missing path")
+	}
+	if _, err := parsePath(a.Path); err != nil {
+		return err
+	}
+	if a.checks() != 1 {
+		return fmt.Errorf("'%s' needs exactly one of equals, notEquals, contains, exists, matches, greaterThan, lessThan or length", a.Path)
+	}
+	if a.Matches != nil {
+		if _, err := regexp.Compile(*a.Matches); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check returns a description of the failure, or an empty string if the
+// assertion holds.
+func (a *Assertion) check(results interface{}) string {
+	value, found := lookup(results, a.Path)
+
+	if a.Exists != nil {
+		if found != *a.Exists {
+			if found {
+				return fmt.Sprintf("%s: expected no value, got %s", a.Path, compact(value))
+			}
+			return fmt.Sprintf("%s: expected a value, found none", a.Path)
+		}
+		return ""
+	}
+
+	if !found {
+		return fmt.Sprintf("%s: no value", a.Path)
+	}
+
+	switch {
+	case a.Equals != nil:
+		expected := decode(a.Equals)
+		if !equal(expected, value) {
+			return fmt.Sprintf("%s: expected %s, got %s", a.Path, compact(expected), compact(value))
+		}
+
+	case a.NotEquals != nil:
+		unexpected := decode(a.NotEquals)
+		if equal(unexpected, value) {
+			return fmt.Sprintf("%s: expected anything but %s", a.Path, compact(unexpected))
+		}
+
+	case a.Contains != nil:
+		expected := decode(a.Contains)
+		if !contains(value, expected) {
+			return fmt.Sprintf("%s: expected %s to contain %s", a.Path, compact(value), compact(expected))
+		}
+
+	case a.Matches != nil:
+		s, ok := value.(string)
+		if !ok || !regexp.MustCompile(*a.Matches).MatchString(s) {
+			return fmt.Sprintf("%s: expected %s to match %s", a.Path, compact(value), *a.Matches)
+		}
+
+	case a.GreaterThan != nil:
+		n, ok := value.(float64)
+		if !ok || n <= *a.GreaterThan {
+			return fmt.Sprintf("%s: expected a number greater than %v, got %s", a.Path, *a.GreaterThan, compact(value))
+		}
+
+	case a.LessThan != nil:
+		n, ok := value.(float64)
+		if !ok || n >= *a.LessThan {
+			return fmt.Sprintf("%s: expected a number less than %v, got %s", a.Path, *a.LessThan, compact(value))
+		}
+
+	case a.Length != nil:
+		length := -1
+		switch v := value.(type) {
+		case []interface{}:
+			length = len(v)
+		case map[string]interface{}:
+			length = len(v)
+		case string:
+			length = len(v)
+		}
+		if length != *a.Length {
+			return fmt.Sprintf("%s: expected length %d, got %s", a.Path, *a.Length, compact(value))
+		}
+	}
+	return ""
+}
+
+// pathStep is a field name or, if isIndex, a list index.
+type pathStep struct {
+	field   string
+	index   int
+	isIndex bool
+}
+
+func parsePath(path string) ([]pathStep, error) {
+	rest := strings.TrimPrefix(path, "$")
+	steps := []pathStep{}
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid path '%s': empty field name", path)
+			}
+			steps = append(steps, pathStep{field: rest[:end]})
+			rest = rest[end:]
+
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path '%s': missing ]", path)
+			}
+			inside := rest[1:end]
+			rest = rest[end+1:]
+			if unquoted, err := strconv.Unquote(inside); err == nil {
+				steps = append(steps, pathStep{field: unquoted})
+				continue
+			}
+			index, err := strconv.Atoi(inside)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid path '%s': '%s' is not an index", path, inside)
+			}
+			steps = append(steps, pathStep{index: index, isIndex: true})
+
+		default:
+			if len(steps) > 0 || strings.HasPrefix(path, "$") {
+				return nil, fmt.Errorf("invalid path '%s'", path)
+			}
+			// a path without the leading $, e.g. items[0]
+			rest = "." + rest
+		}
+	}
+	return steps, nil
+}
+
+// lookup returns the value at the path, and whether there is one.
+func lookup(value interface{}, path string) (interface{}, bool) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, step := range steps {
+		if step.isIndex {
+			list, ok := value.([]interface{})
+			if !ok || step.index >= len(list) {
+				return nil, false
+			}
+			value = list[step.index]
+			continue
+		}
+
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = object[step.field]
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func decode(raw json.RawMessage) interface{} {
+	var v interface{}
+	json.Unmarshal(raw, &v)
+	return v
+}
+
+// equal compares two JSON values; numbers compare as float64 whatever their
+// Go type.
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func normalize(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var rtn interface{}
+	if err := json.Unmarshal(data, &rtn); err != nil {
+		return v
+	}
+	return rtn
+}
+
+// contains returns true if the string holds the expected substring, the list
+// holds the expected element or the object holds every field of the expected
+// object.
+func contains(value, expected interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		s, ok := expected.(string)
+		return ok && strings.Contains(v, s)
+	case []interface{}:
+		for _, element := range v {
+			if equal(element, expected) {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		fields, ok := expected.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, want := range fields {
+			got, found := v[key]
+			if !found || !equal(got, want) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
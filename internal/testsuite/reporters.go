@@ -0,0 +1,118 @@
+package testsuite
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/clearblade/cblib/internal/reportout"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func seconds(ms int64) string {
+	return fmt.Sprintf("%.3f", float64(ms)/1000)
+}
+
+// WriteJUnit writes the report as JUnit XML, one test suite per service.
+func WriteJUnit(w io.Writer, report *Report) error {
+	root := junitTestSuites{}
+	var totalMs int64
+	for _, service := range report.Services() {
+		suite := junitTestSuite{Name: service}
+		var suiteMs int64
+		for _, result := range report.Results {
+			if result.Service != service {
+				continue
+			}
+			testCase := junitTestCase{Name: result.Name, Classname: service, Time: seconds(result.DurationMs)}
+			if result.Error != "" {
+				testCase.Error = &junitMessage{Message: result.Error, Text: result.Error}
+				suite.Errors++
+			} else if len(result.Failures) > 0 {
+				testCase.Failure = &junitMessage{Message: result.Failures[0], Text: strings.Join(result.Failures, "\n")}
+				suite.Failures++
+			}
+			suite.Tests++
+			suiteMs += result.DurationMs
+			suite.Cases = append(suite.Cases, testCase)
+		}
+		suite.Time = seconds(suiteMs)
+
+		root.Tests += suite.Tests
+		root.Failures += suite.Failures
+		root.Errors += suite.Errors
+		totalMs += suiteMs
+		root.Suites = append(root.Suites, suite)
+	}
+	root.Time = seconds(totalMs)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteJSON writes the report as JSON, with the counts of cases.
+func WriteJSON(w io.Writer, report *Report) error {
+	return reportout.WriteJSON(w, struct {
+		Total  int `json:"total"`
+		Failed int `json:"failed"`
+		*Report
+	}{len(report.Results), report.Failed(), report})
+}
+
+// WriteText writes a line per case and a summary.
+func WriteText(w io.Writer, report *Report) error {
+	for _, result := range report.Results {
+		status := "PASS"
+		if !result.Passed() {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s  %s/%s (%dms)\n", status, result.Service, result.Name, result.DurationMs)
+		if result.Error != "" {
+			fmt.Fprintf(w, "      error: %s\n", result.Error)
+		}
+		for _, failure := range result.Failures {
+			fmt.Fprintf(w, "      %s\n", failure)
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d passed, %d failed\n", len(report.Results)-report.Failed(), report.Failed())
+	return err
+}
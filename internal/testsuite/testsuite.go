@@ -0,0 +1,220 @@
+// Package testsuite runs declarative test cases against code services. A case
+// is a JSON file, tests/<service>/<case>.json, holding the params of a call
+// and what the response is expected to be:
+//
+//	{
+//	    "description": "closes an open order",
+//	    "params": {"orderId": "abc"},
+//	    "expect": {
+//	        "success": true,
+//	        "assertions": [
+//	            {"path": "$.order.status", "equals": "closed"},
+//	            {"path": "$.items", "length": 2}
+//	        ]
+//	    }
+//	}
+package testsuite
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clearblade/cblib/internal/execout"
+)
+
+// DefaultDir is the directory of the test cases, relative to the system root.
+const DefaultDir = "tests"
+
+// Case is a call of a service and the expected response.
+type Case struct {
+	// Name is the name of the case file, without extension.
+	Name        string                 `json:"-"`
+	Service     string                 `json:"-"`
+	Description string                 `json:"description,omitempty"`
+	Params      map[string]interface{} `json:"params"`
+	Expect      Expectation            `json:"expect"`
+}
+
+// Expectation describes the expected response. Success defaults to true.
+type Expectation struct {
+	Success    *bool       `json:"success,omitempty"`
+	Results    interface{} `json:"results,omitempty"`
+	Assertions []Assertion `json:"assertions,omitempty"`
+}
+
+// Caller calls a service and returns the platform's response.
+type Caller func(service string, params map[string]interface{}) (map[string]interface{}, error)
+
+// CaseResult is the outcome of a case. Failures are unmet expectations; Error
+// is set if the service couldn't be called at all.
+type CaseResult struct {
+	Name       string   `json:"name"`
+	Service    string   `json:"service"`
+	DurationMs int64    `json:"duration_ms"`
+	Failures   []string `json:"failures,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// Passed returns true if the case met every expectation.
+func (r *CaseResult) Passed() bool {
+	return len(r.Failures) == 0 && r.Error == ""
+}
+
+// Report holds the results of every case, in the order they ran.
+type Report struct {
+	Results []*CaseResult `json:"results"`
+}
+
+// Failed returns the number of cases that didn't pass.
+func (r *Report) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if !result.Passed() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Services returns the names of the services of the report, sorted.
+func (r *Report) Services() []string {
+	services := []string{}
+	for _, result := range r.Results {
+		if !slices.Contains(services, result.Service) {
+			services = append(services, result.Service)
+		}
+	}
+	sort.Strings(services)
+	return services
+}
+
+// Load reads the cases in dir, one directory per service. Only the cases of
+// the given services are read when any are given.
+func Load(dir string, services []string) ([]*Case, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]bool{}
+	cases := []*Case{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		service := entry.Name()
+		found[service] = true
+		if len(services) > 0 && !slices.Contains(services, service) {
+			continue
+		}
+
+		files, err := filepath.Glob(filepath.Join(dir, service, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			c, err := loadCase(file)
+			if err != nil {
+				return nil, err
+			}
+			c.Service = service
+			cases = append(cases, c)
+		}
+	}
+
+	for _, service := range services {
+		if !found[service] {
+			return nil, fmt.Errorf("no test cases for service '%s' in %s", service, dir)
+		}
+	}
+	return cases, nil
+}
+
+func loadCase(file string) (*Case, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Case{}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return nil, fmt.Errorf("invalid test case %s: %s", file, err)
+	}
+	for i, assertion := range c.Expect.Assertions {
+		if err := assertion.validate(); err != nil {
+			return nil, fmt.Errorf("invalid test case %s: assertion %d: %s", file, i+1, err)
+		}
+	}
+
+	c.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	return c, nil
+}
+
+// Run runs the cases in order.
+func Run(cases []*Case, call Caller) *Report {
+	report := &Report{Results: []*CaseResult{}}
+	for _, c := range cases {
+		report.Results = append(report.Results, RunCase(c, call))
+	}
+	return report
+}
+
+// RunCase calls the service of the case and checks the response.
+func RunCase(c *Case, call Caller) *CaseResult {
+	result := &CaseResult{Name: c.Name, Service: c.Service}
+
+	params := c.Params
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+
+	start := time.Now()
+	resp, err := call(c.Service, params)
+	duration := time.Since(start)
+	result.DurationMs = duration.Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	response := execout.FromResponse(c.Service, resp, duration)
+	result.Failures = c.Expect.check(response)
+	return result
+}
+
+func (e *Expectation) check(response *execout.Result) []string {
+	failures := []string{}
+
+	wantSuccess := e.Success == nil || *e.Success
+	if response.Success != wantSuccess {
+		failures = append(failures, fmt.Sprintf("expected success to be %t, got %t; results: %s", wantSuccess, response.Success, compact(response.Results)))
+	}
+
+	if e.Results != nil && !equal(e.Results, response.Results) {
+		failures = append(failures, fmt.Sprintf("expected results %s, got %s", compact(e.Results), compact(response.Results)))
+	}
+
+	for _, assertion := range e.Assertions {
+		if failure := assertion.check(response.Results); failure != "" {
+			failures = append(failures, failure)
+		}
+	}
+	return failures
+}
+
+func compact(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,168 @@
+package testsuite
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCase(t *testing.T, dir, service, name, content string) {
+	path := filepath.Join(dir, service, name+".json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0777))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0666))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeCase(t, dir, "svcA", "b", `{"params": {"x": 1}}`)
+	writeCase(t, dir, "svcA", "a", `{"expect": {"success": false}}`)
+	writeCase(t, dir, "svcB", "c", `{}`)
+
+	cases, err := Load(dir, nil)
+	require.NoError(t, err)
+	require.Len(t, cases, 3)
+	assert.Equal(t, "svcA", cases[0].Service)
+	assert.Equal(t, "a", cases[0].Name)
+	assert.Equal(t, "b", cases[1].Name)
+	assert.Equal(t, "svcB", cases[2].Service)
+
+	cases, err = Load(dir, []string{"svcB"})
+	require.NoError(t, err)
+	require.Len(t, cases, 1)
+
+	_, err = Load(dir, []string{"missing"})
+	assert.Error(t, err)
+
+	writeCase(t, dir, "svcC", "bad", `{"expect": {"assertions": [{"path": "$.a", "equals": 1, "exists": true}]}}`)
+	_, err = Load(dir, []string{"svcC"})
+	assert.Error(t, err)
+
+	writeCase(t, dir, "svcD", "typo", `{"parms": {}}`)
+	_, err = Load(dir, []string{"svcD"})
+	assert.Error(t, err)
+}
+
+func parseCase(t *testing.T, s string) *Case {
+	c := &Case{Name: "case", Service: "svc"}
+	require.NoError(t, json.Unmarshal([]byte(s), c))
+	for _, assertion := range c.Expect.Assertions {
+		require.NoError(t, assertion.validate())
+	}
+	return c
+}
+
+func respond(resp map[string]interface{}) Caller {
+	return func(service string, params map[string]interface{}) (map[string]interface{}, error) {
+		return resp, nil
+	}
+}
+
+func TestRunCase(t *testing.T) {
+	results := `{"order": {"id": "abc", "status": "closed", "total": 12.5}, "items": [{"sku": "x"}, {"sku": "y"}], "note": null}`
+	call := respond(map[string]interface{}{"success": true, "results": results})
+
+	passing := parseCase(t, `{"expect": {"assertions": [
+		{"path": "$.order.status", "equals": "closed"},
+		{"path": "order.id", "notEquals": "xyz"},
+		{"path": "$.items", "length": 2},
+		{"path": "$.items[1].sku", "matches": "^y$"},
+		{"path": "$.items", "contains": {"sku": "x"}},
+		{"path": "$.order", "contains": {"id": "abc"}},
+		{"path": "$.order.total", "greaterThan": 10},
+		{"path": "$.order.total", "lessThan": 20},
+		{"path": "$.note", "equals": null},
+		{"path": "$[\"order\"].id", "exists": true},
+		{"path": "$.missing", "exists": false}
+	]}}`)
+	result := RunCase(passing, call)
+	assert.True(t, result.Passed(), "%v", result.Failures)
+
+	failing := parseCase(t, `{"expect": {"results": {"a": 1}, "assertions": [
+		{"path": "$.order.status", "equals": "open"},
+		{"path": "$.items[5]", "exists": true},
+		{"path": "$.order.total", "greaterThan": 20},
+		{"path": "$.missing", "equals": 1}
+	]}}`)
+	result = RunCase(failing, call)
+	assert.False(t, result.Passed())
+	assert.Equal(t, []string{
+		`expected results {"a":1}, got {"items":[{"sku":"x"},{"sku":"y"}],"note":null,"order":{"id":"abc","status":"closed","total":12.5}}`,
+		`$.order.status: expected "open", got "closed"`,
+		`$.items[5]: expected a value, found none`,
+		`$.order.total: expected a number greater than 20, got 12.5`,
+		`$.missing: no value`,
+	}, result.Failures)
+
+	result = RunCase(parseCase(t, `{}`), respond(map[string]interface{}{"success": false, "results": "boom"}))
+	assert.Equal(t, []string{`expected success to be true, got false; results: "boom"`}, result.Failures)
+
+	result = RunCase(parseCase(t, `{"expect": {"success": false, "results": "boom"}}`), respond(map[string]interface{}{"success": false, "results": "boom"}))
+	assert.True(t, result.Passed())
+
+	result = RunCase(parseCase(t, `{}`), func(string, map[string]interface{}) (map[string]interface{}, error) {
+		return nil, errors.New("unauthorized")
+	})
+	assert.Equal(t, "unauthorized", result.Error)
+	assert.False(t, result.Passed())
+}
+
+func TestParsePath(t *testing.T) {
+	for _, bad := range []string{"$.", "$.a[", "$.a[x]", "$a", "$.a..b"} {
+		_, err := parsePath(bad)
+		assert.Error(t, err, bad)
+	}
+
+	value, found := lookup([]interface{}{"a", "b"}, "$[1]")
+	assert.True(t, found)
+	assert.Equal(t, "b", value)
+
+	value, found = lookup("x", "$")
+	assert.True(t, found)
+	assert.Equal(t, "x", value)
+}
+
+func TestReporters(t *testing.T) {
+	report := &Report{Results: []*CaseResult{
+		{Name: "ok", Service: "svcB", DurationMs: 1500},
+		{Name: "bad", Service: "svcA", DurationMs: 20, Failures: []string{"$.a: expected 1, got 2", "second"}},
+		{Name: "err", Service: "svcA", Error: "unauthorized"},
+	}}
+	assert.Equal(t, 2, report.Failed())
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, report))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="1" errors="1" time="1.520">
+  <testsuite name="svcA" tests="2" failures="1" errors="1" time="0.020">
+    <testcase name="bad" classname="svcA" time="0.020">
+      <failure message="$.a: expected 1, got 2">$.a: expected 1, got 2&#xA;second</failure>
+    </testcase>
+    <testcase name="err" classname="svcA" time="0.000">
+      <error message="unauthorized">unauthorized</error>
+    </testcase>
+  </testsuite>
+  <testsuite name="svcB" tests="1" failures="0" errors="0" time="1.500">
+    <testcase name="ok" classname="svcB" time="1.500"></testcase>
+  </testsuite>
+</testsuites>
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteJSON(&buf, report))
+	decoded := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, float64(3), decoded["total"])
+	assert.Equal(t, float64(2), decoded["failed"])
+	assert.Len(t, decoded["results"], 3)
+
+	buf.Reset()
+	require.NoError(t, WriteText(&buf, report))
+	assert.Contains(t, buf.String(), "FAIL  svcA/bad (20ms)\n      $.a: expected 1, got 2\n")
+	assert.Contains(t, buf.String(), "1 passed, 2 failed\n")
+}
@@ -0,0 +1,179 @@
+// Package triggersim simulates the event of a trigger: it checks a synthetic
+// event against the trigger's definition and key value pairs, and shapes it
+// into the params the platform gives the trigger's service.
+package triggersim
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/clearblade/cblib/internal/mqtttool"
+)
+
+// Definition is a platform event a trigger can fire on.
+type Definition struct {
+	Module string
+	Name   string
+	// Keys are the fields the trigger's key value pairs can filter on.
+	Keys []string
+	// Required are the fields every event of the definition holds.
+	Required []string
+}
+
+// ID returns the definition as the platform names it in the params of the
+// service, e.g. Messaging::Publish.
+func (d *Definition) ID() string {
+	return d.Module + "::" + d.Name
+}
+
+var (
+	topicKeys      = []string{"topic"}
+	collectionKeys = []string{"collectionId", "collectionName"}
+	userKeys       = []string{"userId", "email"}
+	deviceKeys     = []string{"deviceName"}
+	edgeKeys       = []string{"edgeName"}
+)
+
+// Definitions lists the event definitions of the platform.
+var Definitions = []*Definition{
+	{Module: "Messaging", Name: "Publish", Keys: topicKeys, Required: []string{"topic", "body"}},
+	{Module: "Messaging", Name: "Subscribe", Keys: topicKeys, Required: []string{"topic"}},
+	{Module: "Messaging", Name: "Unsubscribe", Keys: topicKeys, Required: []string{"topic"}},
+	{Module: "Messaging", Name: "MQTTClientConnect"},
+	{Module: "Messaging", Name: "MQTTClientDisconnect"},
+	{Module: "Messaging", Name: "MQTTUserConnected", Keys: userKeys},
+	{Module: "Messaging", Name: "MQTTUserDisconnected", Keys: userKeys},
+	{Module: "Messaging", Name: "MQTTDeviceConnected", Keys: deviceKeys, Required: []string{"deviceName"}},
+	{Module: "Messaging", Name: "MQTTDeviceDisconnected", Keys: deviceKeys, Required: []string{"deviceName"}},
+	{Module: "Data", Name: "CollectionCreated", Keys: collectionKeys, Required: []string{"collectionName"}},
+	{Module: "Data", Name: "CollectionUpdated", Keys: collectionKeys, Required: []string{"collectionName"}},
+	{Module: "Data", Name: "CollectionDeleted", Keys: collectionKeys, Required: []string{"collectionName"}},
+	{Module: "Data", Name: "ItemCreated", Keys: collectionKeys, Required: []string{"collectionName", "items"}},
+	{Module: "Data", Name: "ItemUpdated", Keys: collectionKeys, Required: []string{"collectionName", "items"}},
+	{Module: "Data", Name: "ItemDeleted", Keys: collectionKeys, Required: []string{"collectionName", "items"}},
+	{Module: "User", Name: "UserCreated", Keys: userKeys, Required: []string{"user"}},
+	{Module: "User", Name: "UserUpdated", Keys: userKeys, Required: []string{"user"}},
+	{Module: "User", Name: "UserDeleted", Keys: userKeys, Required: []string{"user"}},
+	{Module: "Device", Name: "DeviceCreated", Keys: deviceKeys, Required: []string{"deviceName", "device"}},
+	{Module: "Device", Name: "DeviceUpdated", Keys: deviceKeys, Required: []string{"deviceName", "device"}},
+	{Module: "Device", Name: "DeviceDeleted", Keys: deviceKeys, Required: []string{"deviceName", "device"}},
+	{Module: "Edge", Name: "EdgeConnected", Keys: edgeKeys, Required: []string{"edgeName"}},
+	{Module: "Edge", Name: "EdgeDisconnected", Keys: edgeKeys, Required: []string{"edgeName"}},
+	{Module: "Platform", Name: "PlatformStarted"},
+	{Module: "Platform", Name: "PlatformConnectedOnEdge", Keys: edgeKeys, Required: []string{"edgeName"}},
+	{Module: "Platform", Name: "PlatformDisconnectedOnEdge", Keys: edgeKeys, Required: []string{"edgeName"}},
+}
+
+// Lookup returns the definition with the given module and name.
+func Lookup(module, name string) (*Definition, bool) {
+	for _, def := range Definitions {
+		if def.Module == module && def.Name == name {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+// Trigger is the part of a local trigger an event is checked against.
+type Trigger struct {
+	Name          string
+	Service       string
+	Definition    *Definition
+	KeyValuePairs map[string]interface{}
+}
+
+// FromMap reads a trigger as stored under triggers/. The definition is read
+// from event_definition, or from def_module and def_name as pushed to the
+// platform.
+func FromMap(raw map[string]interface{}) (*Trigger, error) {
+	name, _ := raw["name"].(string)
+	if name == "" {
+		return nil, fmt.Errorf("trigger has no name")
+	}
+	service, _ := raw["service_name"].(string)
+	if service == "" {
+		return nil, fmt.Errorf("trigger '%s' has no service_name", name)
+	}
+
+	def := raw
+	if eventDef, ok := raw["event_definition"].(map[string]interface{}); ok {
+		def = eventDef
+	}
+	module, _ := def["def_module"].(string)
+	defName, _ := def["def_name"].(string)
+	definition, ok := Lookup(module, defName)
+	if !ok {
+		return nil, fmt.Errorf("trigger '%s' has an unknown event definition '%s::%s'", name, module, defName)
+	}
+
+	kv, _ := raw["key_value_pairs"].(map[string]interface{})
+	if kv == nil {
+		kv = map[string]interface{}{}
+	}
+	return &Trigger{Name: name, Service: service, Definition: definition, KeyValuePairs: kv}, nil
+}
+
+// Params checks the event and returns the params the platform would give the
+// trigger's service for it. Key fields the event lacks are taken from the key
+// value pairs, and the trigger field names the definition.
+func (t *Trigger) Params(event map[string]interface{}) (map[string]interface{}, error) {
+	params := map[string]interface{}{}
+	for key, value := range event {
+		params[key] = value
+	}
+
+	if id, ok := params["trigger"]; ok && id != t.Definition.ID() {
+		return nil, fmt.Errorf("event is a %v event but trigger '%s' fires on %s", id, t.Name, t.Definition.ID())
+	}
+	params["trigger"] = t.Definition.ID()
+
+	for _, key := range sortedKeys(t.KeyValuePairs) {
+		want := t.KeyValuePairs[key]
+		if !slices.Contains(t.Definition.Keys, key) {
+			// e.g. settings of the trigger which aren't part of the event
+			continue
+		}
+		got, ok := params[key]
+		if !ok {
+			if key == "topic" && strings.ContainsAny(fmt.Sprint(want), "+#") {
+				return nil, fmt.Errorf("event has no topic; trigger '%s' fires on topics matching '%v'", t.Name, want)
+			}
+			params[key] = want
+			continue
+		}
+		if !keyMatches(key, want, got) {
+			return nil, fmt.Errorf("trigger '%s' wouldn't fire: event %s is '%v' but the trigger requires '%v'", t.Name, key, got, want)
+		}
+	}
+
+	missing := []string{}
+	for _, field := range t.Definition.Required {
+		if _, ok := params[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s events need %s; the event lacks %s", t.Definition.ID(), strings.Join(t.Definition.Required, ", "), strings.Join(missing, ", "))
+	}
+	return params, nil
+}
+
+// keyMatches returns true if the value of an event field satisfies the key
+// value pair of the trigger. Topics match as MQTT subscriptions do.
+func keyMatches(key string, want, got interface{}) bool {
+	if key == "topic" {
+		return mqtttool.Match(fmt.Sprint(want), fmt.Sprint(got))
+	}
+	return fmt.Sprint(want) == fmt.Sprint(got)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,66 @@
+package triggersim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromMap(t *testing.T) {
+	trigger, err := FromMap(map[string]interface{}{
+		"name":             "onState",
+		"service_name":     "handleState",
+		"event_definition": map[string]interface{}{"def_module": "Messaging", "def_name": "Publish"},
+		"key_value_pairs":  map[string]interface{}{"topic": "devices/+/state"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "handleState", trigger.Service)
+	assert.Equal(t, "Messaging::Publish", trigger.Definition.ID())
+
+	trigger, err = FromMap(map[string]interface{}{"name": "pushed", "service_name": "s", "def_module": "Data", "def_name": "ItemCreated"})
+	require.NoError(t, err)
+	assert.Equal(t, "Data::ItemCreated", trigger.Definition.ID())
+	assert.Empty(t, trigger.KeyValuePairs)
+
+	_, err = FromMap(map[string]interface{}{"name": "bad", "service_name": "s", "event_definition": map[string]interface{}{"def_module": "Data", "def_name": "Nope"}})
+	assert.EqualError(t, err, "trigger 'bad' has an unknown event definition 'Data::Nope'")
+
+	_, err = FromMap(map[string]interface{}{"name": "noService"})
+	assert.EqualError(t, err, "trigger 'noService' has no service_name")
+}
+
+func TestParams(t *testing.T) {
+	publish := &Trigger{Name: "onState", Service: "s", Definition: mustLookup(t, "Messaging", "Publish"), KeyValuePairs: map[string]interface{}{"topic": "devices/+/state"}}
+
+	params, err := publish.Params(map[string]interface{}{"topic": "devices/a/state", "body": "on"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"topic": "devices/a/state", "body": "on", "trigger": "Messaging::Publish"}, params)
+
+	_, err = publish.Params(map[string]interface{}{"topic": "devices/a/other", "body": "on"})
+	assert.EqualError(t, err, "trigger 'onState' wouldn't fire: event topic is 'devices/a/other' but the trigger requires 'devices/+/state'")
+
+	_, err = publish.Params(map[string]interface{}{"body": "on"})
+	assert.EqualError(t, err, "event has no topic; trigger 'onState' fires on topics matching 'devices/+/state'")
+
+	_, err = publish.Params(map[string]interface{}{"topic": "devices/a/state"})
+	assert.EqualError(t, err, "Messaging::Publish events need topic, body; the event lacks body")
+
+	_, err = publish.Params(map[string]interface{}{"topic": "devices/a/state", "body": "on", "trigger": "Data::ItemCreated"})
+	assert.EqualError(t, err, "event is a Data::ItemCreated event but trigger 'onState' fires on Messaging::Publish")
+
+	items := &Trigger{Name: "onOrder", Service: "s", Definition: mustLookup(t, "Data", "ItemCreated"), KeyValuePairs: map[string]interface{}{"collectionName": "orders", "other": "setting"}}
+	params, err = items.Params(map[string]interface{}{"items": []interface{}{map[string]interface{}{"id": "a"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "orders", params["collectionName"])
+	assert.NotContains(t, params, "other")
+
+	_, err = items.Params(map[string]interface{}{"collectionName": "users", "items": []interface{}{}})
+	assert.Error(t, err)
+}
+
+func mustLookup(t *testing.T, module, name string) *Definition {
+	def, ok := Lookup(module, name)
+	require.True(t, ok)
+	return def
+}
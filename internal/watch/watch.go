@@ -0,0 +1,273 @@
+// Package watch watches the files of a system, maps the changed files to the
+// assets they belong to and hands the assets over in batches once the files
+// settle.
+package watch
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clearblade/cblib/syspath"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Kind is a kind of asset watched.
+type Kind string
+
+const (
+	KindService Kind = "service"
+	KindLibrary Kind = "library"
+	KindPortal  Kind = "portal"
+	KindPlugin  Kind = "plugin"
+	KindAdaptor Kind = "adaptor"
+	// KindSource is the sources of services built into code, under src/.
+	KindSource Kind = "source"
+)
+
+// srcDir is the directory of the sources built into code.
+const srcDir = "src"
+
+// Dirs are the directories watched, relative to the system root.
+var Dirs = []string{"code", "portals", "plugins", "adapters", srcDir}
+
+// Asset is an asset of the system.
+type Asset struct {
+	Kind Kind
+	Name string
+}
+
+func (a Asset) String() string {
+	return fmt.Sprintf("%s '%s'", a.Kind, a.Name)
+}
+
+// IsCode returns true for services and libraries.
+func (a Asset) IsCode() bool {
+	return a.Kind == KindService || a.Kind == KindLibrary
+}
+
+// AssetForPath returns the asset a file belongs to, given its path relative
+// to the system root. Editor backup and swap files belong to no asset.
+func AssetForPath(relPath string) (Asset, bool) {
+	relPath = filepath.ToSlash(relPath)
+	if isScratchFile(relPath) {
+		return Asset{}, false
+	}
+
+	switch {
+	case strings.HasPrefix(relPath, srcDir+"/"):
+		// the sources of a service, or those shared by every service
+		if parts := strings.Split(relPath, "/"); len(parts) > 3 && parts[1] == "services" {
+			return Asset{KindSource, parts[2]}, true
+		}
+		return Asset{KindSource, srcDir}, true
+
+	case syspath.IsCodePath(relPath):
+		if name, err := syspath.GetServiceNameFromPath(relPath); err == nil {
+			return Asset{KindService, name}, true
+		}
+		if name, err := syspath.GetLibraryNameFromPath(relPath); err == nil {
+			return Asset{KindLibrary, name}, true
+		}
+
+	case syspath.IsPortalPath(relPath):
+		if name, err := syspath.GetPortalNameFromPath(relPath); err == nil {
+			return Asset{KindPortal, name}, true
+		}
+		for _, parse := range []func(string) (string, string, error){
+			syspath.GetDatasourceNameFromPath,
+			syspath.GetInternalResourceNameFromPath,
+			syspath.GetWidgetNameFromPath,
+			syspath.GetWidgetParserFromPath,
+		} {
+			if name, _, err := parse(relPath); err == nil {
+				return Asset{KindPortal, name}, true
+			}
+		}
+		// e.g. the parser.js of a datasource, or an image of the portal
+		if parts := strings.Split(relPath, "/"); len(parts) > 3 && (parts[2] == "config" || parts[2] == "assets") {
+			return Asset{KindPortal, parts[1]}, true
+		}
+
+	case syspath.IsPluginPath(relPath):
+		if name, err := syspath.GetPluginNameFromPath(relPath); err == nil {
+			return Asset{KindPlugin, name}, true
+		}
+		if name, err := syspath.GetPluginMetaNameFromPath(relPath); err == nil {
+			return Asset{KindPlugin, name}, true
+		}
+		if name, _, err := syspath.GetPluginCodeFromPath(relPath); err == nil {
+			return Asset{KindPlugin, name}, true
+		}
+
+	case syspath.IsAdaptorPath(relPath):
+		if name, err := syspath.GetAdaptorNameFromPath(relPath); err == nil {
+			return Asset{KindAdaptor, name}, true
+		}
+		if name, _, err := syspath.GetAdaptorFileMetaNameFromPath(relPath); err == nil {
+			return Asset{KindAdaptor, name}, true
+		}
+		if name, _, err := syspath.GetAdaptorFileDataNameFromPath(relPath); err == nil {
+			return Asset{KindAdaptor, name}, true
+		}
+	}
+	return Asset{}, false
+}
+
+func isScratchFile(relPath string) bool {
+	name := syspath.GetFileName(relPath)
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "#") ||
+		strings.HasSuffix(name, "~") || strings.HasSuffix(name, ".swp") || strings.HasSuffix(name, ".tmp")
+}
+
+// Batch collects the assets of changes until no change comes for Delay.
+type Batch struct {
+	Delay  time.Duration
+	assets map[Asset]bool
+	last   time.Time
+}
+
+// Add adds the asset of a change made at the given time.
+func (b *Batch) Add(asset Asset, at time.Time) {
+	if b.assets == nil {
+		b.assets = map[Asset]bool{}
+	}
+	b.assets[asset] = true
+	b.last = at
+}
+
+// Ready returns true if the batch has assets and no change came for Delay.
+func (b *Batch) Ready(now time.Time) bool {
+	return len(b.assets) > 0 && now.Sub(b.last) >= b.Delay
+}
+
+// Take returns the assets of the batch, sorted by kind and name, and empties
+// it.
+func (b *Batch) Take() []Asset {
+	assets := []Asset{}
+	for asset := range b.assets {
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool {
+		if assets[i].Kind != assets[j].Kind {
+			return assets[i].Kind < assets[j].Kind
+		}
+		return assets[i].Name < assets[j].Name
+	})
+	b.assets = nil
+	return assets
+}
+
+// Watcher watches directories of a system and everything under them.
+type Watcher struct {
+	root    string
+	watcher *fsnotify.Watcher
+	batch   Batch
+}
+
+// New returns a watcher of the given directories of the system at root.
+// Directories which don't exist are skipped.
+func New(root string, dirs []string) (*Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{root: root, watcher: watcher}
+	for _, dir := range dirs {
+		path := filepath.Join(root, dir)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		if err := w.addTree(path, time.Time{}); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	return w.watcher.Close()
+}
+
+// addTree watches the directory and those under it. Unless at is zero, the
+// files found are changes made at that time, e.g. those of a directory
+// created, or moved in, while watching.
+func (w *Watcher) addTree(dir string, at time.Time) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return w.watcher.Add(path)
+		}
+		if !at.IsZero() {
+			w.changed(path, at)
+		}
+		return nil
+	})
+}
+
+func (w *Watcher) changed(path string, at time.Time) {
+	relPath, err := filepath.Rel(w.root, path)
+	if err != nil {
+		return
+	}
+	if asset, ok := AssetForPath(relPath); ok {
+		w.batch.Add(asset, at)
+	}
+}
+
+// Run watches until stop is closed, calling handle with the assets changed
+// once no change came for delay. Errors of the watch itself are passed to
+// onError.
+func (w *Watcher) Run(delay time.Duration, stop <-chan struct{}, handle func([]Asset), onError func(error)) {
+	w.batch.Delay = delay
+	tick := delay / 4
+	if tick < 10*time.Millisecond {
+		tick = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			now := time.Now()
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.addTree(event.Name, now); err != nil {
+						onError(err)
+					}
+					continue
+				}
+			}
+			w.changed(event.Name, now)
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			onError(err)
+
+		case now := <-ticker.C:
+			if w.batch.Ready(now) {
+				handle(w.batch.Take())
+			}
+		}
+	}
+}
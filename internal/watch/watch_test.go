@@ -0,0 +1,100 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetForPath(t *testing.T) {
+	for path, want := range map[string]Asset{
+		"code/services/svc/svc.js":                                {KindService, "svc"},
+		"code/services/svc/svc.json":                              {KindService, "svc"},
+		"code/libraries/lib/lib.js":                               {KindLibrary, "lib"},
+		"portals/p/p.json":                                        {KindPortal, "p"},
+		"portals/p/config/widgets/w/w.json":                       {KindPortal, "p"},
+		"portals/p/config/widgets/w/parsers/incoming_parser/x.js": {KindPortal, "p"},
+		"portals/p/config/datasources/ds/ds.json":                 {KindPortal, "p"},
+		"portals/p/config/internalResources/res/res.js":           {KindPortal, "p"},
+		"portals/p/config/datasources/ds/parser.js":               {KindPortal, "p"},
+		"portals/p/assets/0123456789abcdef.png":                   {KindPortal, "p"},
+		"plugins/plug.json":                                       {KindPlugin, "plug"},
+		"plugins/plug/meta.json":                                  {KindPlugin, "plug"},
+		"plugins/plug/config/template.html":                       {KindPlugin, "plug"},
+		"adapters/ad/ad.json":                                     {KindAdaptor, "ad"},
+		"adapters/ad/files/bin/bin":                               {KindAdaptor, "ad"},
+		"adapters/ad/files/bin/bin.json":                          {KindAdaptor, "ad"},
+		"src/services/svc/index.ts":                               {KindSource, "svc"},
+		"src/lib/util.ts":                                         {KindSource, "src"},
+		"src/build.json":                                          {KindSource, "src"},
+		filepath.FromSlash("code/services/other/other.js"):        {KindService, "other"},
+	} {
+		got, ok := AssetForPath(path)
+		assert.True(t, ok, path)
+		assert.Equal(t, want, got, path)
+	}
+
+	for _, path := range []string{
+		"code/services/svc/.svc.js.swp",
+		"code/services/svc/svc.js~",
+		"code/services/svc/other.js",
+		"data/items.json",
+		"portals/p/README.md",
+		"plugins/plug/README.md",
+	} {
+		_, ok := AssetForPath(path)
+		assert.False(t, ok, path)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	start := time.Now()
+	batch := Batch{Delay: time.Second}
+	assert.False(t, batch.Ready(start.Add(time.Hour)))
+
+	batch.Add(Asset{KindService, "b"}, start)
+	batch.Add(Asset{KindService, "a"}, start.Add(500*time.Millisecond))
+	batch.Add(Asset{KindLibrary, "z"}, start.Add(800*time.Millisecond))
+	batch.Add(Asset{KindService, "a"}, start.Add(900*time.Millisecond))
+	assert.False(t, batch.Ready(start.Add(1500*time.Millisecond)))
+	assert.True(t, batch.Ready(start.Add(1900*time.Millisecond)))
+
+	assert.Equal(t, []Asset{{KindLibrary, "z"}, {KindService, "a"}, {KindService, "b"}}, batch.Take())
+	assert.False(t, batch.Ready(start.Add(time.Hour)))
+}
+
+func TestWatcher(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "code", "services", "svc"), 0777))
+
+	watcher, err := New(root, Dirs)
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	batches := make(chan []Asset, 10)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		watcher.Run(50*time.Millisecond, stop, func(assets []Asset) { batches <- assets }, func(err error) { t.Error(err) })
+		close(done)
+	}()
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "code", "services", "svc", "svc.js"), []byte("a"), 0666))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "code", "libraries", "lib"), 0777))
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "code", "libraries", "lib", "lib.js"), []byte("b"), 0666))
+
+	select {
+	case assets := <-batches:
+		assert.Equal(t, []Asset{{KindLibrary, "lib"}, {KindService, "svc"}}, assets)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no batch of changes")
+	}
+
+	close(stop)
+	<-done
+}
@@ -0,0 +1,158 @@
+// Package widgetdirs names the directories of the widgets of a decompressed
+// portal after the names users gave the widgets, e.g. Temperature_Chart
+// rather than LINE_CHART_WIDGET_a1b2c3. The directory of each widget id is
+// kept in a map file next to the portal, so that directories only change when
+// widgets are renamed, and widgets sharing a name keep their suffixes.
+package widgetdirs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/clearblade/cblib/syspath"
+)
+
+// FileName is the name of the map file, a sidecar file of the directory of the
+// portal; see syspath.PortalSidecarExt.
+const FileName = "widget_dirs" + syspath.PortalSidecarExt
+
+// maxNameLength is the longest name used for a directory, before any suffix.
+const maxNameLength = 64
+
+// Widget is what a widget is named after.
+type Widget struct {
+	ID   string
+	Type string
+	// Name is the name the user gave the widget, if any.
+	Name string
+}
+
+// FromConfig returns the widget of the config of a widget. The name is the
+// first of the name, label and title settings holding a string.
+func FromConfig(id string, config map[string]interface{}) Widget {
+	widget := Widget{ID: id}
+	widget.Type, _ = config["type"].(string)
+	props, _ := config["props"].(map[string]interface{})
+	for _, key := range []string{"name", "label", "title"} {
+		if name, ok := props[key].(string); ok && strings.TrimSpace(name) != "" {
+			widget.Name = name
+			break
+		}
+	}
+	return widget
+}
+
+// Fallback returns the directory of a widget without a name: its type and id.
+func (w Widget) Fallback() string {
+	return fmt.Sprintf("%s_%v", w.Type, w.ID)
+}
+
+func (w Widget) base() string {
+	if name := Slug(w.Name); name != "" {
+		return name
+	}
+	return w.Fallback()
+}
+
+// Slug returns the name as a directory name: letters and digits are kept,
+// dashes and underscores too, and every other run of characters becomes an
+// underscore.
+func Slug(name string) string {
+	var b strings.Builder
+	pending := false
+	for _, r := range strings.TrimSpace(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			if pending && b.Len() > 0 {
+				b.WriteRune('_')
+			}
+			pending = false
+			b.WriteRune(r)
+			continue
+		}
+		pending = true
+	}
+	slug := b.String()
+	if runes := []rune(slug); len(runes) > maxNameLength {
+		slug = string(runes[:maxNameLength])
+	}
+	return strings.Trim(slug, "_")
+}
+
+// Assign returns the directory of each widget, by id. A widget keeps its
+// previous directory as long as its name is unchanged; other widgets get the
+// slug of their name, suffixed with _2, _3... if another widget has it
+// already. Directories are unique even on case insensitive file systems.
+func Assign(widgets []Widget, previous map[string]string) map[string]string {
+	sorted := append([]Widget{}, widgets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	dirs := map[string]string{}
+	taken := map[string]bool{}
+	for _, widget := range sorted {
+		dir, ok := previous[widget.ID]
+		if !ok || !isNameOf(dir, widget.base()) || taken[strings.ToLower(dir)] {
+			continue
+		}
+		dirs[widget.ID] = dir
+		taken[strings.ToLower(dir)] = true
+	}
+
+	for _, widget := range sorted {
+		if _, ok := dirs[widget.ID]; ok {
+			continue
+		}
+		base := widget.base()
+		dir := base
+		for n := 2; taken[strings.ToLower(dir)]; n++ {
+			dir = fmt.Sprintf("%s_%d", base, n)
+		}
+		dirs[widget.ID] = dir
+		taken[strings.ToLower(dir)] = true
+	}
+	return dirs
+}
+
+// isNameOf returns true if dir is base, or base with a numeric suffix.
+func isNameOf(dir, base string) bool {
+	if dir == base {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(dir, base+"_")
+	if !ok || suffix == "" {
+		return false
+	}
+	for _, r := range suffix {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Read reads a map file. A missing file is an empty map.
+func Read(path string) (map[string]string, error) {
+	dirs := map[string]string{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return dirs, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &dirs); err != nil {
+		return nil, fmt.Errorf("invalid widget directory map %s: %s", path, err)
+	}
+	return dirs, nil
+}
+
+// Write writes a map file.
+func Write(path string, dirs map[string]string) error {
+	data, err := json.MarshalIndent(dirs, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0666)
+}
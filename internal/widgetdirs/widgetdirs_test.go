@@ -0,0 +1,61 @@
+package widgetdirs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlug(t *testing.T) {
+	assert.Equal(t, "Temperature_Chart", Slug("  Temperature Chart "))
+	assert.Equal(t, "a_b-c_d", Slug("a / b-c:: d"))
+	assert.Equal(t, "Größe", Slug("Größe"))
+	assert.Equal(t, "", Slug("../.."))
+	assert.Len(t, Slug(string(make([]byte, 100))+"x"), 1)
+}
+
+func TestFromConfig(t *testing.T) {
+	widget := FromConfig("w1", map[string]interface{}{
+		"type":  "LABEL_WIDGET",
+		"props": map[string]interface{}{"label": map[string]interface{}{"dataType": "DYNAMIC_DATA_TYPE"}, "title": "Status"},
+	})
+	assert.Equal(t, Widget{ID: "w1", Type: "LABEL_WIDGET", Name: "Status"}, widget)
+	assert.Equal(t, "LABEL_WIDGET_w1", FromConfig("w1", map[string]interface{}{"type": "LABEL_WIDGET"}).base())
+}
+
+func TestAssign(t *testing.T) {
+	widgets := []Widget{
+		{ID: "c", Type: "CHART", Name: "Chart"},
+		{ID: "a", Type: "CHART", Name: "Chart"},
+		{ID: "b", Type: "LABEL", Name: "chart"},
+		{ID: "d", Type: "HTML"},
+	}
+	dirs := Assign(widgets, nil)
+	assert.Equal(t, map[string]string{"a": "Chart", "b": "chart_2", "c": "Chart_3", "d": "HTML_d"}, dirs)
+
+	// a new widget takes a free suffix, the others keep their directories
+	widgets = append(widgets, Widget{ID: "0", Type: "CHART", Name: "Chart"})
+	assert.Equal(t, map[string]string{"0": "Chart_4", "a": "Chart", "b": "chart_2", "c": "Chart_3", "d": "HTML_d"}, Assign(widgets, dirs))
+
+	// renamed widgets move, removed widgets free their directories
+	widgets = []Widget{
+		{ID: "a", Type: "CHART", Name: "Pressure"},
+		{ID: "c", Type: "CHART", Name: "Chart"},
+		{ID: "e", Type: "CHART", Name: "Chart"},
+	}
+	assert.Equal(t, map[string]string{"a": "Pressure", "c": "Chart_3", "e": "Chart"}, Assign(widgets, dirs))
+}
+
+func TestReadWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	dirs, err := Read(path)
+	require.NoError(t, err)
+	assert.Empty(t, dirs)
+
+	require.NoError(t, Write(path, map[string]string{"b": "Chart_2", "a": "Chart"}))
+	dirs, err = Read(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "Chart", "b": "Chart_2"}, dirs)
+}
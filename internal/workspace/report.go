@@ -0,0 +1,76 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Result is the outcome of running a command against a single member.
+type Result struct {
+	Member   string
+	Err      error
+	Duration time.Duration
+}
+
+// Report aggregates the results of running a command across members.
+type Report struct {
+	Command string
+	Results []Result
+}
+
+// Run calls fn for every given member and collects the results. It never stops
+// early so that a failing member doesn't hide the state of the others.
+func Run(command string, members []*Member, fn func(m *Member) error) *Report {
+	report := &Report{Command: command}
+	for _, m := range members {
+		start := time.Now()
+		err := fn(m)
+		report.Results = append(report.Results, Result{
+			Member:   m.Name,
+			Err:      err,
+			Duration: time.Since(start),
+		})
+	}
+	return report
+}
+
+// Failed returns the results that ended with an error.
+func (r *Report) Failed() []Result {
+	failed := make([]Result, 0)
+	for _, res := range r.Results {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Err returns an error summarizing the failed members, or nil if every member
+// succeeded.
+func (r *Report) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(failed))
+	for _, res := range failed {
+		names = append(names, res.Member)
+	}
+
+	return fmt.Errorf("%s failed for %d of %d systems: %s", r.Command, len(failed), len(r.Results), strings.Join(names, ", "))
+}
+
+func (r *Report) String() string {
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "Workspace %s summary:\n", r.Command)
+	for _, res := range r.Results {
+		status := "ok"
+		if res.Err != nil {
+			status = "FAILED: " + res.Err.Error()
+		}
+		fmt.Fprintf(&sb, "    %-30s %-8s %s\n", res.Member, res.Duration.Round(time.Millisecond), status)
+	}
+	return sb.String()
+}
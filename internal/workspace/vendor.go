@@ -0,0 +1,86 @@
+package workspace
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// VendorSharedLibraries copies the shared libraries of the workspace into the
+// code/libraries directory of the given member, replacing any existing copy.
+// It returns the names of the vendored libraries.
+func (w *Workspace) VendorSharedLibraries(m *Member) ([]string, error) {
+	if w.SharedLibraries == nil || m.SkipSharedLibraries {
+		return nil, nil
+	}
+
+	srcRoot := filepath.Join(w.Root, w.SharedLibraries.Dir)
+	dstRoot := filepath.Join(w.MemberDir(m), "code", "libraries")
+
+	for _, name := range w.SharedLibraries.Names {
+		src := filepath.Join(srcRoot, name)
+		stat, err := os.Stat(src)
+		if err != nil {
+			return nil, fmt.Errorf("shared library '%s': %w", name, err)
+		}
+
+		if !stat.IsDir() {
+			return nil, fmt.Errorf("shared library '%s': not a directory: %s", name, src)
+		}
+
+		dst := filepath.Join(dstRoot, name)
+		err = os.RemoveAll(dst)
+		if err != nil {
+			return nil, err
+		}
+
+		err = copyDir(src, dst)
+		if err != nil {
+			return nil, fmt.Errorf("could not vendor shared library '%s' into '%s': %w", name, m.Name, err)
+		}
+	}
+
+	return w.SharedLibraries.Names, nil
+}
+
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, 0777)
+		}
+
+		return copyFile(p, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,164 @@
+// Package workspace implements multi-system workspaces: a single directory
+// holding several system directories (each with its own remotes) described by
+// a workspace file at the root.
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/clearblade/cblib/internal/fsutil"
+)
+
+const (
+	// FileName is the name of the workspace file at the workspace root.
+	FileName = "cb-workspace.json"
+)
+
+// Workspace is the parsed content of a workspace file.
+type Workspace struct {
+	// Root is the directory the workspace file was loaded from. Member
+	// directories are relative to it.
+	Root string `json:"-"`
+
+	Systems         []*Member        `json:"systems"`
+	SharedLibraries *SharedLibraries `json:"shared_libraries,omitempty"`
+}
+
+// Member is a single system directory of the workspace.
+type Member struct {
+	Name string `json:"name"`
+	Dir  string `json:"dir"`
+
+	// SkipSharedLibraries opts the member out of shared library vendoring.
+	SkipSharedLibraries bool `json:"skip_shared_libraries,omitempty"`
+}
+
+// SharedLibraries declares libraries that live once in the workspace and are
+// vendored into every member system on push.
+type SharedLibraries struct {
+	// Dir holds one sub directory per library, laid out like
+	// code/libraries/<name> in a system directory.
+	Dir   string   `json:"dir"`
+	Names []string `json:"names"`
+}
+
+// Exists returns true if the given directory contains a workspace file.
+func Exists(rootDir string) bool {
+	_, err := os.Stat(path.Join(rootDir, FileName))
+	return err == nil
+}
+
+// LoadFromDir loads and validates the workspace file in the given directory.
+func LoadFromDir(rootDir string) (*Workspace, error) {
+	err := fsutil.EnsureDirectory(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path.Join(rootDir, FileName))
+	if err != nil {
+		return nil, err
+	}
+
+	ws := &Workspace{}
+	err = json.Unmarshal(data, ws)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", FileName, err)
+	}
+
+	ws.Root = rootDir
+	err = ws.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+// Validate checks that member names are unique and that member directories
+// stay inside the workspace.
+func (w *Workspace) Validate() error {
+	if len(w.Systems) == 0 {
+		return fmt.Errorf("workspace has no systems")
+	}
+
+	seen := make(map[string]struct{}, len(w.Systems))
+	for _, m := range w.Systems {
+		if m.Name == "" {
+			return fmt.Errorf("workspace system with dir '%s' has no name", m.Dir)
+		}
+
+		if _, ok := seen[m.Name]; ok {
+			return fmt.Errorf("duplicate workspace system '%s'", m.Name)
+		}
+		seen[m.Name] = struct{}{}
+
+		if err := checkRelative(m.Dir); err != nil {
+			return fmt.Errorf("workspace system '%s': %w", m.Name, err)
+		}
+	}
+
+	if w.SharedLibraries != nil {
+		if err := checkRelative(w.SharedLibraries.Dir); err != nil {
+			return fmt.Errorf("shared libraries: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func checkRelative(dir string) error {
+	if dir == "" {
+		return fmt.Errorf("dir is required")
+	}
+
+	if filepath.IsAbs(dir) {
+		return fmt.Errorf("dir '%s' must be relative to the workspace", dir)
+	}
+
+	clean := filepath.Clean(dir)
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("dir '%s' is outside of the workspace", dir)
+	}
+
+	return nil
+}
+
+// MemberDir returns the directory of the given member.
+func (w *Workspace) MemberDir(m *Member) string {
+	return filepath.Join(w.Root, m.Dir)
+}
+
+// Select returns the members with the given names, in workspace order. An
+// empty list selects every member.
+func (w *Workspace) Select(names []string) ([]*Member, error) {
+	if len(names) == 0 {
+		return w.Systems, nil
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = false
+	}
+
+	selected := make([]*Member, 0, len(names))
+	for _, m := range w.Systems {
+		if _, ok := wanted[m.Name]; ok {
+			wanted[m.Name] = true
+			selected = append(selected, m)
+		}
+	}
+
+	for _, name := range names {
+		if !wanted[name] {
+			return nil, fmt.Errorf("unknown workspace system '%s'", name)
+		}
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,140 @@
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, p, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0777))
+	require.NoError(t, os.WriteFile(p, []byte(content), 0666))
+}
+
+func makeWorkspace(t *testing.T, content string) string {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, FileName), content)
+	return root
+}
+
+const twoSystems = `{
+    "systems": [
+        {"name": "core", "dir": "systems/core"},
+        {"name": "edge", "dir": "systems/edge", "skip_shared_libraries": true}
+    ],
+    "shared_libraries": {"dir": "shared", "names": ["utils"]}
+}`
+
+func TestLoadFromDir(t *testing.T) {
+	root := makeWorkspace(t, twoSystems)
+	assert.True(t, Exists(root))
+
+	ws, err := LoadFromDir(root)
+	require.NoError(t, err)
+	require.Len(t, ws.Systems, 2)
+	assert.Equal(t, "core", ws.Systems[0].Name)
+	assert.Equal(t, filepath.Join(root, "systems/core"), ws.MemberDir(ws.Systems[0]))
+	assert.True(t, ws.Systems[1].SkipSharedLibraries)
+	assert.Equal(t, []string{"utils"}, ws.SharedLibraries.Names)
+}
+
+func TestLoadFromDirMissing(t *testing.T) {
+	root := t.TempDir()
+	assert.False(t, Exists(root))
+
+	_, err := LoadFromDir(root)
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	cases := map[string]string{
+		"no systems": `{"systems": []}`,
+		"no name":    `{"systems": [{"dir": "a"}]}`,
+		"duplicate":  `{"systems": [{"name": "a", "dir": "a"}, {"name": "a", "dir": "b"}]}`,
+		"outside":    `{"systems": [{"name": "a", "dir": "../a"}]}`,
+		"absolute":   `{"systems": [{"name": "a", "dir": "/a"}]}`,
+		"no dir":     `{"systems": [{"name": "a"}]}`,
+		"shared":     `{"systems": [{"name": "a", "dir": "a"}], "shared_libraries": {"dir": "../x"}}`,
+	}
+
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadFromDir(makeWorkspace(t, content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	ws, err := LoadFromDir(makeWorkspace(t, twoSystems))
+	require.NoError(t, err)
+
+	all, err := ws.Select(nil)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	some, err := ws.Select([]string{"edge"})
+	require.NoError(t, err)
+	require.Len(t, some, 1)
+	assert.Equal(t, "edge", some[0].Name)
+
+	_, err = ws.Select([]string{"edge", "nope"})
+	assert.Error(t, err)
+}
+
+func TestVendorSharedLibraries(t *testing.T) {
+	root := makeWorkspace(t, twoSystems)
+	writeFile(t, filepath.Join(root, "shared/utils/utils.js"), "function utils() {}")
+	writeFile(t, filepath.Join(root, "shared/utils/utils.json"), `{"name": "utils"}`)
+	writeFile(t, filepath.Join(root, "systems/core/code/libraries/utils/stale.js"), "stale")
+
+	ws, err := LoadFromDir(root)
+	require.NoError(t, err)
+
+	names, err := ws.VendorSharedLibraries(ws.Systems[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"utils"}, names)
+
+	libDir := filepath.Join(root, "systems/core/code/libraries/utils")
+	data, err := os.ReadFile(filepath.Join(libDir, "utils.js"))
+	require.NoError(t, err)
+	assert.Equal(t, "function utils() {}", string(data))
+	assert.FileExists(t, filepath.Join(libDir, "utils.json"))
+	assert.NoFileExists(t, filepath.Join(libDir, "stale.js"))
+
+	names, err = ws.VendorSharedLibraries(ws.Systems[1])
+	require.NoError(t, err)
+	assert.Empty(t, names)
+	assert.NoDirExists(t, filepath.Join(root, "systems/edge/code/libraries/utils"))
+}
+
+func TestVendorSharedLibrariesMissing(t *testing.T) {
+	ws, err := LoadFromDir(makeWorkspace(t, twoSystems))
+	require.NoError(t, err)
+
+	_, err = ws.VendorSharedLibraries(ws.Systems[0])
+	assert.Error(t, err)
+}
+
+func TestRunReport(t *testing.T) {
+	ws, err := LoadFromDir(makeWorkspace(t, twoSystems))
+	require.NoError(t, err)
+
+	var visited []string
+	report := Run("push", ws.Systems, func(m *Member) error {
+		visited = append(visited, m.Name)
+		if m.Name == "core" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	assert.Equal(t, []string{"core", "edge"}, visited)
+	require.Len(t, report.Failed(), 1)
+	assert.EqualError(t, report.Err(), "push failed for 1 of 2 systems: core")
+	assert.Contains(t, report.String(), "FAILED: boom")
+}
@@ -0,0 +1,39 @@
+package cblib
+
+import (
+	"flag"
+
+	"github.com/clearblade/cblib/internal/metaformat"
+)
+
+var (
+	flagMetadataFormat string
+	flagRoleFormat     string
+)
+
+func setMetadataFormatFlag(f *flag.FlagSet) {
+	f.StringVar(&flagMetadataFormat, "metadata-format", "", "On-disk format of roles, triggers, timers, webhooks, deployments and adapters: 'json' or 'yaml'. Stored in .cb-cli/settings.json")
+	f.StringVar(&flagRoleFormat, "role-format", "", "Shape of role files: 'platform' or 'readable' (per-asset lists of read/create/update/delete verbs). Stored in .cb-cli/settings.json")
+}
+
+// applyMetadataFormatFlag persists the -metadata-format and -role-format
+// flags, if given, to the settings of the system rooted at rootDir. Assets are
+// written in the new format the next time they are pulled.
+func applyMetadataFormatFlag() error {
+	if flagMetadataFormat == "" && flagRoleFormat == "" {
+		return nil
+	}
+
+	settings, err := metaformat.LoadSettings(rootDir)
+	if err != nil {
+		return err
+	}
+
+	if flagMetadataFormat != "" {
+		settings.MetadataFormat = metaformat.Format(flagMetadataFormat)
+	}
+	if flagRoleFormat != "" {
+		settings.RoleFormat = metaformat.RoleFormat(flagRoleFormat)
+	}
+	return metaformat.SaveSettings(rootDir, settings)
+}
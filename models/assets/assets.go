@@ -0,0 +1,176 @@
+// Package assets holds typed models of the assets stored in a system
+// directory. Files on disk (and platform responses) are decoded into these
+// models before use, so malformed input is reported as an error instead of
+// panicking on a type assertion further down the line.
+package assets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Model is implemented by every asset model.
+type Model interface {
+	// Kind returns the kind of asset, used in error messages.
+	Kind() string
+	// Validate returns an error if required fields are missing or invalid.
+	Validate() error
+}
+
+// Extras holds the fields of an asset that have no counterpart in its model.
+// Models embed it so that the fields of local files, user-defined (devices,
+// edges, ...) or not, survive a round trip. Pulled assets are whitelisted with
+// DropExtras.
+type Extras struct {
+	Extra map[string]interface{} `json:"-"`
+
+	// present are the keys of the decoded map, so that ToMap doesn't add
+	// known fields the source didn't have.
+	present map[string]bool
+}
+
+func (e *Extras) extras() *Extras {
+	return e
+}
+
+type hasExtras interface {
+	extras() *Extras
+}
+
+// DropExtras forgets the fields of the decoded map the model has no
+// counterpart for, and which of its fields were absent, so that ToMap returns
+// every known field and nothing else.
+func DropExtras(model Model) {
+	if withExtras, ok := model.(hasExtras); ok {
+		*withExtras.extras() = Extras{}
+	}
+}
+
+// FromMap decodes the map into the given model and validates it.
+func FromMap(m map[string]interface{}, model Model) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %s", model.Kind(), err)
+	}
+
+	err = json.Unmarshal(data, model)
+	if err != nil {
+		return describeDecodeError(model, m, err)
+	}
+
+	if withExtras, ok := model.(hasExtras); ok {
+		known := jsonFieldNames(model)
+		extras := withExtras.extras()
+		extras.Extra = map[string]interface{}{}
+		extras.present = map[string]bool{}
+		for key, value := range m {
+			extras.present[key] = true
+			if !known[key] {
+				extras.Extra[key] = value
+			}
+		}
+	}
+
+	return model.Validate()
+}
+
+// ToMap encodes the model, extras included, into a map.
+func ToMap(model Model) (map[string]interface{}, error) {
+	data, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+
+	rtn := map[string]interface{}{}
+	err = json.Unmarshal(data, &rtn)
+	if err != nil {
+		return nil, err
+	}
+
+	if withExtras, ok := model.(hasExtras); ok {
+		extras := withExtras.extras()
+		if extras.present != nil {
+			for key, value := range rtn {
+				if !extras.present[key] && isZero(value) {
+					delete(rtn, key)
+				}
+			}
+		}
+		for key, value := range extras.Extra {
+			if _, found := rtn[key]; !found {
+				rtn[key] = value
+			}
+		}
+	}
+
+	return rtn, nil
+}
+
+func isZero(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	default:
+		return false
+	}
+}
+
+func describeDecodeError(model Model, m map[string]interface{}, err error) error {
+	name, _ := m["name"].(string)
+	what := model.Kind()
+	if name != "" {
+		what = fmt.Sprintf("%s '%s'", what, name)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return fmt.Errorf("invalid %s: field '%s' must be %s, got %s", what, typeErr.Field, typeErr.Type, typeErr.Value)
+	}
+	return fmt.Errorf("invalid %s: %s", what, err)
+}
+
+// jsonFieldNames returns the JSON names of the fields of the model's struct.
+func jsonFieldNames(model Model) map[string]bool {
+	rtn := map[string]bool{}
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || field.Anonymous {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		rtn[name] = true
+	}
+	return rtn
+}
+
+func requireName(model Model, name string) error {
+	if name == "" {
+		return fmt.Errorf("invalid %s: missing name", model.Kind())
+	}
+	return nil
+}
+
+func requireField(model Model, name, field, value string) error {
+	if value == "" {
+		return fmt.Errorf("invalid %s '%s': missing %s", model.Kind(), name, field)
+	}
+	return nil
+}
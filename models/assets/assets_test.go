@@ -0,0 +1,131 @@
+package assets
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fromJSON(t *testing.T, s string) map[string]interface{} {
+	m := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(s), &m))
+	return m
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		model Model
+		json  string
+	}{
+		{&Service{}, `{"name": "svc", "description": "", "dependencies": "a,b", "code": "function svc(req, resp) {}", "params": ["x"], "run_user": "", "auth_needed": true}`},
+		{&Library{}, `{"name": "lib", "api": "", "dependencies": "", "description": "d", "visibility": "system"}`},
+		{&Trigger{}, `{"name": "t", "service_name": "svc", "disabled": false, "event_definition": {"def_module": "Data", "def_name": "ItemCreated"}, "key_value_pairs": {"collectionName": "orders"}}`},
+		{&Timer{}, `{"name": "t", "service_name": "svc", "description": "", "frequency": 60, "repeats": -1, "start_time": "2020-01-01T00:00:00Z", "disabled": true}`},
+		{&Webhook{}, `{"name": "w", "service_name": "svc", "auth_method": "header", "description": "", "path": "w"}`},
+		{&Deployment{}, `{"name": "d", "description": "", "assets": {"services": ["svc"]}, "edges": ["e1"]}`},
+		{&Edge{}, `{"name": "e", "description": "", "custom_column": 3, "token": "x"}`},
+		{&Device{}, `{"name": "d", "type": "sensor", "state": "", "description": "", "enabled": true, "allow_key_auth": false, "allow_certificate_auth": false, "room": "kitchen"}`},
+		{&Portal{}, `{"name": "p", "description": "", "config": {"pages": []}}`},
+		{&Plugin{}, `{"name": "p", "description": "", "version": "1"}`},
+		{&ServiceCache{}, `{"name": "c", "description": "", "ttl": 3600}`},
+		{&ExternalDatabase{}, `{"name": "db", "dbtype": "postgres", "credentials": {"user": "u"}}`},
+		{&MessageHistoryStorageEntry{}, `{"topic": "a/b", "edge": true, "platform": false, "max_rows": 10, "max_time": 20}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.model.Kind(), func(t *testing.T) {
+			in := fromJSON(t, test.json)
+			require.NoError(t, FromMap(in, test.model))
+
+			out, err := ToMap(test.model)
+			require.NoError(t, err)
+			assert.Equal(t, in, out)
+		})
+	}
+}
+
+func TestToMapDoesNotAddMissingFields(t *testing.T) {
+	device := &Device{}
+	require.NoError(t, FromMap(fromJSON(t, `{"name": "d", "enabled": true}`), device))
+
+	out, err := ToMap(device)
+	require.NoError(t, err)
+	assert.Equal(t, fromJSON(t, `{"name": "d", "enabled": true}`), out)
+
+	// models that aren't decoded have every field
+	out, err = ToMap(&Device{Name: "d"})
+	require.NoError(t, err)
+	assert.Contains(t, out, "allow_key_auth")
+}
+
+func TestDropExtras(t *testing.T) {
+	trigger := &Trigger{}
+	require.NoError(t, FromMap(fromJSON(t, `{"name": "t", "service_name": "svc", "namespace": "x"}`), trigger))
+	DropExtras(trigger)
+
+	out, err := ToMap(trigger)
+	require.NoError(t, err)
+	assert.NotContains(t, out, "namespace")
+	assert.Equal(t, false, out["disabled"])
+}
+
+func TestLocalAssetsKeepTheirFields(t *testing.T) {
+	timer := &Timer{}
+	require.NoError(t, FromMap(fromJSON(t, `{"name": "t", "service_name": "svc", "namespace": "x"}`), timer))
+	out, err := ToMap(timer)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "t", "service_name": "svc", "namespace": "x"}, out)
+
+	library := &Library{}
+	require.NoError(t, FromMap(fromJSON(t, `{"name": "lib", "api": "", "owner": "me"}`), library))
+	out, err = ToMap(library)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "lib", "api": "", "owner": "me"}, out)
+}
+
+func TestFromMapTypeErrors(t *testing.T) {
+	err := FromMap(fromJSON(t, `{"topic": "a", "max_rows": "ten"}`), &MessageHistoryStorageEntry{})
+	require.Error(t, err)
+	assert.Equal(t, "invalid message history storage entry: field 'max_rows' must be int, got string", err.Error())
+
+	err = FromMap(fromJSON(t, `{"name": "svc", "code": 3}`), &Service{})
+	require.Error(t, err)
+	assert.Equal(t, "invalid service 'svc': field 'code' must be string, got number", err.Error())
+
+	err = FromMap(fromJSON(t, `{"name": "lib", "dependencies": ["a"]}`), &Library{})
+	assert.Error(t, err)
+
+	err = FromMap(fromJSON(t, `{"name": "t", "service_name": "svc", "frequency": 1.5}`), &Timer{})
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		model Model
+		json  string
+		err   string
+	}{
+		{&Service{}, `{}`, "invalid service: missing name"},
+		{&Trigger{}, `{"name": "t"}`, "invalid trigger 't': missing service_name"},
+		{&Timer{}, `{"name": "t"}`, "invalid timer 't': missing service_name"},
+		{&Webhook{}, `{"name": "w"}`, "invalid webhook 'w': missing service_name"},
+		{&ExternalDatabase{}, `{"name": "db"}`, "invalid external database 'db': missing dbtype"},
+		{&MessageHistoryStorageEntry{}, `{}`, "invalid message history storage entry: missing topic"},
+		{&Deployment{}, `{"description": "x"}`, "invalid deployment: missing name"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.model.Kind(), func(t *testing.T) {
+			err := FromMap(fromJSON(t, test.json), test.model)
+			require.Error(t, err)
+			assert.Equal(t, test.err, err.Error())
+		})
+	}
+}
+
+func TestDependencyNames(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, (&Library{Dependencies: "a, ,b,"}).DependencyNames())
+	assert.Equal(t, []string{}, (&Service{}).DependencyNames())
+}
@@ -0,0 +1,75 @@
+package assets
+
+// Trigger runs a service when a platform event happens.
+type Trigger struct {
+	EventDefinition map[string]interface{} `json:"event_definition"`
+	KeyValuePairs   map[string]interface{} `json:"key_value_pairs"`
+	Name            string                 `json:"name"`
+	ServiceName     string                 `json:"service_name"`
+	Disabled        bool                   `json:"disabled"`
+	Extras
+}
+
+func (t *Trigger) Kind() string { return "trigger" }
+
+func (t *Trigger) Validate() error {
+	if err := requireName(t, t.Name); err != nil {
+		return err
+	}
+	return requireField(t, t.Name, "service_name", t.ServiceName)
+}
+
+// Timer runs a service on a schedule.
+type Timer struct {
+	Description string `json:"description"`
+	Frequency   int    `json:"frequency"`
+	Name        string `json:"name"`
+	Repeats     int    `json:"repeats"`
+	ServiceName string `json:"service_name"`
+	StartTime   string `json:"start_time"`
+	Disabled    bool   `json:"disabled"`
+	Extras
+}
+
+func (t *Timer) Kind() string { return "timer" }
+
+func (t *Timer) Validate() error {
+	if err := requireName(t, t.Name); err != nil {
+		return err
+	}
+	return requireField(t, t.Name, "service_name", t.ServiceName)
+}
+
+// Webhook runs a service when its path is requested.
+type Webhook struct {
+	AuthMethod  string `json:"auth_method"`
+	Description string `json:"description"`
+	Name        string `json:"name"`
+	ServiceName string `json:"service_name"`
+	Path        string `json:"path"`
+	Extras
+}
+
+func (w *Webhook) Kind() string { return "webhook" }
+
+func (w *Webhook) Validate() error {
+	if err := requireName(w, w.Name); err != nil {
+		return err
+	}
+	return requireField(w, w.Name, "service_name", w.ServiceName)
+}
+
+// Deployment distributes assets to edges.
+type Deployment struct {
+	Assets      map[string]interface{} `json:"assets"`
+	Description string                 `json:"description"`
+	Edges       []interface{}          `json:"edges"`
+	Name        string                 `json:"name"`
+	Extras
+}
+
+func (d *Deployment) Kind() string { return "deployment" }
+
+func (d *Deployment) Validate() error {
+	return requireName(d, d.Name)
+}
@@ -0,0 +1,61 @@
+package assets
+
+import "strings"
+
+// Service is a code service. Fields the CLI doesn't use (parameters, run
+// user, log settings, ...) are kept as extras.
+type Service struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Dependencies string `json:"dependencies"`
+	Code         string `json:"code,omitempty"`
+	SourceMap    string `json:"source_map,omitempty"`
+	Extras
+}
+
+func (s *Service) Kind() string { return "service" }
+
+func (s *Service) Validate() error {
+	return requireName(s, s.Name)
+}
+
+// DependencyNames returns the names of the libraries the service depends on.
+func (s *Service) DependencyNames() []string {
+	return splitDependencies(s.Dependencies)
+}
+
+// Library is a code library.
+type Library struct {
+	Name         string `json:"name"`
+	API          string `json:"api"`
+	Dependencies string `json:"dependencies"`
+	Description  string `json:"description"`
+	Visibility   string `json:"visibility"`
+	Code         string `json:"code,omitempty"`
+	SourceMap    string `json:"source_map,omitempty"`
+	Extras
+}
+
+func (l *Library) Kind() string { return "library" }
+
+func (l *Library) Validate() error {
+	return requireName(l, l.Name)
+}
+
+// DependencyNames returns the names of the libraries the library depends on.
+func (l *Library) DependencyNames() []string {
+	return splitDependencies(l.Dependencies)
+}
+
+// splitDependencies splits the comma separated dependencies field, ignoring
+// blanks.
+func splitDependencies(dependencies string) []string {
+	rtn := []string{}
+	for _, name := range strings.Split(dependencies, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			rtn = append(rtn, name)
+		}
+	}
+	return rtn
+}
@@ -0,0 +1,83 @@
+package assets
+
+import "fmt"
+
+// Edge is an edge of the system. Custom edge columns are kept as extras.
+type Edge struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Extras
+}
+
+func (e *Edge) Kind() string { return "edge" }
+
+func (e *Edge) Validate() error {
+	return requireName(e, e.Name)
+}
+
+// Device is a device of the system. Custom device columns are kept as extras.
+type Device struct {
+	Name                 string `json:"name"`
+	Type                 string `json:"type"`
+	State                string `json:"state"`
+	Description          string `json:"description"`
+	Enabled              bool   `json:"enabled"`
+	AllowKeyAuth         bool   `json:"allow_key_auth"`
+	AllowCertificateAuth bool   `json:"allow_certificate_auth"`
+	Extras
+}
+
+func (d *Device) Kind() string { return "device" }
+
+func (d *Device) Validate() error {
+	return requireName(d, d.Name)
+}
+
+// ServiceCache is a shared cache.
+type ServiceCache struct {
+	Description string `json:"description"`
+	TTL         int    `json:"ttl"`
+	Name        string `json:"name"`
+	Extras
+}
+
+func (s *ServiceCache) Kind() string { return "shared cache" }
+
+func (s *ServiceCache) Validate() error {
+	return requireName(s, s.Name)
+}
+
+// ExternalDatabase is a connection to a database outside of the platform.
+type ExternalDatabase struct {
+	Credentials map[string]interface{} `json:"credentials"`
+	Name        string                 `json:"name"`
+	DBType      string                 `json:"dbtype"`
+	Extras
+}
+
+func (e *ExternalDatabase) Kind() string { return "external database" }
+
+func (e *ExternalDatabase) Validate() error {
+	if err := requireName(e, e.Name); err != nil {
+		return err
+	}
+	return requireField(e, e.Name, "dbtype", e.DBType)
+}
+
+// MessageHistoryStorageEntry configures how long messages of a topic are kept.
+type MessageHistoryStorageEntry struct {
+	Edge     bool   `json:"edge"`
+	Platform bool   `json:"platform"`
+	MaxRows  int    `json:"max_rows"`
+	MaxTime  int    `json:"max_time"`
+	Topic    string `json:"topic"`
+}
+
+func (m *MessageHistoryStorageEntry) Kind() string { return "message history storage entry" }
+
+func (m *MessageHistoryStorageEntry) Validate() error {
+	if m.Topic == "" {
+		return fmt.Errorf("invalid %s: missing topic", m.Kind())
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package assets
+
+// Portal is a portal. Its config and the rest of its fields are kept as
+// extras since the portal layout on disk is handled separately.
+type Portal struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Extras
+}
+
+func (p *Portal) Kind() string { return "portal" }
+
+func (p *Portal) Validate() error {
+	return requireName(p, p.Name)
+}
+
+// Plugin is a portal plugin.
+type Plugin struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Extras
+}
+
+func (p *Plugin) Kind() string { return "plugin" }
+
+func (p *Plugin) Validate() error {
+	return requireName(p, p.Name)
+}
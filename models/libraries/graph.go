@@ -0,0 +1,322 @@
+package libraries
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Kinds of nodes in a dependency graph. Only libraries can be depended on.
+const (
+	KindLibrary = "library"
+	KindService = "service"
+)
+
+// Node is a library or service and the names of the libraries it depends on.
+type Node struct {
+	Kind         string
+	Name         string
+	Dependencies []string
+}
+
+func (n *Node) String() string {
+	return n.Kind + " " + n.Name
+}
+
+// UnknownDependency is a dependency on a library that doesn't exist.
+type UnknownDependency struct {
+	From *Node
+	Name string
+}
+
+func (u UnknownDependency) String() string {
+	return fmt.Sprintf("%s depends on unknown library '%s'", u.From, u.Name)
+}
+
+// Graph is the dependency graph of the libraries and services of a system.
+type Graph struct {
+	libraries map[string]*Node
+	services  map[string]*Node
+}
+
+// NewGraph builds the graph of the given nodes.
+func NewGraph(nodes []*Node) *Graph {
+	g := &Graph{
+		libraries: map[string]*Node{},
+		services:  map[string]*Node{},
+	}
+	for _, n := range nodes {
+		if n.Kind == KindService {
+			g.services[n.Name] = n
+		} else {
+			g.libraries[n.Name] = n
+		}
+	}
+	return g
+}
+
+// Library returns the library with the given name.
+func (g *Graph) Library(name string) (*Node, bool) {
+	n, ok := g.libraries[name]
+	return n, ok
+}
+
+// Service returns the service with the given name.
+func (g *Graph) Service(name string) (*Node, bool) {
+	n, ok := g.services[name]
+	return n, ok
+}
+
+// Nodes returns every node, libraries first, sorted by name.
+func (g *Graph) Nodes() []*Node {
+	return append(sortedNodes(g.libraries), sortedNodes(g.services)...)
+}
+
+func sortedNodes(m map[string]*Node) []*Node {
+	rtn := make([]*Node, 0, len(m))
+	for _, n := range m {
+		rtn = append(rtn, n)
+	}
+	sort.Slice(rtn, func(i, j int) bool { return rtn[i].Name < rtn[j].Name })
+	return rtn
+}
+
+// Unknown returns the dependencies on libraries that don't exist.
+func (g *Graph) Unknown() []UnknownDependency {
+	rtn := []UnknownDependency{}
+	for _, n := range g.Nodes() {
+		for _, dep := range n.Dependencies {
+			if _, ok := g.libraries[dep]; !ok {
+				rtn = append(rtn, UnknownDependency{From: n, Name: dep})
+			}
+		}
+	}
+	return rtn
+}
+
+// Cycles returns the dependency cycles between libraries. Each cycle starts
+// and ends with the same library, e.g. [a b a].
+func (g *Graph) Cycles() [][]string {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+
+	state := map[string]int{}
+	stack := []string{}
+	cycles := [][]string{}
+
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		state[n.Name] = inProgress
+		stack = append(stack, n.Name)
+
+		for _, dep := range n.Dependencies {
+			depNode, ok := g.libraries[dep]
+			if !ok {
+				continue
+			}
+			switch state[dep] {
+			case unvisited:
+				visit(depNode)
+			case inProgress:
+				start := indexOf(stack, dep)
+				cycle := append([]string{}, stack[start:]...)
+				cycles = append(cycles, append(cycle, dep))
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[n.Name] = done
+	}
+
+	for _, n := range sortedNodes(g.libraries) {
+		if state[n.Name] == unvisited {
+			visit(n)
+		}
+	}
+	return cycles
+}
+
+func indexOf(list []string, s string) int {
+	for i, item := range list {
+		if item == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// Check returns an error describing every cycle and unknown dependency.
+func (g *Graph) Check() error {
+	problems := []string{}
+	for _, cycle := range g.Cycles() {
+		problems = append(problems, "dependency cycle: "+strings.Join(cycle, " -> "))
+	}
+	for _, u := range g.Unknown() {
+		problems = append(problems, u.String())
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(problems, "\n"))
+}
+
+// CheckCycles returns an error describing the dependency cycles through any
+// of the given libraries, or through any library if none are given. Unknown
+// dependencies aren't checked: outside of the local system, they may be
+// global libraries, which are never pulled.
+func (g *Graph) CheckCycles(libraries ...string) error {
+	problems := []string{}
+	for _, cycle := range g.Cycles() {
+		if len(libraries) > 0 && !containsAny(cycle, libraries) {
+			continue
+		}
+		problems = append(problems, "dependency cycle: "+strings.Join(cycle, " -> "))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(problems, "\n"))
+}
+
+func containsAny(list []string, names []string) bool {
+	for _, name := range names {
+		if indexOf(list, name) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Dependencies returns the libraries the node depends on, directly or not,
+// in the order they must be pushed (dependencies first). Libraries which
+// aren't in the graph, e.g. global libraries, are left out.
+func (g *Graph) Dependencies(n *Node) ([]string, error) {
+	rtn := []string{}
+	visited := map[string]bool{}
+	inProgress := map[string]bool{}
+
+	var visit func(n *Node) error
+	visit = func(n *Node) error {
+		for _, dep := range n.Dependencies {
+			if visited[dep] {
+				continue
+			}
+			if inProgress[dep] {
+				return fmt.Errorf("dependency cycle through library '%s'", dep)
+			}
+			depNode, ok := g.libraries[dep]
+			if !ok {
+				continue
+			}
+			inProgress[dep] = true
+			if err := visit(depNode); err != nil {
+				return err
+			}
+			inProgress[dep] = false
+			visited[dep] = true
+			rtn = append(rtn, dep)
+		}
+		return nil
+	}
+
+	if err := visit(n); err != nil {
+		return nil, err
+	}
+	return rtn, nil
+}
+
+// Dependents returns the libraries and services that depend on the given
+// library, directly or not. Those are what may break when the library changes.
+// Libraries are in the order they must be pushed and services are sorted by
+// name.
+func (g *Graph) Dependents(library string) (libraries []string, services []string) {
+	reverse := map[string][]*Node{}
+	for _, n := range g.Nodes() {
+		for _, dep := range n.Dependencies {
+			reverse[dep] = append(reverse[dep], n)
+		}
+	}
+
+	dependentLibraries := map[string]bool{}
+	seen := map[*Node]bool{}
+	queue := []string{library}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, n := range reverse[name] {
+			if seen[n] {
+				continue
+			}
+			seen[n] = true
+			if n.Kind == KindService {
+				services = append(services, n.Name)
+			} else if n.Name != library {
+				dependentLibraries[n.Name] = true
+				queue = append(queue, n.Name)
+			}
+		}
+	}
+
+	sort.Strings(services)
+	return g.pushOrder(dependentLibraries), services
+}
+
+// pushOrder sorts the given libraries so that each comes after the ones it
+// depends on. Ties are broken by name.
+func (g *Graph) pushOrder(names map[string]bool) []string {
+	rtn := []string{}
+	visited := map[string]bool{}
+
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] || !names[name] {
+			return
+		}
+		visited[name] = true
+		for _, dep := range g.libraries[name].Dependencies {
+			visit(dep)
+		}
+		rtn = append(rtn, name)
+	}
+
+	for _, n := range sortedNodes(g.libraries) {
+		visit(n.Name)
+	}
+	return rtn
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language. Edges go from a node
+// to the libraries it depends on; unknown libraries are drawn dashed.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	lines := []string{"digraph dependencies {", "\trankdir=LR;"}
+	for _, n := range sortedNodes(g.libraries) {
+		lines = append(lines, fmt.Sprintf("\t%q [shape=box];", dotID(n.Kind, n.Name)))
+	}
+	for _, n := range sortedNodes(g.services) {
+		lines = append(lines, fmt.Sprintf("\t%q [shape=ellipse];", dotID(n.Kind, n.Name)))
+	}
+	unknown := map[string]bool{}
+	for _, u := range g.Unknown() {
+		if !unknown[u.Name] {
+			unknown[u.Name] = true
+			lines = append(lines, fmt.Sprintf("\t%q [shape=box, style=dashed];", dotID(KindLibrary, u.Name)))
+		}
+	}
+	for _, n := range g.Nodes() {
+		for _, dep := range n.Dependencies {
+			lines = append(lines, fmt.Sprintf("\t%q -> %q;", dotID(n.Kind, n.Name), dotID(KindLibrary, dep)))
+		}
+	}
+	lines = append(lines, "}")
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+func dotID(kind, name string) string {
+	return kind + ":" + name
+}
@@ -0,0 +1,139 @@
+package libraries
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testGraph() *Graph {
+	return NewGraph([]*Node{
+		{Kind: KindLibrary, Name: "a", Dependencies: []string{"b", "c"}},
+		{Kind: KindLibrary, Name: "b", Dependencies: []string{"c"}},
+		{Kind: KindLibrary, Name: "c", Dependencies: []string{}},
+		{Kind: KindLibrary, Name: "d", Dependencies: []string{}},
+		{Kind: KindService, Name: "svc1", Dependencies: []string{"a"}},
+		{Kind: KindService, Name: "svc2", Dependencies: []string{"c"}},
+		{Kind: KindService, Name: "svc3", Dependencies: []string{"d"}},
+	})
+}
+
+func TestGraphCheck(t *testing.T) {
+	assert.NoError(t, testGraph().Check())
+
+	g := NewGraph([]*Node{
+		{Kind: KindLibrary, Name: "a", Dependencies: []string{"b"}},
+		{Kind: KindLibrary, Name: "b", Dependencies: []string{"a"}},
+		{Kind: KindService, Name: "svc", Dependencies: []string{"missing"}},
+	})
+	assert.Equal(t, [][]string{{"a", "b", "a"}}, g.Cycles())
+	require.Len(t, g.Unknown(), 1)
+	assert.Equal(t, "service svc depends on unknown library 'missing'", g.Unknown()[0].String())
+
+	err := g.Check()
+	require.Error(t, err)
+	assert.Equal(t, "dependency cycle: a -> b -> a\nservice svc depends on unknown library 'missing'", err.Error())
+}
+
+func TestGraphCheckCycles(t *testing.T) {
+	g := NewGraph([]*Node{
+		{Kind: KindLibrary, Name: "a", Dependencies: []string{"b"}},
+		{Kind: KindLibrary, Name: "b", Dependencies: []string{"a"}},
+		{Kind: KindLibrary, Name: "c", Dependencies: []string{"global"}},
+	})
+	assert.EqualError(t, g.CheckCycles(), "dependency cycle: a -> b -> a")
+	assert.EqualError(t, g.CheckCycles("b", "c"), "dependency cycle: a -> b -> a")
+	// unrelated cycles and unknown libraries aren't errors
+	assert.NoError(t, g.CheckCycles("c"))
+}
+
+func TestGraphSelfCycle(t *testing.T) {
+	g := NewGraph([]*Node{{Kind: KindLibrary, Name: "a", Dependencies: []string{"a"}}})
+	assert.Equal(t, [][]string{{"a", "a"}}, g.Cycles())
+}
+
+func TestGraphDependencies(t *testing.T) {
+	g := testGraph()
+	a, ok := g.Library("a")
+	require.True(t, ok)
+
+	deps, err := g.Dependencies(a)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "b"}, deps)
+
+	svc, ok := g.Service("svc1")
+	require.True(t, ok)
+	deps, err = g.Dependencies(svc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "b", "a"}, deps)
+
+	g = NewGraph([]*Node{
+		{Kind: KindLibrary, Name: "a", Dependencies: []string{"b"}},
+		{Kind: KindLibrary, Name: "b", Dependencies: []string{"a"}},
+		{Kind: KindLibrary, Name: "c", Dependencies: []string{"missing"}},
+	})
+	a, _ = g.Library("a")
+	_, err = g.Dependencies(a)
+	assert.Error(t, err)
+
+	c, _ := g.Library("c")
+	deps, err = g.Dependencies(c)
+	require.NoError(t, err)
+	assert.Empty(t, deps)
+}
+
+func TestGraphDependents(t *testing.T) {
+	g := testGraph()
+
+	libraries, services := g.Dependents("c")
+	assert.Equal(t, []string{"b", "a"}, libraries)
+	assert.Equal(t, []string{"svc1", "svc2"}, services)
+
+	libraries, services = g.Dependents("d")
+	assert.Empty(t, libraries)
+	assert.Equal(t, []string{"svc3"}, services)
+
+	libraries, services = g.Dependents("svc1")
+	assert.Empty(t, libraries)
+	assert.Empty(t, services)
+}
+
+func TestGraphWriteDOT(t *testing.T) {
+	g := NewGraph([]*Node{
+		{Kind: KindLibrary, Name: "a", Dependencies: []string{}},
+		{Kind: KindService, Name: "svc", Dependencies: []string{"a", "missing"}},
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, g.WriteDOT(&buf))
+	assert.Equal(t, `digraph dependencies {
+	rankdir=LR;
+	"library:a" [shape=box];
+	"service:svc" [shape=ellipse];
+	"library:missing" [shape=box, style=dashed];
+	"service:svc" -> "library:a";
+	"service:svc" -> "library:missing";
+}
+`, buf.String())
+}
+
+func TestNodeFromMap(t *testing.T) {
+	node, err := NodeFromMap(KindLibrary, map[string]interface{}{"name": "a", "dependencies": " b, ,c,"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, node.Dependencies)
+
+	node, err = NodeFromMap(KindService, map[string]interface{}{"name": "svc"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, node.Dependencies)
+
+	_, err = NodeFromMap(KindLibrary, map[string]interface{}{"name": "a", "dependencies": []interface{}{"b"}})
+	assert.Error(t, err)
+
+	_, err = NodeFromMap(KindLibrary, map[string]interface{}{"dependencies": "b"})
+	assert.Error(t, err)
+
+	_, err = NewLibraryFromMap(map[string]interface{}{"name": "a", "dependencies": 3})
+	assert.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package libraries
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -10,13 +11,41 @@ type Library struct {
 	dependencies []string
 }
 
-func NewLibraryFromMap(rawLibrary map[string]interface{}) Library {
-	dependencies := strings.Split(rawLibrary["dependencies"].(string), ",")
+func NewLibraryFromMap(rawLibrary map[string]interface{}) (Library, error) {
+	node, err := NodeFromMap(KindLibrary, rawLibrary)
+	if err != nil {
+		return Library{}, err
+	}
 	return Library{
 		rawLibrary:   rawLibrary,
-		name:         rawLibrary["name"].(string),
-		dependencies: dependencies,
+		name:         node.Name,
+		dependencies: node.Dependencies,
+	}, nil
+}
+
+// NodeFromMap returns the dependency graph node of a raw library or service.
+// The dependencies field is a comma separated list of library names.
+func NodeFromMap(kind string, raw map[string]interface{}) (*Node, error) {
+	name, ok := raw["name"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("%s is missing a name", kind)
+	}
+
+	node := &Node{Kind: kind, Name: name, Dependencies: []string{}}
+	switch dependencies := raw["dependencies"].(type) {
+	case nil:
+	case string:
+		for _, dep := range strings.Split(dependencies, ",") {
+			dep = strings.TrimSpace(dep)
+			if dep != "" {
+				node.Dependencies = append(node.Dependencies, dep)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%s '%s': dependencies must be a comma separated string, got %T", kind, name, dependencies)
 	}
+
+	return node, nil
 }
 
 func (l *Library) GetName() string {
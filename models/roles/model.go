@@ -0,0 +1,388 @@
+package roles
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/clearblade/cblib/maputil"
+)
+
+// Level is the permission bitmask used by the platform.
+type Level int
+
+const (
+	Read   Level = 1
+	Create Level = 2
+	Update Level = 4
+	Delete Level = 8
+
+	allLevels = Read | Create | Update | Delete
+)
+
+var verbLevels = []struct {
+	verb  string
+	level Level
+}{
+	{"read", Read},
+	{"create", Create},
+	{"update", Update},
+	{"delete", Delete},
+}
+
+// Verbs returns the verbs granted by the level, in read, create, update,
+// delete order.
+func (l Level) Verbs() ([]string, error) {
+	if l&^allLevels != 0 {
+		return nil, fmt.Errorf("permission level %d has unknown bits", int(l))
+	}
+
+	verbs := make([]string, 0, len(verbLevels))
+	for _, v := range verbLevels {
+		if l&v.level != 0 {
+			verbs = append(verbs, v.verb)
+		}
+	}
+	return verbs, nil
+}
+
+// Has returns true if every bit of other is granted by the level.
+func (l Level) Has(other Level) bool {
+	return l&other == other
+}
+
+// LevelFromVerbs is the inverse of Level.Verbs.
+func LevelFromVerbs(verbs []string) (Level, error) {
+	var level Level
+	for _, verb := range verbs {
+		found := false
+		for _, v := range verbLevels {
+			if v.verb == verb {
+				level |= v.level
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown permission verb '%s', expected one of read, create, update, delete", verb)
+		}
+	}
+	return level, nil
+}
+
+func levelFromInterface(v interface{}) (Level, error) {
+	switch n := v.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return Level(n), nil
+	case int64:
+		return Level(n), nil
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, fmt.Errorf("permission level %v is not an integer", n)
+		}
+		return Level(n), nil
+	case json.Number:
+		i, err := n.Int64()
+		if err != nil {
+			return 0, fmt.Errorf("permission level %v is not an integer", n)
+		}
+		return Level(i), nil
+	default:
+		return 0, fmt.Errorf("permission level has unexpected type %T", v)
+	}
+}
+
+// Kind describes one kind of permission and the key it uses in each of the
+// role shapes.
+type Kind struct {
+	// Readable is the key used by the readable role file format.
+	Readable string
+	// Get is the key used in the Permissions of a role as returned by the platform.
+	Get string
+	// Update is the key used by the platform when updating a role.
+	Update string
+	// PerAsset is true when the permission is granted per named asset (a
+	// service, a collection, ...) rather than to the whole kind.
+	PerAsset bool
+}
+
+// Kinds lists every permission kind understood by the CLI.
+var Kinds = []Kind{
+	{Readable: "services", Get: "CodeServices", Update: "services", PerAsset: true},
+	{Readable: "collections", Get: "Collections", Update: "collections", PerAsset: true},
+	{Readable: "portals", Get: "Portals", Update: "portals", PerAsset: true},
+	{Readable: "topics", Get: "Topics", Update: "topics", PerAsset: true},
+	{Readable: "edge_remote_admin", Get: "EdgeRemoteAdmin", Update: "edgeremoteadmin", PerAsset: true},
+	{Readable: "external_databases", Get: "ExternalDatabases", Update: "externaldatabases", PerAsset: true},
+	{Readable: "service_caches", Get: "ServiceCaches", Update: "servicecaches", PerAsset: true},
+	{Readable: "files", Get: "Files", Update: "files", PerAsset: true},
+	{Readable: "file_store_files", Get: "FileStoreFiles", Update: "filestorefiles", PerAsset: true},
+	{Readable: "devices", Get: "DevicesList", Update: "devices"},
+	{Readable: "message_history", Get: "MsgHistory", Update: "msgHistory"},
+	{Readable: "system_services", Get: "SystemServices", Update: "system_services"},
+	{Readable: "push", Get: "Push", Update: "push"},
+	{Readable: "users", Get: "UsersList", Update: "users"},
+	{Readable: "edges", Get: "EdgesList", Update: "edges"},
+	{Readable: "triggers", Get: "Triggers", Update: "triggers"},
+	{Readable: "timers", Get: "Timers", Update: "timers"},
+	{Readable: "deployments", Get: "Deployments", Update: "deployments"},
+	{Readable: "roles", Get: "Roles", Update: "roles"},
+	{Readable: "all_collections", Get: "AllCollections", Update: "allcollections"},
+	{Readable: "all_services", Get: "AllServices", Update: "allservices"},
+	{Readable: "manage_users", Get: "ManageUsers", Update: "manageusers"},
+	{Readable: "all_external_databases", Get: "AllExternalDatabases", Update: "allexternaldatabases"},
+	{Readable: "file_stores", Get: "Filestores", Update: "filestores"},
+	{Readable: "user_secrets", Get: "usersecrets", Update: "usersecrets"},
+	{Readable: "adapters", Get: "adapters", Update: "adapters"},
+}
+
+// FindKind returns the kind with the given readable key.
+func FindKind(readable string) (Kind, bool) {
+	for _, k := range Kinds {
+		if k.Readable == readable {
+			return k, true
+		}
+	}
+	return Kind{}, false
+}
+
+// AssetPermission is the level granted on a single named asset.
+type AssetPermission struct {
+	Name  string
+	Level Level
+}
+
+// Permissions are the permissions of a role, keyed by the readable key of
+// their kind. Kinds that are not present are not granted at all.
+type Permissions struct {
+	Assets map[string][]AssetPermission
+	Kinds  map[string]Level
+}
+
+// Role is the typed model of a role.
+type Role struct {
+	ID          string
+	Name        string
+	Description string
+	Permissions Permissions
+}
+
+func newPermissions() Permissions {
+	return Permissions{
+		Assets: map[string][]AssetPermission{},
+		Kinds:  map[string]Level{},
+	}
+}
+
+// Level returns the level granted on the given asset of a per-asset kind.
+func (p Permissions) Level(kind, name string) Level {
+	for _, a := range p.Assets[kind] {
+		if a.Name == name {
+			return a.Level
+		}
+	}
+	return 0
+}
+
+// PermissionsFromGetShape parses the Permissions of a role as returned by the
+// platform. Unknown keys are ignored and duplicate assets keep their first
+// level, matching what the platform accepts on update.
+func PermissionsFromGetShape(in map[string]interface{}) (Permissions, error) {
+	out := newPermissions()
+	for _, kind := range Kinds {
+		valIF, ok := in[kind.Get]
+		if !ok || valIF == nil {
+			continue
+		}
+
+		if kind.PerAsset {
+			assets, err := assetsFromGetShape(kind, valIF)
+			if err != nil {
+				return Permissions{}, err
+			}
+			out.Assets[kind.Readable] = assets
+			continue
+		}
+
+		val, ok := valIF.(map[string]interface{})
+		if !ok {
+			return Permissions{}, fmt.Errorf("bad format for %s permissions, not a map: %T", kind.Get, valIF)
+		}
+		level, err := levelFromInterface(val["Level"])
+		if err != nil {
+			return Permissions{}, fmt.Errorf("bad level for %s permissions: %s", kind.Get, err)
+		}
+		out.Kinds[kind.Readable] = level
+	}
+	return out, nil
+}
+
+func assetsFromGetShape(kind Kind, valIF interface{}) ([]AssetPermission, error) {
+	list, err := maputil.GetASliceOfMaps(valIF)
+	if err != nil {
+		return nil, fmt.Errorf("bad format for %s permissions, not a slice of maps: %T", kind.Get, valIF)
+	}
+
+	assets := make([]AssetPermission, 0, len(list))
+	found := map[string]bool{}
+	for _, item := range list {
+		name, ok := item["Name"].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s permission is missing a name", kind.Get)
+		}
+		level, err := levelFromInterface(item["Level"])
+		if err != nil {
+			return nil, fmt.Errorf("bad level for %s permission '%s': %s", kind.Get, name, err)
+		}
+		if found[name] {
+			continue
+		}
+		found[name] = true
+		assets = append(assets, AssetPermission{Name: name, Level: level})
+	}
+	return assets, nil
+}
+
+// GetShape returns the permissions in the shape returned by the platform.
+func (p Permissions) GetShape() map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, kind := range Kinds {
+		if kind.PerAsset {
+			assets, ok := p.Assets[kind.Readable]
+			if !ok {
+				continue
+			}
+			list := make([]interface{}, len(assets))
+			for i, a := range assets {
+				list[i] = map[string]interface{}{"Name": a.Name, "Level": int(a.Level)}
+			}
+			out[kind.Get] = list
+			continue
+		}
+
+		if level, ok := p.Kinds[kind.Readable]; ok {
+			out[kind.Get] = map[string]interface{}{"Level": int(level)}
+		}
+	}
+	return out
+}
+
+// PermissionsFromUpdateShape parses permissions in the shape accepted by the
+// platform when updating a role.
+func PermissionsFromUpdateShape(in map[string]interface{}) (Permissions, error) {
+	out := newPermissions()
+	for _, kind := range Kinds {
+		valIF, ok := in[kind.Update]
+		if !ok || valIF == nil {
+			continue
+		}
+
+		if kind.PerAsset {
+			list, err := maputil.GetASliceOfMaps(valIF)
+			if err != nil {
+				return Permissions{}, fmt.Errorf("bad format for %s permissions, not a slice of maps: %T", kind.Update, valIF)
+			}
+
+			assets := make([]AssetPermission, 0, len(list))
+			for _, item := range list {
+				info, _ := item["itemInfo"].(map[string]interface{})
+				name, ok := info["name"].(string)
+				if !ok {
+					return Permissions{}, fmt.Errorf("%s permission is missing a name", kind.Update)
+				}
+				level, err := levelFromInterface(item["permissions"])
+				if err != nil {
+					return Permissions{}, fmt.Errorf("bad level for %s permission '%s': %s", kind.Update, name, err)
+				}
+				assets = append(assets, AssetPermission{Name: name, Level: level})
+			}
+			out.Assets[kind.Readable] = assets
+			continue
+		}
+
+		val, ok := valIF.(map[string]interface{})
+		if !ok {
+			return Permissions{}, fmt.Errorf("bad format for %s permissions, not a map: %T", kind.Update, valIF)
+		}
+		level, err := levelFromInterface(val["permissions"])
+		if err != nil {
+			return Permissions{}, fmt.Errorf("bad level for %s permissions: %s", kind.Update, err)
+		}
+		out.Kinds[kind.Readable] = level
+	}
+	return out, nil
+}
+
+// UpdateShape returns the permissions in the shape accepted by the platform
+// when updating a role. Collections are referenced by id, which is looked up
+// with the given fetcher.
+func (p Permissions) UpdateShape(fetcher CollectionIdFetcher) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for _, kind := range Kinds {
+		if !kind.PerAsset {
+			if level, ok := p.Kinds[kind.Readable]; ok {
+				out[kind.Update] = map[string]interface{}{"permissions": int(level)}
+			}
+			continue
+		}
+
+		assets, ok := p.Assets[kind.Readable]
+		if !ok {
+			continue
+		}
+
+		list := make([]map[string]interface{}, 0, len(assets))
+		for _, a := range assets {
+			itemInfo := map[string]interface{}{"name": a.Name}
+			if kind.Readable == "collections" {
+				id, err := fetcher.GetCollectionIdByName(a.Name)
+				if err != nil {
+					return nil, fmt.Errorf("could not get id for collection named %q: %s", a.Name, err)
+				}
+				itemInfo["id"] = id
+			}
+			list = append(list, map[string]interface{}{
+				"itemInfo":    itemInfo,
+				"permissions": int(a.Level),
+			})
+		}
+		out[kind.Update] = list
+	}
+	return out, nil
+}
+
+// RoleFromGetShape parses a role as returned by the platform (or stored on
+// disk by pull).
+func RoleFromGetShape(in map[string]interface{}) (*Role, error) {
+	role := &Role{}
+	role.ID, _ = in["ID"].(string)
+	role.Name, _ = in["Name"].(string)
+	role.Description, _ = in["Description"].(string)
+
+	permissions, ok := in["Permissions"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("permissions for role do not exist or is not a map")
+	}
+
+	var err error
+	role.Permissions, err = PermissionsFromGetShape(permissions)
+	if err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// GetShape returns the role in the shape returned by the platform.
+func (r *Role) GetShape() map[string]interface{} {
+	out := map[string]interface{}{
+		"Name":        r.Name,
+		"Description": r.Description,
+		"Permissions": r.Permissions.GetShape(),
+	}
+	if r.ID != "" {
+		out["ID"] = r.ID
+	}
+	return out
+}
@@ -0,0 +1,251 @@
+package roles
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFetcher map[string]string
+
+func (f fakeFetcher) GetCollectionIdByName(name string) (string, error) {
+	if id, ok := f[name]; ok {
+		return id, nil
+	}
+	return "", fmt.Errorf("no collection named %s", name)
+}
+
+// throughJSON marshals and unmarshals v, like writing it to disk and reading
+// it back does.
+func throughJSON(t *testing.T, v interface{}) map[string]interface{} {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	out := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(data, &out))
+	return out
+}
+
+func getShapeForKind(kind Kind) map[string]interface{} {
+	if kind.PerAsset {
+		return map[string]interface{}{
+			kind.Get: []interface{}{
+				map[string]interface{}{"Name": "a", "Level": 1.0},
+				map[string]interface{}{"Name": "b", "Level": 15.0},
+				map[string]interface{}{"Name": "c", "Level": 0.0},
+			},
+		}
+	}
+	return map[string]interface{}{
+		kind.Get: map[string]interface{}{"Level": 6.0},
+	}
+}
+
+func TestLevelVerbs(t *testing.T) {
+	for level := Level(0); level <= allLevels; level++ {
+		verbs, err := level.Verbs()
+		require.NoError(t, err)
+
+		back, err := LevelFromVerbs(verbs)
+		require.NoError(t, err)
+		assert.Equal(t, level, back)
+	}
+
+	verbs, err := (Read | Delete).Verbs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"read", "delete"}, verbs)
+
+	_, err = Level(16).Verbs()
+	assert.Error(t, err)
+
+	_, err = LevelFromVerbs([]string{"execute"})
+	assert.Error(t, err)
+
+	assert.True(t, allLevels.Has(Update|Delete))
+	assert.False(t, Read.Has(Update))
+}
+
+func TestKindsAreUnique(t *testing.T) {
+	readable := map[string]bool{}
+	get := map[string]bool{}
+	update := map[string]bool{}
+	for _, kind := range Kinds {
+		assert.False(t, readable[kind.Readable], kind.Readable)
+		assert.False(t, get[kind.Get], kind.Get)
+		assert.False(t, update[kind.Update], kind.Update)
+		readable[kind.Readable] = true
+		get[kind.Get] = true
+		update[kind.Update] = true
+	}
+}
+
+func TestGetShapeRoundTripEveryKind(t *testing.T) {
+	for _, kind := range Kinds {
+		t.Run(kind.Readable, func(t *testing.T) {
+			in := map[string]interface{}{
+				"ID":          "abc",
+				"Name":        "role",
+				"Description": "desc",
+				"Permissions": getShapeForKind(kind),
+			}
+
+			role, err := RoleFromGetShape(in)
+			require.NoError(t, err)
+			assert.Equal(t, in, throughJSON(t, role.GetShape()))
+		})
+	}
+}
+
+func TestReadableRoundTripEveryKind(t *testing.T) {
+	for _, kind := range Kinds {
+		t.Run(kind.Readable, func(t *testing.T) {
+			in := map[string]interface{}{
+				"Name":        "role",
+				"Description": "desc",
+				"Permissions": getShapeForKind(kind),
+			}
+
+			role, err := RoleFromGetShape(in)
+			require.NoError(t, err)
+
+			readable, err := role.Readable()
+			require.NoError(t, err)
+			onDisk := throughJSON(t, readable)
+			assert.True(t, IsReadableShape(onDisk))
+
+			if kind.PerAsset {
+				assert.Equal(t, map[string]interface{}{
+					"a": []interface{}{"read"},
+					"b": []interface{}{"read", "create", "update", "delete"},
+					"c": []interface{}{},
+				}, onDisk["permissions"].(map[string]interface{})[kind.Readable])
+			} else {
+				assert.Equal(t, []interface{}{"create", "update"}, onDisk["permissions"].(map[string]interface{})[kind.Readable])
+			}
+
+			back, err := ReadableToGetShape(onDisk)
+			require.NoError(t, err)
+			assert.Equal(t, in, throughJSON(t, back))
+		})
+	}
+}
+
+func TestUpdateShapeRoundTripEveryKind(t *testing.T) {
+	fetcher := fakeFetcher{"a": "id-a", "b": "id-b", "c": "id-c"}
+	for _, kind := range Kinds {
+		t.Run(kind.Readable, func(t *testing.T) {
+			permissions, err := PermissionsFromGetShape(getShapeForKind(kind))
+			require.NoError(t, err)
+
+			update, err := permissions.UpdateShape(fetcher)
+			require.NoError(t, err)
+			update = throughJSON(t, update)
+
+			if kind.PerAsset {
+				itemInfo := func(name string) map[string]interface{} {
+					if kind.Readable == "collections" {
+						return map[string]interface{}{"name": name, "id": "id-" + name}
+					}
+					return map[string]interface{}{"name": name}
+				}
+				assert.Equal(t, map[string]interface{}{
+					kind.Update: []interface{}{
+						map[string]interface{}{"itemInfo": itemInfo("a"), "permissions": 1.0},
+						map[string]interface{}{"itemInfo": itemInfo("b"), "permissions": 15.0},
+						map[string]interface{}{"itemInfo": itemInfo("c"), "permissions": 0.0},
+					},
+				}, update)
+			} else {
+				assert.Equal(t, map[string]interface{}{
+					kind.Update: map[string]interface{}{"permissions": 6.0},
+				}, update)
+			}
+
+			back, err := PermissionsFromUpdateShape(update)
+			require.NoError(t, err)
+			assert.Equal(t, permissions, back)
+		})
+	}
+}
+
+func TestConvertPermissionsStructure(t *testing.T) {
+	in := map[string]interface{}{
+		"CodeServices": []interface{}{
+			map[string]interface{}{"Name": "svc", "Level": 1.0},
+			map[string]interface{}{"Name": "svc", "Level": 15.0},
+		},
+		"Collections": []interface{}{
+			map[string]interface{}{"Name": "col", "Level": 3.0},
+		},
+		"DevicesList": map[string]interface{}{"Level": 1.0},
+		"Topics":      nil,
+		"Unknown":     map[string]interface{}{"Level": 1.0},
+	}
+
+	out, err := ConvertPermissionsStructure(in, fakeFetcher{"col": "col-id"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"services": []interface{}{
+			map[string]interface{}{"itemInfo": map[string]interface{}{"name": "svc"}, "permissions": 1.0},
+		},
+		"collections": []interface{}{
+			map[string]interface{}{"itemInfo": map[string]interface{}{"name": "col", "id": "col-id"}, "permissions": 3.0},
+		},
+		"devices": map[string]interface{}{"permissions": 1.0},
+	}, throughJSON(t, out))
+
+	_, err = ConvertPermissionsStructure(in, fakeFetcher{})
+	assert.Error(t, err)
+}
+
+func TestRoleFromReadableSortsAssets(t *testing.T) {
+	role, err := RoleFromReadable(map[string]interface{}{
+		"name": "role",
+		"permissions": map[string]interface{}{
+			"services": map[string]interface{}{
+				"zeta":  []interface{}{"read"},
+				"alpha": []interface{}{"read"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []AssetPermission{{Name: "alpha", Level: Read}, {Name: "zeta", Level: Read}}, role.Permissions.Assets["services"])
+	assert.Equal(t, Read, role.Permissions.Level("services", "zeta"))
+	assert.Equal(t, Level(0), role.Permissions.Level("services", "missing"))
+}
+
+func TestRoleFromReadableErrors(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"missing name": {"permissions": map[string]interface{}{}},
+		"unknown kind": {"name": "r", "permissions": map[string]interface{}{"widgets": []interface{}{"read"}}},
+		"unknown verb": {"name": "r", "permissions": map[string]interface{}{"devices": []interface{}{"execute"}}},
+		"not a map":    {"name": "r", "permissions": map[string]interface{}{"services": []interface{}{"read"}}},
+		"not verbs":    {"name": "r", "permissions": map[string]interface{}{"devices": "read"}},
+	}
+
+	for name, in := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := RoleFromReadable(in)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRoleFromGetShapeErrors(t *testing.T) {
+	_, err := RoleFromGetShape(map[string]interface{}{"Name": "r"})
+	assert.Error(t, err)
+
+	_, err = RoleFromGetShape(map[string]interface{}{
+		"Name":        "r",
+		"Permissions": map[string]interface{}{"CodeServices": map[string]interface{}{}},
+	})
+	assert.Error(t, err)
+
+	_, err = RoleFromGetShape(map[string]interface{}{
+		"Name":        "r",
+		"Permissions": map[string]interface{}{"DevicesList": map[string]interface{}{"Level": "all"}},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,169 @@
+package roles
+
+import (
+	"fmt"
+	"sort"
+)
+
+// The readable role format lists, for each kind of permission, the verbs
+// granted by the role. Per-asset kinds map the name of each asset to its verbs:
+//
+//	{
+//	    "name": "Technician",
+//	    "description": "Field technicians",
+//	    "permissions": {
+//	        "collections": {"work_orders": ["read", "update"]},
+//	        "services": {"closeWorkOrder": ["read"]},
+//	        "devices": ["read"]
+//	    }
+//	}
+//
+// Kinds that are absent are not granted. Assets are sorted by name when the
+// file is read so that it diffs cleanly however the platform orders them.
+
+// IsReadableShape returns true if the role map uses the readable format
+// rather than the platform's.
+func IsReadableShape(in map[string]interface{}) bool {
+	_, hasRaw := in["Permissions"]
+	_, hasReadable := in["permissions"]
+	return hasReadable && !hasRaw
+}
+
+// Readable returns the role in the readable format. The role id is not part
+// of the format since it differs between systems.
+func (r *Role) Readable() (map[string]interface{}, error) {
+	permissions := map[string]interface{}{}
+	for _, kind := range Kinds {
+		if kind.PerAsset {
+			assets, ok := r.Permissions.Assets[kind.Readable]
+			if !ok {
+				continue
+			}
+			named := map[string]interface{}{}
+			for _, a := range assets {
+				verbs, err := a.Level.Verbs()
+				if err != nil {
+					return nil, fmt.Errorf("%s '%s': %s", kind.Readable, a.Name, err)
+				}
+				named[a.Name] = verbs
+			}
+			permissions[kind.Readable] = named
+			continue
+		}
+
+		if level, ok := r.Permissions.Kinds[kind.Readable]; ok {
+			verbs, err := level.Verbs()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", kind.Readable, err)
+			}
+			permissions[kind.Readable] = verbs
+		}
+	}
+
+	out := map[string]interface{}{
+		"name":        r.Name,
+		"permissions": permissions,
+	}
+	if r.Description != "" {
+		out["description"] = r.Description
+	}
+	return out, nil
+}
+
+// RoleFromReadable parses a role in the readable format.
+func RoleFromReadable(in map[string]interface{}) (*Role, error) {
+	role := &Role{Permissions: newPermissions()}
+
+	var ok bool
+	role.Name, ok = in["name"].(string)
+	if !ok || role.Name == "" {
+		return nil, fmt.Errorf("role is missing a name")
+	}
+
+	if desc, found := in["description"]; found && desc != nil {
+		role.Description, ok = desc.(string)
+		if !ok {
+			return nil, fmt.Errorf("role '%s': description is not a string", role.Name)
+		}
+	}
+
+	permissionsIF, found := in["permissions"]
+	if !found || permissionsIF == nil {
+		return role, nil
+	}
+
+	permissions, ok := permissionsIF.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("role '%s': permissions is not a map", role.Name)
+	}
+
+	for key, valIF := range permissions {
+		kind, ok := FindKind(key)
+		if !ok {
+			return nil, fmt.Errorf("role '%s': unknown permission kind '%s'", role.Name, key)
+		}
+
+		if !kind.PerAsset {
+			level, err := levelFromVerbsInterface(valIF)
+			if err != nil {
+				return nil, fmt.Errorf("role '%s': %s: %s", role.Name, key, err)
+			}
+			role.Permissions.Kinds[key] = level
+			continue
+		}
+
+		named, ok := valIF.(map[string]interface{})
+		if !ok && valIF != nil {
+			return nil, fmt.Errorf("role '%s': %s must map asset names to verbs", role.Name, key)
+		}
+
+		names := make([]string, 0, len(named))
+		for name := range named {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		assets := make([]AssetPermission, 0, len(names))
+		for _, name := range names {
+			level, err := levelFromVerbsInterface(named[name])
+			if err != nil {
+				return nil, fmt.Errorf("role '%s': %s '%s': %s", role.Name, key, name, err)
+			}
+			assets = append(assets, AssetPermission{Name: name, Level: level})
+		}
+		role.Permissions.Assets[key] = assets
+	}
+
+	return role, nil
+}
+
+func levelFromVerbsInterface(v interface{}) (Level, error) {
+	switch verbs := v.(type) {
+	case nil:
+		return 0, nil
+	case []string:
+		return LevelFromVerbs(verbs)
+	case []interface{}:
+		strs := make([]string, len(verbs))
+		for i, verb := range verbs {
+			s, ok := verb.(string)
+			if !ok {
+				return 0, fmt.Errorf("verbs must be strings, got %T", verb)
+			}
+			strs[i] = s
+		}
+		return LevelFromVerbs(strs)
+	default:
+		return 0, fmt.Errorf("expected a list of verbs, got %T", v)
+	}
+}
+
+// ReadableToGetShape converts a role in the readable format to the shape
+// returned by the platform, which the rest of the CLI works with.
+func ReadableToGetShape(in map[string]interface{}) (map[string]interface{}, error) {
+	role, err := RoleFromReadable(in)
+	if err != nil {
+		return nil, err
+	}
+	return role.GetShape(), nil
+}
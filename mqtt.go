@@ -0,0 +1,282 @@
+package cblib
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cblib/internal/mqtttool"
+	mqttTypes "github.com/clearblade/mqtt_parsing"
+)
+
+var (
+	mqttTopic       string
+	mqttQoS         int
+	mqttBroker      string
+	mqttOutput      string
+	mqttCount       int
+	mqttPayload     string
+	mqttPayloadFile string
+	mqttRetain      bool
+	mqttRepeat      int
+	mqttRate        float64
+	mqttFile        string
+	mqttSpeed       float64
+)
+
+func init() {
+
+	usage :=
+		`
+	Work with the MQTT broker of the system.
+	'mqtt sub' subscribes to a topic, wildcards included, and prints the messages received;
+	with -output it also records them to a file of newline delimited JSON.
+	'mqtt pub' publishes a message, given inline or read from a file, optionally repeated at a given rate.
+	'mqtt replay' publishes the messages of a recording with their original timing.
+	Use -broker to talk to another broker than the system's, e.g. a local one.
+	`
+
+	example :=
+		`
+	cb-cli mqtt sub -topic=devices/+/state					# Print the state messages of every device
+	cb-cli mqtt sub -topic=devices/# -output=devices.ndjson		# Also record the messages to devices.ndjson
+	cb-cli mqtt sub -topic=alerts -count=1					# Wait for one message on alerts
+	cb-cli mqtt pub -topic=devices/a/state -payload=on -qos=1 -retain	# Publish a retained message
+	cb-cli mqtt pub -topic=devices/a/reading -payload-file=reading.json -repeat=100 -rate=10	# Publish a file 100 times, 10 per second
+	cb-cli mqtt replay -file=devices.ndjson					# Replay a recording with its original timing
+	cb-cli mqtt replay -file=devices.ndjson -speed=10 -topic=devices/a/#	# Replay the messages of device a ten times faster
+	cb-cli mqtt pub -broker=localhost:1883 -topic=test -payload=hello	# Publish to a local broker
+	`
+
+	mqttCommand := &SubCommand{
+		name:      "mqtt",
+		usage:     usage,
+		needsAuth: true,
+		run:       doMQTT,
+		example:   example,
+	}
+
+	mqttCommand.flags.StringVar(&mqttTopic, "topic", "", "Topic to publish to or subscribe to. Subscriptions may use the + and # wildcards; replay only publishes the messages matching it")
+	mqttCommand.flags.IntVar(&mqttQoS, "qos", 0, "Quality of service: 0, 1 or 2")
+	mqttCommand.flags.StringVar(&mqttBroker, "broker", "", "host:port of the MQTT broker. Defaults to the messaging URL of the system")
+	mqttCommand.flags.StringVar(&mqttOutput, "output", "", "sub: file to record the messages to, as newline delimited JSON")
+	mqttCommand.flags.IntVar(&mqttCount, "count", 0, "sub: stop after receiving this many messages. 0 means until interrupted")
+	mqttCommand.flags.StringVar(&mqttPayload, "payload", "", "pub: payload of the message")
+	mqttCommand.flags.StringVar(&mqttPayloadFile, "payload-file", "", "pub: file holding the payload of the message")
+	mqttCommand.flags.BoolVar(&mqttRetain, "retain", false, "pub: ask the broker to retain the message")
+	mqttCommand.flags.IntVar(&mqttRepeat, "repeat", 1, "pub: number of times to publish the message. 0 means until interrupted")
+	mqttCommand.flags.Float64Var(&mqttRate, "rate", 0, "pub: messages per second when repeating. 0 means as fast as possible")
+	mqttCommand.flags.StringVar(&mqttFile, "file", "", "replay: recording to replay, as written by 'mqtt sub -output'")
+	mqttCommand.flags.Float64Var(&mqttSpeed, "speed", 1, "replay: speed relative to the original timing, e.g. 2 for twice as fast. 0 means no delays")
+
+	AddCommand("mqtt", mqttCommand)
+}
+
+func doMQTT(cmd *SubCommand, client *cb.DevClient, args ...string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: cb-cli mqtt sub|pub|replay [options]")
+	}
+	action := args[0]
+
+	// flags given after the action
+	err := cmd.flags.Parse(args[1:])
+	if err != nil {
+		return err
+	}
+	if cmd.flags.NArg() != 0 {
+		return fmt.Errorf("mqtt %s takes no arguments; only options", action)
+	}
+	if err := mqtttool.CheckQoS(mqttQoS); err != nil {
+		return err
+	}
+
+	SetRootDir(".")
+	systemInfo, err := getSysMeta()
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "sub":
+		return doMQTTSubscribe(systemInfo.Key, client)
+	case "pub":
+		return doMQTTPublish(systemInfo.Key, client)
+	case "replay":
+		return doMQTTReplay(systemInfo.Key, client)
+	default:
+		return fmt.Errorf("unknown mqtt action '%s'; must be one of sub, pub or replay", action)
+	}
+}
+
+func doMQTTSubscribe(systemKey string, client *cb.DevClient) error {
+	if err := mqtttool.CheckTopic(mqttTopic, true); err != nil {
+		return err
+	}
+
+	var recorder *mqtttool.Recorder
+	if mqttOutput != "" {
+		file, err := os.Create(mqttOutput)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		recorder = mqtttool.NewRecorder(file)
+	}
+
+	mqttClient, err := newMQTTClient(systemKey, client)
+	if err != nil {
+		return err
+	}
+	messages, err := mqttClient.Subscribe(mqttTopic, mqttQoS)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Subscribed to '%s'. Press Ctrl-C to stop\n", mqttTopic)
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	defer signal.Stop(interrupted)
+
+	received := 0
+	for mqttCount == 0 || received < mqttCount {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return fmt.Errorf("connection to the broker closed after %d messages", received)
+			}
+			received++
+			fmt.Printf("%s %s\n", msg.Topic, mqtttool.FormatPayload(msg.Payload))
+			if recorder != nil {
+				if err := recorder.Write(msg, time.Now()); err != nil {
+					return err
+				}
+			}
+		case <-interrupted:
+			fmt.Fprintf(os.Stderr, "Received %d messages\n", received)
+			return nil
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Received %d messages\n", received)
+	return nil
+}
+
+func doMQTTPublish(systemKey string, client *cb.DevClient) error {
+	if err := mqtttool.CheckTopic(mqttTopic, false); err != nil {
+		return err
+	}
+	if mqttPayload != "" && mqttPayloadFile != "" {
+		return fmt.Errorf("-payload and -payload-file are mutually exclusive")
+	}
+	if mqttRepeat < 0 || mqttRate < 0 {
+		return fmt.Errorf("-repeat and -rate can't be negative")
+	}
+
+	payload := []byte(mqttPayload)
+	if mqttPayloadFile != "" {
+		var err error
+		payload, err = os.ReadFile(mqttPayloadFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	mqttClient, err := newMQTTClient(systemKey, client)
+	if err != nil {
+		return err
+	}
+	msg := mqtttool.Message{Topic: mqttTopic, Payload: payload, QoS: mqttQoS, Retain: mqttRetain}
+	count, err := mqtttool.Repeat(mqttClient, msg, mqtttool.RepeatOptions{Count: mqttRepeat, Rate: mqttRate})
+	if err != nil {
+		return fmt.Errorf("failed after publishing %d messages: %s", count, err)
+	}
+	fmt.Printf("Successfully published %d message(s) on topic '%s'\n", count, mqttTopic)
+	return nil
+}
+
+func doMQTTReplay(systemKey string, client *cb.DevClient) error {
+	if mqttFile == "" {
+		return fmt.Errorf("-file is required")
+	}
+	if mqttTopic != "" {
+		if err := mqtttool.CheckTopic(mqttTopic, true); err != nil {
+			return err
+		}
+	}
+	if mqttSpeed < 0 {
+		return fmt.Errorf("-speed can't be negative")
+	}
+
+	file, err := os.Open(mqttFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	records, err := mqtttool.ReadRecording(file)
+	if err != nil {
+		return fmt.Errorf("invalid recording %s: %s", mqttFile, err)
+	}
+
+	mqttClient, err := newMQTTClient(systemKey, client)
+	if err != nil {
+		return err
+	}
+	count, err := mqtttool.Replay(mqttClient, records, mqtttool.ReplayOptions{Speed: mqttSpeed, Topic: mqttTopic})
+	if err != nil {
+		return fmt.Errorf("failed after replaying %d messages: %s", count, err)
+	}
+	fmt.Printf("Successfully replayed %d message(s) from %s\n", count, mqttFile)
+	return nil
+}
+
+// mqttClient adapts the MQTT client of the platform client to mqtttool.
+type mqttClient struct {
+	client *cb.DevClient
+}
+
+func newMQTTClient(systemKey string, client *cb.DevClient) (*mqttClient, error) {
+	if mqttBroker != "" {
+		client.MqttAddr = mqttBroker
+	}
+	if err := client.InitializeMQTT("", systemKey, 60, nil, nil); err != nil {
+		return nil, err
+	}
+	return &mqttClient{client: client}, nil
+}
+
+// Publish publishes the message. The platform client has no retain flag, so
+// retained messages are published by the MQTT client underneath it.
+func (c *mqttClient) Publish(msg mqtttool.Message) error {
+	if !msg.Retain {
+		return c.client.Publish(msg.Topic, msg.Payload, msg.QoS)
+	}
+	token := c.client.MQTTClient.Publish(msg.Topic, byte(msg.QoS), true, msg.Payload)
+	token.Wait()
+	return token.Error()
+}
+
+func (c *mqttClient) Subscribe(topic string, qos int) (<-chan mqtttool.Message, error) {
+	publishes, err := c.client.Subscribe(topic, qos)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make(chan mqtttool.Message)
+	go func() {
+		defer close(messages)
+		for publish := range publishes {
+			messages <- fromPublish(publish)
+		}
+	}()
+	return messages, nil
+}
+
+func fromPublish(publish *mqttTypes.Publish) mqtttool.Message {
+	msg := mqtttool.Message{Topic: publish.Topic.Whole, Payload: publish.Payload}
+	if publish.Header != nil {
+		msg.QoS = int(publish.Header.QOS)
+		msg.Retain = publish.Header.Retain
+	}
+	return msg
+}
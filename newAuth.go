@@ -14,6 +14,7 @@ import (
 	"github.com/bgentry/speakeasy"
 	"github.com/chromedp/chromedp"
 	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cblib/internal/browserlogin"
 	"github.com/clearblade/cblib/maputil"
 )
 
@@ -21,17 +22,23 @@ const (
 	urlPrompt          = "Platform URL"
 	msgurlPrompt       = "Messaging URL"
 	systemKeyPrompt    = "System Key"
-	browserLoginPrompt = "Login using Browser? (n|Y - Only Google Chrome supported.)"
+	browserLoginPrompt = "Login using Browser? (n|Y)"
 	emailPrompt        = "Developer Email"
 	passwordPrompt     = "Developer Password (will be hidden): "
 )
 
+const (
+	browserLoginCallback = "callback"
+	browserLoginChrome   = "chrome"
+)
+
 func initAuthFlags() {
 	flag.StringVar(&URL, "platform-url", "", "Clearblade platform url for target system")
 	flag.StringVar(&MsgURL, "messaging-url", "", "Clearblade messaging url for target system")
 	flag.StringVar(&SystemKey, "system-key", "", "System key for target system")
 	flag.StringVar(&Email, "email", "", "Developer email for login")
 	flag.StringVar(&Password, "password", "", "Developer password")
+	flag.StringVar(&BrowserLoginMethod, "browser-login", browserLoginCallback, "Browser login method: 'callback' (any browser, token is redirected to a local listener) or 'chrome' (drives Google Chrome)")
 }
 
 func getOneItem(prompt string, isASecret bool) string {
@@ -306,6 +313,39 @@ func retrieveTokenFromChromeLocalStorage(url string) (string, error) {
 	}
 }
 
+// retrieveTokenFromBrowser runs the browser login selected with the
+// -browser-login flag and returns the developer token.
+func retrieveTokenFromBrowser(url string) (string, error) {
+	switch BrowserLoginMethod {
+	case browserLoginChrome:
+		return retrieveTokenFromChromeLocalStorage(url)
+	case browserLoginCallback, "":
+		return retrieveTokenFromLoopbackCallback(url)
+	default:
+		return "", fmt.Errorf("unknown browser login method '%s'", BrowserLoginMethod)
+	}
+}
+
+// retrieveTokenFromLoopbackCallback opens the platform login page in the
+// default browser and waits for the platform to redirect the token back to a
+// listener on the loopback interface. Works with any browser; over SSH the
+// printed url can be opened on a machine that forwards the listener port.
+func retrieveTokenFromLoopbackCallback(url string) (string, error) {
+	token, err := browserlogin.Login(context.Background(), browserlogin.Options{
+		PlatformURL: url,
+		Open:        browserlogin.OpenBrowser,
+		Printf: func(format string, args ...interface{}) {
+			fmt.Printf(format, args...)
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Logged into %s.\n", url)
+	return token, nil
+}
+
 func promptAndFillMissingAuth(defaults *DefaultInfo, promptSet PromptSet) {
 	// var defaultURL, defaultMsgURL, defaultEmail, defaultSystemKey string
 	var defaultURL, defaultEmail, defaultSystemKey, token string
@@ -341,7 +381,7 @@ func promptAndFillMissingAuth(defaults *DefaultInfo, promptSet PromptSet) {
 			// Browser login was never initiated, continue to prompt for system key
 		} else {
 			// Browser login was initiated
-			token, err = retrieveTokenFromChromeLocalStorage(URL)
+			token, err = retrieveTokenFromBrowser(URL)
 
 			if err != nil {
 				// Browser login failed, abort and don't prompt for system key
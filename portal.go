@@ -0,0 +1,164 @@
+package cblib
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cblib/internal/portalserve"
+	"github.com/clearblade/cblib/internal/watch"
+)
+
+var (
+	portalName  string
+	portalAddr  string
+	portalDelay time.Duration
+)
+
+func init() {
+
+	usage :=
+		`
+	Work with the decompressed source of a portal under portals/<name>/config.
+	'portal serve' compresses the portal, as push does, on every change and serves a local
+	preview of it: the compressed config, the HTML, JavaScript and CSS of its widgets and
+	their parsers. The preview reloads itself on changes, and the platform API calls of the
+	portal's datasources are proxied to the current remote.
+	'portal verify' pulls the portal, decompresses it in a temporary directory, compresses it
+	back and reports every setting lost or changed on the way.
+	The code of portals, parsers, internal resources and HTML widgets, can be formatted when
+	pulled and linted before being pushed by commands set in .cb-cli/settings.json, e.g.
+	{"portal_code": {"format": [{"command": ["npx", "prettier", "--write", "{file}"]}],
+	"lint": [{"command": ["npx", "eslint", "{file}"], "extensions": [".js"]}]}}. A push fails
+	when a lint command fails.
+	`
+
+	example :=
+		`
+	cb-cli portal serve -portal=Dashboard				# Preview the Dashboard portal at http://localhost:8090
+	cb-cli portal serve -portal=Dashboard -addr=localhost:9000	# Serve the preview on port 9000
+	cb-cli portal verify -portal=Dashboard				# Check that a pull and a push of Dashboard change nothing
+	cb-cli portal verify -portal=Dashboard -file=dashboard.json	# Check a portal exported from the platform
+	`
+
+	portalCommand := &SubCommand{
+		name:      "portal",
+		usage:     usage,
+		needsAuth: true,
+		run:       doPortal,
+		example:   example,
+	}
+
+	portalCommand.flags.StringVar(&portalName, "portal", "", "Name of the portal")
+	portalCommand.flags.StringVar(&portalAddr, "addr", "localhost:8090", "serve: address to serve the preview on")
+	portalCommand.flags.DurationVar(&portalDelay, "debounce", 300*time.Millisecond, "serve: time without changes to wait for before recompressing")
+	portalCommand.flags.StringVar(&portalVerifyFile, "file", "", "verify: check the portal in this file, as returned by the platform, rather than pulling it")
+
+	AddCommand("portal", portalCommand)
+}
+
+func doPortal(cmd *SubCommand, client *cb.DevClient, args ...string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: cb-cli portal serve|verify [options]")
+	}
+	action := args[0]
+
+	// flags given after the action
+	err := cmd.flags.Parse(args[1:])
+	if err != nil {
+		return err
+	}
+	if cmd.flags.NArg() != 0 {
+		return fmt.Errorf("portal %s takes no arguments; only options", action)
+	}
+	if portalName == "" {
+		return fmt.Errorf("-portal is required")
+	}
+
+	SetRootDir(".")
+	switch action {
+	case "serve":
+		if _, err := getPortal(portalName); err != nil {
+			return fmt.Errorf("Could not read portal '%s': %s", portalName, err.Error())
+		}
+		return doPortalServe(client)
+	case "verify":
+		return doPortalVerify(client)
+	default:
+		return fmt.Errorf("unknown portal action '%s'; must be serve or verify", action)
+	}
+}
+
+func doPortalServe(client *cb.DevClient) error {
+	platform, err := url.Parse(client.HttpAddr)
+	if err != nil {
+		return fmt.Errorf("Invalid platform URL '%s': %s", client.HttpAddr, err.Error())
+	}
+
+	server := &portalserve.Server{
+		Name:     portalName,
+		Compress: func() (map[string]interface{}, error) { return compressPortal(portalName) },
+		Addr:     portalAddr,
+		Platform: platform,
+		Token:    client.DevToken,
+	}
+	if err := server.Reload(); err != nil {
+		fmt.Printf("Could not compress portal '%s': %s\n", portalName, err)
+	}
+
+	watcher, err := watch.New(".", []string{filepath.Join("portals", portalName)})
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if host, _, err := net.SplitHostPort(portalAddr); err != nil {
+		return fmt.Errorf("Invalid address '%s': %s", portalAddr, err.Error())
+	} else if !portalserve.IsLoopback(host) {
+		logWarning(fmt.Sprintf("The preview is served on %s, which other machines can reach. Its API calls are only proxied when it is opened at localhost", portalAddr))
+	}
+
+	httpServer := &http.Server{Addr: portalAddr, Handler: server.Handler()}
+	served := make(chan error, 1)
+	go func() {
+		served <- httpServer.ListenAndServe()
+	}()
+
+	var serveErr error
+	stop := make(chan struct{})
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	defer signal.Stop(interrupted)
+	go func() {
+		select {
+		case <-interrupted:
+		case serveErr = <-served:
+		}
+		close(stop)
+	}()
+
+	fmt.Printf("Serving a preview of portal '%s' at http://%s, proxying API calls to %s. Press Ctrl-C to stop\n", portalName, portalAddr, client.HttpAddr)
+	watcher.Run(portalDelay, stop, func([]watch.Asset) {
+		if err := server.Reload(); err != nil {
+			fmt.Printf("%s Could not compress portal '%s': %s\n", time.Now().Format("15:04:05"), portalName, err)
+			return
+		}
+		fmt.Printf("%s Recompressed portal '%s'\n", time.Now().Format("15:04:05"), portalName)
+	}, func(err error) {
+		fmt.Printf("Watch error: %s\n", err)
+	})
+
+	// closed rather than shut down, as the previews keep their event streams open
+	httpServer.Close()
+	if serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package cblib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/clearblade/cblib/fs"
+	"github.com/clearblade/cblib/internal/metaformat"
+	"github.com/clearblade/cblib/internal/portalcode"
+	"github.com/clearblade/cblib/internal/widgetdirs"
+)
+
+// getPortalCodeRunner returns the runner of the portal code hooks of the
+// system, or nil if none are configured.
+func getPortalCodeRunner() (*portalcode.Runner, error) {
+	settings, err := metaformat.LoadSettings(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	config, err := portalcode.ParseConfig(settings.PortalCode)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	return &portalcode.Runner{Config: config, Dir: rootDir}, nil
+}
+
+// formatPortalCode runs the format hooks over the code of a decompressed
+// portal. Failures are only warned about, the code is kept as pulled.
+func formatPortalCode(portalName string) error {
+	runner, err := getPortalCodeRunner()
+	if err != nil || runner == nil || len(runner.Config.Format) == 0 {
+		return err
+	}
+	files, err := getPortalCodeFiles(portalName)
+	if err != nil {
+		return err
+	}
+	if err := runner.Format(files); err != nil {
+		logWarning(fmt.Sprintf("Could not format the code of portal '%s':\n%s", portalName, err.Error()))
+	}
+	return nil
+}
+
+// writePulledPortal writes a pulled portal and runs the format hooks over its
+// code. Portals written for other reasons, e.g. by portal verify, are left as
+// pulled.
+func writePulledPortal(name string, portal map[string]interface{}) error {
+	if err := writePortal(name, portal); err != nil {
+		return err
+	}
+	return formatPortalCode(name)
+}
+
+// lintPortalCode runs the lint hooks over the code of a decompressed portal.
+func lintPortalCode(portalName string) error {
+	runner, err := getPortalCodeRunner()
+	if err != nil || runner == nil || len(runner.Config.Lint) == 0 {
+		return err
+	}
+	files, err := getPortalCodeFiles(portalName)
+	if err != nil {
+		return err
+	}
+	if err := runner.Lint(files); err != nil {
+		return fmt.Errorf("The code of portal '%s' doesn't pass the lint hooks:\n%s", portalName, err.Error())
+	}
+	return nil
+}
+
+// lintPortalsToPush runs the lint hooks over the code of the portals pushed
+// with the options, as they are pushed decompressed.
+func lintPortalsToPush(options *fs.ZipOptions) error {
+	entries, err := os.ReadDir(portalsDir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && options.ShouldPushPortal(entry.Name()) {
+			if err := lintPortalCode(entry.Name()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getPortalCodeFiles returns the parsers, internal resources and HTML files of
+// a decompressed portal.
+func getPortalCodeFiles(portalName string) ([]portalcode.File, error) {
+	configDir := getDecompressedPortalDir(portalName)
+	files := []portalcode.File{}
+	owners := map[string]string{}
+	err := filepath.Walk(configDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !portalcode.IsCode(path) {
+			return nil
+		}
+		relPath, err := filepath.Rel(configDir, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(relPath), "/")
+		if len(parts) < 3 {
+			return nil
+		}
+		ownerDir := filepath.Join(configDir, parts[0], parts[1])
+		if _, ok := owners[ownerDir]; !ok {
+			owners[ownerDir] = describePortalCodeOwner(parts[0], ownerDir)
+		}
+		files = append(files, portalcode.File{Path: path, Owner: owners[ownerDir]})
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return files, nil
+	}
+	return files, err
+}
+
+// describePortalCodeOwner describes the widget, datasource or internal
+// resource of a directory of a decompressed portal.
+func describePortalCodeOwner(kind, dir string) string {
+	name := filepath.Base(dir)
+	switch kind {
+	case widgetsDirectory:
+		meta, err := getPortalWidgetMetaFile(dir)
+		if err != nil {
+			return fmt.Sprintf("widget '%s'", name)
+		}
+		settings, _ := getPortalWidgetSettingsFile(dir)
+		meta["props"] = settings
+		id := fmt.Sprint(meta["id"])
+		widget := widgetdirs.FromConfig(id, meta)
+		if widget.Name != "" {
+			return fmt.Sprintf("widget '%s' (%s)", widget.Name, id)
+		}
+		return fmt.Sprintf("widget %s (%s)", widget.Type, id)
+	case datasourceDirectory:
+		return fmt.Sprintf("datasource '%s'", name)
+	case internalResourcesDirectory:
+		return fmt.Sprintf("internal resource '%s'", name)
+	default:
+		return name
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/clearblade/cblib/internal/portalassets"
 	"github.com/totherme/unstructured"
 )
 
@@ -284,6 +285,10 @@ func getDecompressedPortalDir(portalName string) string {
 	return filepath.Join(portalsDir, portalName, portalConfigDirectory)
 }
 
+func getPortalAssetsDir(portalName string) string {
+	return filepath.Join(portalsDir, portalName, portalassets.Dir)
+}
+
 func compressInternalResources(portal *unstructured.Data, decompressedPortalDir string) error {
 	portalConfig, err := portal.GetByPointer(portalConfigPath)
 	if err != nil {
@@ -338,5 +343,12 @@ func compressPortal(name string) (map[string]interface{}, error) {
 		return nil, err
 	}
 
-	return portalConfig.ObValue()
+	portal, err := portalConfig.ObValue()
+	if err != nil {
+		return nil, err
+	}
+	if portal["config"], err = portalassets.Inline(portal["config"], getPortalAssetsDir(name)); err != nil {
+		return nil, err
+	}
+	return portal, nil
 }
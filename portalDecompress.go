@@ -8,6 +8,8 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/clearblade/cblib/internal/portalassets"
+	"github.com/clearblade/cblib/internal/widgetdirs"
 	"github.com/totherme/unstructured"
 )
 
@@ -16,7 +18,19 @@ func cleanUpAndDecompress(name string, portal map[string]interface{}) (map[strin
 		return nil, err
 	}
 
-	portalConfig, err := convertPortalMapToUnstructured(portal)
+	// the embedded assets are written first, so that the files of the config
+	// hold references to them
+	config, err := portalassets.Extract(portal["config"], getPortalAssetsDir(name))
+	if err != nil {
+		return nil, err
+	}
+	withReferences := map[string]interface{}{}
+	for key, value := range portal {
+		withReferences[key] = value
+	}
+	withReferences["config"] = config
+
+	portalConfig, err := convertPortalMapToUnstructured(withReferences)
 	if err != nil {
 		return nil, err
 	}
@@ -157,16 +171,25 @@ func decompressWidgets(portal *unstructured.Data) error {
 		return err
 	}
 
+	dirsFile := filepath.Join(portalsDir, portalName, widgetdirs.FileName)
+	previousDirs, err := widgetdirs.Read(dirsFile)
+	if err != nil {
+		return err
+	}
+	dirs := widgetdirs.Assign(getWidgetsToName(widgets), previousDirs)
+
 	for id := range widgets {
 		widgetData, err := portal.GetByPointer(portalWidgetsPath + "/" + id)
 		if err != nil {
 			return err
 		}
-		widgetName := getOrGenerateWidgetName(widgetData)
-		if err := writeWidget(portalName, widgetName, &widgetData); err != nil {
+		if err := writeWidget(portalName, dirs[id], &widgetData); err != nil {
 			return err
 		}
 	}
+	if err := widgetdirs.Write(dirsFile, dirs); err != nil {
+		return err
+	}
 
 	portalConfig, err := portal.GetByPointer(portalConfigPath)
 	if err != nil {
@@ -176,11 +199,15 @@ func decompressWidgets(portal *unstructured.Data) error {
 	return nil
 }
 
-func getOrGenerateWidgetName(widgetData unstructured.Data) string {
-	widgetID, _ := widgetData.UnsafeGetField("id").StringValue()
-	widgetType, _ := widgetData.UnsafeGetField("type").StringValue()
-	name := fmt.Sprintf("%s"+"_"+"%v", widgetType, widgetID)
-	return name
+// getWidgetsToName returns the widgets of the portal, to name their
+// directories after.
+func getWidgetsToName(widgets map[string]interface{}) []widgetdirs.Widget {
+	rtn := []widgetdirs.Widget{}
+	for id, widget := range widgets {
+		config, _ := widget.(map[string]interface{})
+		rtn = append(rtn, widgetdirs.FromConfig(id, config))
+	}
+	return rtn
 }
 
 // currentWidgetRelativePath = portals/empty/config/widgets/HTML_WIDGET_COMPONENT_brand
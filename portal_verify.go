@@ -0,0 +1,134 @@
+package cblib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cblib/internal/jsoncmp"
+)
+
+var portalVerifyFile string
+
+// portalFidelityFields are the fields of a portal which must survive a
+// decompression and compression unchanged.
+var portalFidelityFields = []string{"name", "type", "config"}
+
+// doPortalVerify checks the round trip of the portal as pulled from the
+// platform, or as exported in -file.
+func doPortalVerify(client *cb.DevClient) error {
+	var portal map[string]interface{}
+	var err error
+	if portalVerifyFile != "" {
+		portal, err = readPortalExport(portalVerifyFile)
+	} else {
+		systemInfo, sysErr := getSysMeta()
+		if sysErr != nil {
+			return sysErr
+		}
+		portal, err = pullPortal(systemInfo.Key, portalName, client)
+	}
+	if err != nil {
+		return err
+	}
+
+	differences, err := verifyPortalRoundTrip(portal)
+	if err != nil {
+		return err
+	}
+	if len(differences) == 0 {
+		fmt.Printf("Portal '%s' round trips without differences\n", portalName)
+		return nil
+	}
+	for _, difference := range differences {
+		fmt.Println(difference)
+	}
+	return fmt.Errorf("Portal '%s' changes in %d places when decompressed and compressed", portalName, len(differences))
+}
+
+// readPortalExport reads a portal as returned by the platform, with its
+// config as an object or a string.
+func readPortalExport(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	portal := map[string]interface{}{}
+	if err := json.Unmarshal(data, &portal); err != nil {
+		return nil, fmt.Errorf("Could not parse %s: %s", path, err.Error())
+	}
+	if err := transformPortal(portal); err != nil {
+		return nil, err
+	}
+	return portal, nil
+}
+
+// verifyPortalRoundTrip decompresses the portal, as a pull does, compresses
+// it back, as a push does, and returns the differences with the original.
+func verifyPortalRoundTrip(portal map[string]interface{}) ([]jsoncmp.Difference, error) {
+	original, err := copyPortalFields(portal)
+	if err != nil {
+		return nil, err
+	}
+	roundTripped, err := roundTripPortal(portal)
+	if err != nil {
+		return nil, err
+	}
+	result, err := copyPortalFields(roundTripped)
+	if err != nil {
+		return nil, err
+	}
+	return jsoncmp.Compare(original, result)
+}
+
+// roundTripPortal decompresses the portal in a temporary directory and
+// compresses it back.
+func roundTripPortal(portal map[string]interface{}) (map[string]interface{}, error) {
+	name, ok := portal["name"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("portal has no name")
+	}
+	portal, err := copyPortalFields(portal)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "cb-portal-verify")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	savedPortalsDir := portalsDir
+	portalsDir = tmpDir
+	defer func() { portalsDir = savedPortalsDir }()
+
+	if err := writePortal(name, portal); err != nil {
+		return nil, fmt.Errorf("Could not decompress portal '%s': %s", name, err.Error())
+	}
+	compressed, err := compressPortal(name)
+	if err != nil {
+		return nil, fmt.Errorf("Could not compress portal '%s': %s", name, err.Error())
+	}
+	return compressed, nil
+}
+
+// copyPortalFields returns a deep copy of the fidelity fields of the portal.
+func copyPortalFields(portal map[string]interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	for _, field := range portalFidelityFields {
+		if value, ok := portal[field]; ok {
+			fields[field] = value
+		}
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	rtn := map[string]interface{}{}
+	if err := json.Unmarshal(data, &rtn); err != nil {
+		return nil, err
+	}
+	return rtn, nil
+}
@@ -0,0 +1,55 @@
+package cblib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// portalCorpusEnv names a directory of portal exports, as saved from the
+// platform, to check in addition to the samples in testdata/portals.
+const portalCorpusEnv = "CB_PORTAL_CORPUS"
+
+func TestPortalRoundTripCorpus(t *testing.T) {
+	dirs := []string{filepath.Join("testdata", "portals")}
+	if dir := os.Getenv(portalCorpusEnv); dir != "" {
+		dirs = append(dirs, dir)
+	}
+
+	for _, dir := range dirs {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		require.NoError(t, err)
+		require.NotEmpty(t, paths, "no portal exports in %s", dir)
+
+		for _, path := range paths {
+			path := path
+			t.Run(path, func(t *testing.T) {
+				portal, err := readPortalExport(path)
+				require.NoError(t, err)
+
+				differences, err := verifyPortalRoundTrip(portal)
+				require.NoError(t, err)
+				for _, difference := range differences {
+					t.Error(difference)
+				}
+			})
+		}
+	}
+}
+
+func TestRoundTripPortal(t *testing.T) {
+	saved := portalsDir
+	portal, err := readPortalExport(filepath.Join("testdata", "portals", "parsers.json"))
+	require.NoError(t, err)
+
+	roundTripped, err := roundTripPortal(portal)
+	require.NoError(t, err)
+	assert.Equal(t, "parsers", roundTripped["name"])
+	assert.Equal(t, saved, portalsDir)
+
+	_, err = roundTripPortal(map[string]interface{}{"config": map[string]interface{}{}})
+	assert.EqualError(t, err, "portal has no name")
+}
@@ -71,6 +71,7 @@ func init() {
 	pullCommand.flags.StringVar(&LibraryName, "library", "", "Name of library to pull")
 	pullCommand.flags.StringVar(&CollectionName, "collection", "", "Name of collection to pull")
 	pullCommand.flags.BoolVar(&SortCollections, "sort-collections", SortCollectionsDefault, "Sort collections by item id, for version control ease")
+	setMetadataFormatFlag(&pullCommand.flags)
 	pullCommand.flags.IntVar(&DataPageSize, "page-size", DataPageSizeDefault, "Number of rows in a collection to request at a time")
 	pullCommand.flags.StringVar(&User, "user", "", "Name of user to pull")
 	pullCommand.flags.StringVar(&RoleName, "role", "", "Name of role to pull")
@@ -111,6 +112,10 @@ func doPull(cmd *SubCommand, client *cb.DevClient, args ...string) error {
 		return err
 	}
 
+	if err := applyMetadataFormatFlag(); err != nil {
+		return err
+	}
+
 	// This is a hack to check if token has expired and auth again
 	// since we dont have an endpoint to determine this
 	client, err = checkIfTokenHasExpired(client, systemInfo.Key)
@@ -372,7 +377,7 @@ func PullAndWritePortal(systemKey, name string, client *cb.DevClient) error {
 	if portal, err := pullPortal(systemKey, name, client); err != nil {
 		return err
 	} else {
-		return writePortal(name, portal)
+		return writePulledPortal(name, portal)
 	}
 }
 
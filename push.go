@@ -3,14 +3,22 @@ package cblib
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	cb "github.com/clearblade/Go-SDK"
 	"github.com/clearblade/cblib/fs"
+	"github.com/clearblade/cblib/internal/build"
 	"github.com/clearblade/cblib/models/systemUpload"
 	"github.com/clearblade/cblib/models/systemUpload/dryRun"
 	"github.com/clearblade/cblib/types"
 )
 
+var (
+	pushRemotesGroup string
+	pushSkipBuild    bool
+)
+
 func init() {
 
 	usage :=
@@ -24,7 +32,10 @@ func init() {
 	cb-cli push -all -auto-approve				# Push all assets up to Platform and automatically confirm any prompts for creating new assets
 	cb-cli push -all-services -all-portals		# Push all services and all portals up to Platform
 	cb-cli push -service=Service1				# Push a code service up to Platform
+	cb-cli push -service=Service1 -with-deps	# Push a code service and the libraries it depends on
+	cb-cli push -library=Lib1 -with-dependents	# Push a library and the libraries and services that depend on it
 	cb-cli push -collection=Collection1			# Push a code service up to Platform
+	cb-cli push -all -remotes=regions			# Push all assets to every remote of the 'regions' group
 	`
 
 	pushCommand := &SubCommand{
@@ -70,6 +81,8 @@ func init() {
 	pushCommand.flags.BoolVar(&ExcludeIndexes, "exclude-indexes", false, "Do not push indexes when pushing a collection schema via cb-cli push -collectionschema=<name> -piecemeal")
 	pushCommand.flags.StringVar(&ServiceName, "service", "", "Name of service to push")
 	pushCommand.flags.StringVar(&LibraryName, "library", "", "Name of library to push")
+	pushCommand.flags.BoolVar(&WithDependents, "with-dependents", false, "Also push the libraries and services that depend on the library given with -library")
+	pushCommand.flags.BoolVar(&WithDeps, "with-deps", false, "Also push the libraries the service given with -service (or the library given with -library) depends on, directly or not")
 	pushCommand.flags.StringVar(&CollectionName, "collection", "", "Name of collection to push")
 	pushCommand.flags.StringVar(&CollectionId, "collectionID", "", "Unique id of collection to update. -collection flag is preferred")
 	pushCommand.flags.StringVar(&User, "user", "", "Name of user to push")
@@ -94,8 +107,11 @@ func init() {
 	pushCommand.flags.StringVar(&FileStoreFiles, "file-store-files", "", "Name of file store to push files to. Can be used in conjunction with -file-store-file")
 	pushCommand.flags.StringVar(&FileStoreFileName, "file-store-file", "", "Name of file to push from file store specified with -file-store-files")
 	pushCommand.flags.StringVar(&SecretName, "user-secret", "", "Name of user secret to push")
+	pushCommand.flags.StringVar(&pushRemotesGroup, "remotes", "", "Name of a remote group to push to. The zip is built once, dry runs are shown for every remote and changes are uploaded to each of them")
 	pushCommand.flags.BoolVar(&PreserveEdges, "preserve-edges", false, "Preserve edges when pushing a deployment. When this flag is specified, the edges in the deployment will not be modified on the platform. Note: this option is only available when using the -piecemeal flag")
 
+	pushCommand.flags.BoolVar(&pushSkipBuild, "skip-build", false, "Don't build the services kept under src/ before pushing")
+
 	setBackoffFlags(pushCommand.flags)
 
 	AddCommand("push", pushCommand)
@@ -111,6 +127,15 @@ func checkPushArgsAndFlags(args []string) error {
 	if AllLibraries && LibraryName != "" {
 		return fmt.Errorf("Cannot specify both -all-libraries and -library=<library_name>\n")
 	}
+	if WithDependents && LibraryName == "" {
+		return fmt.Errorf("-with-dependents requires -library=<library_name>\n")
+	}
+	if WithDeps && LibraryName == "" && ServiceName == "" {
+		return fmt.Errorf("-with-deps requires -service=<service_name> or -library=<library_name>\n")
+	}
+	if pushRemotesGroup != "" && PieceMeal {
+		return fmt.Errorf("Cannot specify both -remotes=<group_name> and -piecemeal\n")
+	}
 	return nil
 }
 
@@ -125,6 +150,17 @@ func doPush(cmd *SubCommand, client *cb.DevClient, args ...string) error {
 	}
 	SetRootDir(".")
 
+	opts, err := pushZipOptions()
+	if err != nil {
+		return err
+	}
+
+	if buildOpts, ok := pushBuildOptions(opts); ok && !pushSkipBuild {
+		if err := buildSources(buildOpts); err != nil {
+			return err
+		}
+	}
+
 	// This is a hack to check if token has expired and auth again
 	// since we dont have an endpoint to determine this
 	client, err = checkIfTokenHasExpired(client, systemInfo.Key)
@@ -137,12 +173,61 @@ func doPush(cmd *SubCommand, client *cb.DevClient, args ...string) error {
 		return err
 	}
 
+	if pushRemotesGroup != "" {
+		if version < 5 {
+			return fmt.Errorf("Pushing to a remote group requires system upload version 5 or later, got version %d\n", version)
+		}
+		return pushSystemZipToGroup(systemInfo, cmd.remotes, pushRemotesGroup, opts)
+	}
+
 	// Below version 5 we only support code services, so we need to do the legacy push
 	if version < 5 || PieceMeal {
 		return doLegacyPush(client, systemInfo)
 	}
 
-	return pushSystemZip(systemInfo, client, defaultZipOptions())
+	return pushSystemZip(systemInfo, client, opts)
+}
+
+// pushZipOptions returns the zip options of the push flags, with the
+// dependents of the library when -with-dependents is given and the library
+// dependencies when -with-deps is given.
+func pushZipOptions() (*fs.ZipOptions, error) {
+	opts := defaultZipOptions()
+	if WithDeps {
+		libraries, err := codeDependencies(LibraryName, ServiceName)
+		if err != nil {
+			return nil, err
+		}
+		if len(libraries) > 0 {
+			fmt.Printf("Including library dependencies: %s\n", strings.Join(libraries, ", "))
+		}
+		opts.LibraryNames = append(opts.LibraryNames, libraries...)
+	}
+	if WithDependents {
+		libraries, services, err := libraryDependents(LibraryName)
+		if err != nil {
+			return nil, err
+		}
+		opts.LibraryNames = append(opts.LibraryNames, libraries...)
+		opts.ServiceNames = append(opts.ServiceNames, services...)
+	}
+	return opts, nil
+}
+
+// pushBuildOptions returns the build options of the services to push that
+// are kept under src/, and false if there are none.
+func pushBuildOptions(opts *fs.ZipOptions) (build.Options, bool) {
+	if opts.AllAssets || opts.AllServices {
+		return build.Options{}, build.HasSources(rootDir)
+	}
+
+	services := []string{}
+	for _, name := range append([]string{opts.ServiceName}, opts.ServiceNames...) {
+		if name != "" && build.HasServiceSources(rootDir, name) && !slices.Contains(services, name) {
+			services = append(services, name)
+		}
+	}
+	return build.Options{Services: services}, len(services) > 0
 }
 
 type prompter struct{}
@@ -151,9 +236,24 @@ func (p prompter) PromptForSecret(prompt string) string {
 	return getOneItem(prompt, true)
 }
 
+// getSystemZipBytes lints the portals of the options and builds the system
+// zip. Every zip push builds its zip here, so that none skips the lint hooks.
+func getSystemZipBytes(options *fs.ZipOptions) ([]byte, error) {
+	if err := lintPortalsToPush(options); err != nil {
+		return nil, err
+	}
+	return fs.GetSystemZipBytes(rootDir, prompter{}, options)
+}
+
 func pushSystemZip(systemInfo *types.System_meta, client *cb.DevClient, options *fs.ZipOptions) error {
+	return uploadSystemZip(systemInfo, client, options, false)
+}
+
+// uploadSystemZip pushes the assets of the options after a dry run. The
+// changes of the dry run are accepted without asking if approve is true.
+func uploadSystemZip(systemInfo *types.System_meta, client *cb.DevClient, options *fs.ZipOptions, approve bool) error {
 	fmt.Printf("Preparing to push system %s\n", systemInfo.Name)
-	buffer, err := fs.GetSystemZipBytes(rootDir, prompter{}, options)
+	buffer, err := getSystemZipBytes(options)
 	if err != nil {
 		return err
 	}
@@ -179,9 +279,12 @@ func pushSystemZip(systemInfo *types.System_meta, client *cb.DevClient, options
 	}
 
 	fmt.Print(dryRun.String())
-	changesAccepted, err := confirmPrompt(fmt.Sprintln("Would you like to accept these changes?"))
-	if err != nil {
-		return err
+	changesAccepted := approve
+	if !changesAccepted {
+		changesAccepted, err = confirmPrompt(fmt.Sprintln("Would you like to accept these changes?"))
+		if err != nil {
+			return err
+		}
 	}
 
 	if !changesAccepted {
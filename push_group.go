@@ -0,0 +1,225 @@
+package cblib
+
+import (
+	"fmt"
+	"strings"
+
+	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cblib/fs"
+	"github.com/clearblade/cblib/internal/remote"
+	"github.com/clearblade/cblib/models/systemUpload"
+	"github.com/clearblade/cblib/models/systemUpload/dryRun"
+	"github.com/clearblade/cblib/types"
+)
+
+// groupPushTarget holds the state of a single remote during a group push.
+type groupPushTarget struct {
+	remote    *remote.Remote
+	client    *cb.DevClient
+	dryRun    dryRun.DryRun
+	dryRunErr error
+	pushErr   error
+	pushed    bool
+}
+
+func (t *groupPushTarget) canPush() bool {
+	return t.dryRunErr == nil && !t.dryRun.HasErrors() && t.dryRun.HasChanges()
+}
+
+// pushSystemZipToGroup builds the system zip once and pushes it to every remote
+// of the given group. Dry runs for all the remotes are shown together and
+// confirmed once; each remote then succeeds or fails independently.
+func pushSystemZipToGroup(systemInfo *types.System_meta, remotes *remote.Remotes, groupName string, options *fs.ZipOptions) error {
+	group, ok := remotes.FindGroup(groupName)
+	if !ok {
+		return fmt.Errorf("no remote group named '%s'", groupName)
+	}
+
+	fmt.Printf("Preparing to push system %s to remote group %s\n", systemInfo.Name, group.Name)
+	buffer, err := getSystemZipBytes(options)
+	if err != nil {
+		return err
+	}
+
+	targets := make([]*groupPushTarget, 0, len(group.Remotes))
+	for _, r := range group.Remotes {
+		target := &groupPushTarget{remote: r}
+		targets = append(targets, target)
+
+		r, err = remote.Resolve(r)
+		if err != nil {
+			target.dryRunErr = err
+			continue
+		}
+		target.remote = r
+
+		fmt.Printf("Doing dry run against %s\n", r.Name)
+		target.client, target.dryRunErr = authorizeGroupRemote(remotes, r)
+		if target.dryRunErr != nil {
+			continue
+		}
+
+		version, err := systemUpload.GetSystemUploadVersion(&types.System_meta{Key: r.SystemKey}, target.client)
+		if err != nil {
+			target.dryRunErr = err
+			continue
+		}
+		if version < 5 {
+			target.dryRunErr = fmt.Errorf("system upload version %d doesn't support zip pushes, push to it with -piecemeal", version)
+			continue
+		}
+
+		result, err := target.client.UploadToSystemDryRun(r.SystemKey, buffer)
+		if err != nil {
+			target.dryRunErr = err
+			continue
+		}
+
+		target.dryRun, target.dryRunErr = dryRun.New(result)
+	}
+
+	pushable := 0
+	for _, target := range targets {
+		fmt.Printf("\n=== %s (%s) ===\n", target.remote.Name, target.remote.PlatformURL)
+		switch {
+		case target.dryRunErr != nil:
+			fmt.Printf("Dry run failed: %s\n", target.dryRunErr)
+		case target.dryRun.HasErrors():
+			fmt.Println(target.dryRun.String())
+		case !target.dryRun.HasChanges():
+			fmt.Print(target.dryRun.String())
+			fmt.Println("Nothing to push")
+		default:
+			fmt.Print(target.dryRun.String())
+			pushable++
+		}
+	}
+	fmt.Println()
+
+	if pushable == 0 {
+		fmt.Println("Nothing to push")
+		return groupPushError(targets)
+	}
+
+	changesAccepted, err := confirmPrompt(fmt.Sprintf("Would you like to accept these changes on %d of %d remotes?\n", pushable, len(targets)))
+	if err != nil {
+		return err
+	}
+
+	if !changesAccepted {
+		fmt.Println("Changes will not be pushed")
+		return nil
+	}
+
+	currentName := ""
+	if current, ok := remotes.Current(); ok {
+		currentName = current.Name
+	}
+	for _, target := range targets {
+		if !target.canPush() {
+			continue
+		}
+
+		fmt.Printf("Pushing changes to %s\n", target.remote.Name)
+		r, err := target.client.UploadToSystem(target.remote.SystemKey, buffer)
+		if err != nil {
+			target.pushErr = err
+			continue
+		}
+
+		// The id maps on disk belong to the current remote only
+		if target.remote.Name == currentName {
+			updateIdMap(r)
+		}
+
+		target.pushErr = r.Error()
+		target.pushed = target.pushErr == nil
+	}
+
+	printGroupPushResults(targets)
+	return groupPushError(targets)
+}
+
+// authorizeGroupRemote authorizes with the remote and, when its token has
+// expired, asks for its credentials again. The new token replaces the one of
+// the remote, or of the user-level remote it references.
+func authorizeGroupRemote(remotes *remote.Remotes, r *remote.Remote) (*cb.DevClient, error) {
+	client, err := authorizeUsingRemote(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.CheckAuth(); err == nil {
+		return client, nil
+	}
+
+	fmt.Printf("Token of remote %s has probably expired. Please enter details for authentication again...\n", r.Name)
+	// WARNING: side-effect (changes globals)
+	URL, MsgURL, Email, Password, DevToken = r.PlatformURL, r.MessagingURL, "", "", ""
+	promptAndFillMissingAuth(nil, PromptSkipURL|PromptSkipMsgURL|PromptSkipSystemKey)
+	client, err = authorizeUsingGlobalCLIFlags()
+	if err != nil {
+		return nil, fmt.Errorf("could not authorize with remote '%s': %s", r.Name, err)
+	}
+
+	if err := storeRemoteToken(remotes, r.Name, client.DevToken); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// storeRemoteToken sets the token of the named remote. The remotes are saved
+// once the command is done; user-level remotes are saved right away.
+func storeRemoteToken(remotes *remote.Remotes, name, token string) error {
+	r, ok := remotes.FindByName(name)
+	if !ok {
+		return nil
+	}
+	if r.Profile == "" || r.Token != "" {
+		r.Token = token
+		return nil
+	}
+
+	user, err := remote.LoadUserRemotes()
+	if err != nil {
+		return err
+	}
+	profile, ok := user.FindByName(r.Profile)
+	if !ok {
+		return fmt.Errorf("remote %s references unknown user-level remote %s", r.Name, r.Profile)
+	}
+	profile.Token = token
+	return remote.SaveUserRemotes(user)
+}
+
+func printGroupPushResults(targets []*groupPushTarget) {
+	fmt.Println("\nPush results:")
+	for _, target := range targets {
+		status := "skipped (no changes)"
+		switch {
+		case target.dryRunErr != nil:
+			status = fmt.Sprintf("FAILED (dry run): %s", target.dryRunErr)
+		case target.dryRun.HasErrors():
+			status = "FAILED (dry run errors)"
+		case target.pushErr != nil:
+			status = fmt.Sprintf("FAILED: %s", target.pushErr)
+		case target.pushed:
+			status = "pushed"
+		}
+		fmt.Printf("    %-30s %s\n", target.remote.Name, status)
+	}
+}
+
+func groupPushError(targets []*groupPushTarget) error {
+	failed := make([]string, 0)
+	for _, target := range targets {
+		if target.dryRunErr != nil || target.dryRun.HasErrors() || target.pushErr != nil {
+			failed = append(failed, target.remote.Name)
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("push failed for %d of %d remotes: %s", len(failed), len(targets), strings.Join(failed, ", "))
+}
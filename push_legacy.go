@@ -13,7 +13,6 @@ import (
 	"github.com/clearblade/cblib/models/bucketSetFiles"
 	"github.com/clearblade/cblib/models/collections"
 	"github.com/clearblade/cblib/models/index"
-	libPkg "github.com/clearblade/cblib/models/libraries"
 	"github.com/clearblade/cblib/models/roles"
 	"github.com/clearblade/cblib/models/systemUpload"
 	"github.com/clearblade/cblib/types"
@@ -93,11 +92,22 @@ func doLegacyPush(client *cb.DevClient, systemInfo *types.System_meta) error {
 		}
 	}
 
+	if WithDeps && (LibraryName != "" || ServiceName != "") {
+		if err := pushCodeDependencies(systemInfo, client, LibraryName, ServiceName); err != nil {
+			return err
+		}
+	}
+
 	if LibraryName != "" {
 		didSomething = true
 		if err := pushOneLibrary(systemInfo, client, LibraryName); err != nil {
 			return err
 		}
+		if WithDependents {
+			if err := pushLibraryDependents(systemInfo, client, LibraryName); err != nil {
+				return err
+			}
+		}
 	}
 
 	if ServiceName != "" {
@@ -705,6 +715,9 @@ func pushAllEdges(systemInfo *types.System_meta, client *cb.DevClient) error {
 
 func pushOnePortal(systemInfo *types.System_meta, client *cb.DevClient, name string) error {
 	fmt.Printf("Pushing portal %+s\n", name)
+	if err := lintPortalCode(name); err != nil {
+		return err
+	}
 	compressedPortal, err := compressPortal(name)
 	if err != nil {
 		return err
@@ -1116,6 +1129,37 @@ func pushOneLibrary(systemInfo *types.System_meta, client *cb.DevClient, name st
 	return updateLibrary(systemInfo.Key, library, client)
 }
 
+func pushCodeDependencies(systemInfo *types.System_meta, client *cb.DevClient, libraryName, serviceName string) error {
+	libraries, err := codeDependencies(libraryName, serviceName)
+	if err != nil {
+		return err
+	}
+	for _, library := range libraries {
+		if err := pushOneLibrary(systemInfo, client, library); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pushLibraryDependents(systemInfo *types.System_meta, client *cb.DevClient, name string) error {
+	libraries, services, err := libraryDependents(name)
+	if err != nil {
+		return err
+	}
+	for _, library := range libraries {
+		if err := pushOneLibrary(systemInfo, client, library); err != nil {
+			return err
+		}
+	}
+	for _, service := range services {
+		if err := pushOneService(systemInfo, client, service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func pushMessageHistoryStorage(systemInfo *types.System_meta, client *cb.DevClient) error {
 	storage, err := getMessageHistoryStorage()
 	if err != nil {
@@ -1159,13 +1203,11 @@ func pushAllLibraries(systemInfo *types.System_meta, client *cb.DevClient) error
 		return err
 	}
 
-	libraries := make([]libPkg.Library, 0)
-	for _, rawLib := range rawLibraries {
-		libraries = append(libraries, libPkg.NewLibraryFromMap(rawLib))
+	orderedLibraries, err := orderLibraries(rawLibraries)
+	if err != nil {
+		return err
 	}
 
-	orderedLibraries := libPkg.PostorderLibraries(libraries)
-
 	for _, library := range orderedLibraries {
 		fmt.Printf("Pushing library %+s\n", library.GetName())
 		if err := updateLibrary(systemInfo.Key, library.GetMap(), client); err != nil {
@@ -2139,9 +2181,6 @@ func getServiceBody(service map[string]interface{}) map[string]interface{} {
 
 func createService(systemKey string, service map[string]interface{}, client *cb.DevClient) error {
 	svcName := service["name"].(string)
-	if ServiceName != "" {
-		svcName = ServiceName
-	}
 	svcCode := service["code"].(string)
 	extra := getServiceBody(service)
 	if err := client.NewServiceWithBody(systemKey, svcName, svcCode, extra); err != nil {
@@ -2188,9 +2227,6 @@ func updateLibrary(systemKey string, library map[string]interface{}, client *cb.
 
 func createLibrary(systemKey string, library map[string]interface{}, client *cb.DevClient) error {
 	libName := library["name"].(string)
-	if LibraryName != "" {
-		libName = LibraryName
-	}
 	delete(library, "name")
 	delete(library, "version")
 	if _, err := client.CreateLibrary(systemKey, libName, library); err != nil {
@@ -0,0 +1,146 @@
+package cblib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cbjson"
+	"github.com/clearblade/cblib/internal/localrun"
+)
+
+var (
+	runLocalParams  string
+	runLocalTimeout time.Duration
+)
+
+func init() {
+
+	usage :=
+		`
+	Run a code service locally, without a platform. The service's code and the code of the
+	libraries it depends on (in dependency order) run in an embedded JavaScript engine.
+	ClearBlade.Collection and ClearBlade.Query are backed by the local collection files
+	under data/; changes made by the service are kept in memory and never written back.
+	Other ClearBlade APIs aren't available. Exits with an error if the service calls
+	resp.error.
+	`
+
+	example :=
+		`
+	cb-cli run-local -service=Service1								# Run Service1 without parameters
+	cb-cli run-local -service=Service1 -params='{"orderId": "abc"}'	# Run Service1 with parameters
+	`
+
+	runLocalCommand := &SubCommand{
+		name:      "run-local",
+		usage:     usage,
+		needsAuth: false,
+		run:       doRunLocal,
+		example:   example,
+	}
+
+	runLocalCommand.flags.StringVar(&ServiceName, "service", "", "Name of the service to run")
+	runLocalCommand.flags.StringVar(&runLocalParams, "params", "", "params to service in json stringified format")
+	runLocalCommand.flags.DurationVar(&runLocalTimeout, "timeout", localrun.DefaultTimeout, "Time the service may run before it's stopped")
+
+	AddCommand("run-local", runLocalCommand)
+}
+
+func doRunLocal(cmd *SubCommand, client *cb.DevClient, args ...string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("There are no arguments to the run-local command, only command line options")
+	}
+	if ServiceName == "" {
+		return fmt.Errorf("-service=<service_name> is required")
+	}
+	SetRootDir(".")
+
+	params := map[string]interface{}{}
+	if runLocalParams != "" {
+		var err error
+		params, _, err = cbjson.GetJSONFromString(runLocalParams)
+		if err != nil {
+			return fmt.Errorf("Could not parse parameters string: %s", err.Error())
+		}
+	}
+
+	opts, err := localRunOptions(ServiceName)
+	if err != nil {
+		return err
+	}
+	opts.Params = params
+	opts.Timeout = runLocalTimeout
+	opts.Log = os.Stdout
+
+	result, err := localrun.Run(*opts)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	if !result.Success {
+		return fmt.Errorf("Service '%s' failed after %s", ServiceName, result.Duration)
+	}
+	return nil
+}
+
+// localRunOptions returns the options to run the local service: its code,
+// the code of the libraries it depends on and a loader of the local
+// collections.
+func localRunOptions(name string) (*localrun.Options, error) {
+	service, err := getService(name)
+	if err != nil {
+		return nil, err
+	}
+	code, ok := service["code"].(string)
+	if !ok {
+		return nil, fmt.Errorf("Service '%s' has no code", name)
+	}
+
+	libraryNames, err := codeDependencies("", name)
+	if err != nil {
+		return nil, err
+	}
+	libraries := []localrun.Script{}
+	for _, libraryName := range libraryNames {
+		library, err := getLibrary(libraryName)
+		if err != nil {
+			return nil, err
+		}
+		libraryCode, _ := library["code"].(string)
+		libraries = append(libraries, localrun.Script{Name: libraryName, Code: libraryCode})
+	}
+
+	return &localrun.Options{
+		Name:        name,
+		Code:        code,
+		Libraries:   libraries,
+		Collections: localCollectionItems,
+	}, nil
+}
+
+// localCollectionItems returns the items of the collection stored under data/.
+func localCollectionItems(name string) ([]map[string]interface{}, error) {
+	collection, err := getCollection(name)
+	if err != nil {
+		return nil, fmt.Errorf("collection '%s' not found in %s", name, dataDir)
+	}
+
+	items := []map[string]interface{}{}
+	rawItems, _ := collection["items"].([]interface{})
+	for _, rawItem := range rawItems {
+		item, ok := rawItem.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("collection '%s' has an item that isn't an object", name)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
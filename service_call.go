@@ -0,0 +1,130 @@
+package cblib
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cbjson"
+	"github.com/clearblade/cblib/internal/execout"
+	"github.com/clearblade/cblib/internal/reportout"
+)
+
+var (
+	serviceParamsFile string
+	serviceOutput     string
+	serviceTailLogs   time.Duration
+)
+
+const tailLogsInterval = 2 * time.Second
+
+func setServiceCallFlags(f *flag.FlagSet) {
+	f.StringVar(&Params, "params", "", "params to service in json stringified format")
+	f.StringVar(&serviceParamsFile, "params-file", "", "JSON file holding the params to the service")
+	f.StringVar(&serviceOutput, "format", execout.FormatText, "Output format: text, pretty (the whole result as indented JSON) or raw (the results only, as JSON)")
+	f.DurationVar(&serviceTailLogs, "tail-logs", 0, "Keep printing the logs of the service for this long after the call, e.g. 30s. Enables logs for the call")
+}
+
+// serviceParams returns the params given with -params or -params-file.
+func serviceParams() (map[string]interface{}, error) {
+	if Params != "" && serviceParamsFile != "" {
+		return nil, fmt.Errorf("Cannot specify both -params and -params-file")
+	}
+
+	if serviceParamsFile != "" {
+		data, err := os.ReadFile(serviceParamsFile)
+		if err != nil {
+			return nil, err
+		}
+		params := map[string]interface{}{}
+		if err := json.Unmarshal(data, &params); err != nil {
+			return nil, fmt.Errorf("Could not parse %s: %s", serviceParamsFile, err.Error())
+		}
+		return params, nil
+	}
+
+	params := map[string]interface{}{}
+	if Params != "" {
+		var err error
+		params, _, err = cbjson.GetJSONFromString(Params)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse parameters string: %s", err.Error())
+		}
+	}
+	return params, nil
+}
+
+// callService calls the service, writes the result in the -format format and
+// tails its logs if -tail-logs is given. An error is returned if the service
+// fails, so that the command exits with a non-zero code.
+func callService(systemKey, name string, client *cb.DevClient, logging bool) error {
+	params, err := serviceParams()
+	if err != nil {
+		return err
+	}
+	return callServiceWithParams(systemKey, name, params, client, logging)
+}
+
+// callServiceWithParams is callService with the given params rather than
+// those of -params or -params-file.
+func callServiceWithParams(systemKey, name string, params map[string]interface{}, client *cb.DevClient, logging bool) error {
+	if err := reportout.CheckFormat(serviceOutput, execout.Formats); err != nil {
+		return err
+	}
+
+	seenLogs := map[string]bool{}
+	if serviceTailLogs > 0 {
+		logging = true
+		// only tail the logs of this call
+		logs, err := client.GetLogsForService(systemKey, name)
+		if err != nil {
+			return fmt.Errorf("Could not get the logs of service '%s': %s", name, err.Error())
+		}
+		execout.NewLogEntries(logs, seenLogs)
+	}
+
+	start := time.Now()
+	resp, err := client.CallService(systemKey, name, params, logging)
+	if err != nil {
+		return fmt.Errorf("Call service failed: %s", err.Error())
+	}
+	result := execout.FromResponse(name, resp, time.Since(start))
+
+	if err := execout.Write(os.Stdout, result, serviceOutput); err != nil {
+		return err
+	}
+
+	if serviceTailLogs > 0 {
+		if err := tailServiceLogs(systemKey, name, client, seenLogs); err != nil {
+			return err
+		}
+	}
+
+	if !result.Success {
+		return fmt.Errorf("Service '%s' failed", name)
+	}
+	return nil
+}
+
+func tailServiceLogs(systemKey, name string, client *cb.DevClient, seen map[string]bool) error {
+	// logs go to stderr so that the output of -format=raw/pretty stays parsable
+	fmt.Fprintf(os.Stderr, "Logs of service '%s' for the next %s:\n", name, serviceTailLogs)
+	deadline := time.Now().Add(serviceTailLogs)
+	for {
+		logs, err := client.GetLogsForService(systemKey, name)
+		if err != nil {
+			return fmt.Errorf("Could not get the logs of service '%s': %s", name, err.Error())
+		}
+		for _, line := range execout.NewLogEntries(logs, seen) {
+			fmt.Fprintln(os.Stderr, line)
+		}
+
+		if time.Now().Add(tailLogsInterval).After(deadline) {
+			return nil
+		}
+		time.Sleep(tailLogsInterval)
+	}
+}
@@ -68,6 +68,11 @@ func (c *SubCommand) beforeExecute(args []string) error {
 			return fmt.Errorf("No current remote")
 		}
 
+		curr, err = remote.Resolve(curr)
+		if err != nil {
+			return err
+		}
+
 		useRemoteByMergingFromGlobals(curr)
 	}
 
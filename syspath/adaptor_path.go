@@ -6,7 +6,7 @@ import (
 )
 
 const (
-	adaptorRegexStr         = `^adapters\/([^\/]+)\/([^\/]+)\.json$`
+	adaptorRegexStr         = `^adapters\/([^\/]+)\/([^\/]+)\.(?:json|ya?ml)$`
 	adaptorFileMetaRegexStr = `^adapters\/([^\/]+)\/files\/([^\/]+)\/([^\/]+)\.json$`
 	adaptorFileDataRegexStr = `^adapters\/([^\/]+)\/files\/([^\/]+)\/([^\/]+)$`
 )
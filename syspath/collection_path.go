@@ -6,7 +6,7 @@ import (
 )
 
 const (
-	collectionPathRegexStr = `^data\/([^\/]+)\.json$`
+	collectionPathRegexStr = `^data\/([^\/]+)\.(?:json|ya?ml)$`
 )
 
 var (
@@ -6,7 +6,7 @@ import (
 )
 
 const (
-	deploymentPathRegexStr = `^deployments\/([^\/]+)\.json$`
+	deploymentPathRegexStr = `^deployments\/([^\/]+)\.(?:json|ya?ml)$`
 )
 
 var (
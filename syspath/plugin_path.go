@@ -7,14 +7,20 @@ import (
 
 const (
 	pluginPathRegexStr = `^plugins\/([^\/]+)\.json$`
+	pluginMetaRegexStr = `^plugins\/([^\/]+)\/meta\.json$`
+	pluginCodeRegexStr = `^plugins\/([^\/]+)\/(.+)\.(?:js|html|css)$`
 )
 
 var (
 	pluginPathRegex *regexp.Regexp
+	pluginMetaRegex *regexp.Regexp
+	pluginCodeRegex *regexp.Regexp
 )
 
 func init() {
 	pluginPathRegex = regexp.MustCompile(pluginPathRegexStr)
+	pluginMetaRegex = regexp.MustCompile(pluginMetaRegexStr)
+	pluginCodeRegex = regexp.MustCompile(pluginCodeRegexStr)
 }
 
 func IsPluginPath(path string) bool {
@@ -29,3 +35,25 @@ func GetPluginNameFromPath(path string) (string, error) {
 
 	return matches[1], nil
 }
+
+// GetPluginMetaNameFromPath returns the name of the plugin of the meta file
+// of a decompressed plugin.
+func GetPluginMetaNameFromPath(path string) (string, error) {
+	matches := pluginMetaRegex.FindStringSubmatch(path)
+	if matches == nil || len(matches) != 2 {
+		return "", fmt.Errorf("path %q is not a plugin meta path", path)
+	}
+
+	return matches[1], nil
+}
+
+// GetPluginCodeFromPath returns the name of the plugin and the field of a
+// code file of a decompressed plugin.
+func GetPluginCodeFromPath(path string) (string, string, error) {
+	matches := pluginCodeRegex.FindStringSubmatch(path)
+	if matches == nil || len(matches) != 3 {
+		return "", "", fmt.Errorf("path %q is not a plugin code path", path)
+	}
+
+	return matches[1], matches[2], nil
+}
@@ -13,6 +13,13 @@ const (
 	portalWidgetParserRegexStr     = `^portals\/([^\/]+)\/config\/widgets\/([^\/]+)\/parsers\/([^\/]+)\/(.+)$`
 )
 
+// PortalSidecarExt is the extension of the files cb-cli keeps in the directory
+// of a portal for its own bookkeeping, e.g. the directories of the widgets or
+// the media types of the assets. Sidecar files aren't part of the portal: none
+// of the portal paths matches them, as they aren't .json files, and they are
+// neither code nor assets.
+const PortalSidecarExt = ".map"
+
 var (
 	portalPathRegex             *regexp.Regexp
 	portalDatasourceRegex       *regexp.Regexp
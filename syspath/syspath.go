@@ -27,6 +27,11 @@ func IsJsonFile(path string) bool {
 	return getFileExtension(path) == "json"
 }
 
+func IsYamlFile(path string) bool {
+	ext := getFileExtension(path)
+	return ext == "yaml" || ext == "yml"
+}
+
 func IsJsMapFile(path string) bool {
 	return getFileExtension(path) == "map"
 }
@@ -62,6 +62,9 @@ func TestRolePathParsing(t *testing.T) {
 		isRole bool
 	}{
 		{"roles/Authenticated.json", true},
+		{"roles/Authenticated.yaml", true},
+		{"roles/Authenticated.yml", true},
+		{"roles/Authenticated.txt", false},
 		{"code/MyRole.json", false},
 	}
 
@@ -176,3 +179,37 @@ func TestBucketSetFilePathParsing(t *testing.T) {
 		}
 	}
 }
+
+func TestPluginPathParsing(t *testing.T) {
+	tests := []struct {
+		path         string
+		expectedName string
+		isPlugin     bool
+		isMeta       bool
+		isCode       bool
+	}{
+		{"plugins/gauge.json", "gauge", true, false, false},
+		{"plugins/gauge/meta.json", "gauge", false, true, false},
+		{"plugins/gauge/code.js", "gauge", false, false, true},
+		{"plugins/gauge/config/template.html", "gauge", false, false, true},
+		{"plugins/gauge/README.md", "", false, false, false},
+		{"portals/gauge/code.js", "", false, false, false},
+	}
+
+	for _, test := range tests {
+		name, err := GetPluginNameFromPath(test.path)
+		if (err == nil) != test.isPlugin || (test.isPlugin && name != test.expectedName) {
+			t.Errorf("Unexpected plugin name %q, error %v for %q", name, err, test.path)
+		}
+
+		name, err = GetPluginMetaNameFromPath(test.path)
+		if (err == nil) != test.isMeta || (test.isMeta && name != test.expectedName) {
+			t.Errorf("Unexpected plugin meta name %q, error %v for %q", name, err, test.path)
+		}
+
+		name, _, err = GetPluginCodeFromPath(test.path)
+		if (err == nil) != test.isCode || (test.isCode && name != test.expectedName) {
+			t.Errorf("Unexpected plugin code name %q, error %v for %q", name, err, test.path)
+		}
+	}
+}
@@ -6,7 +6,7 @@ import (
 )
 
 const (
-	timerPathRegexStr = `^timers\/([^\/]+)\.json$`
+	timerPathRegexStr = `^timers\/([^\/]+)\.(?:json|ya?ml)$`
 )
 
 var (
@@ -6,7 +6,7 @@ import (
 )
 
 const (
-	triggerPathRegexStr = `^triggers\/([^\/]+)\.json$`
+	triggerPathRegexStr = `^triggers\/([^\/]+)\.(?:json|ya?ml)$`
 )
 
 var (
@@ -6,7 +6,7 @@ import (
 )
 
 const (
-	webhookPathRegexStr = `^webhooks\/([^\/]+)\.json$`
+	webhookPathRegexStr = `^webhooks\/([^\/]+)\.(?:json|ya?ml)$`
 )
 
 var (
@@ -4,19 +4,25 @@ import (
 	"fmt"
 
 	cb "github.com/clearblade/Go-SDK"
-	"github.com/clearblade/cbjson"
+	"github.com/clearblade/cblib/internal/testsuite"
 )
 
 func init() {
 	usage :=
 		`
 	Test an executable ClearBlade Asset, or send an MQTT message.
+	With -trigger, fire a local trigger with a synthetic event: the event is checked against
+	the trigger's event definition and key value pairs, then the trigger's service is called
+	with the event shaped as the platform would deliver it.
 	`
 
 	example :=
 		`
 	cb-cli test -service=MyService
 	cb-cli test -topic=mqtt_topic -payload=dat_payload
+	cb-cli test -suite -junit=report.xml			# Run every test case under tests/ and write a JUnit report
+	cb-cli test -suite -service=MyService -push=false	# Run the test cases of MyService without pushing it first
+	cb-cli test -trigger=OnDeviceState -event=event.json	# Call the service of a trigger with a synthetic event
 	`
 	systemDotJSON = map[string]interface{}{}
 	svcCode = map[string]interface{}{}
@@ -28,19 +34,19 @@ func init() {
 		example:   example,
 	}
 	myTestCommand.flags.StringVar(&ServiceName, "service", "", "name of service to test")
-	myTestCommand.flags.StringVar(&Params, "params", "", "params to service in json stringified format")
 	myTestCommand.flags.StringVar(&Topic, "topic", "", "message topic for publishing")
 	myTestCommand.flags.StringVar(&Payload, "payload", "", "The message payload")
 	myTestCommand.flags.BoolVar(&Push, "push", true, "Push the service prior to running")
+	setServiceCallFlags(&myTestCommand.flags)
+	myTestCommand.flags.BoolVar(&testSuite, "suite", false, "Run the test cases under -suite-dir (tests/<service>/<case>.json) against the platform; only the cases of -service when given")
+	myTestCommand.flags.StringVar(&testSuiteDir, "suite-dir", testsuite.DefaultDir, "Directory of the test cases")
+	myTestCommand.flags.StringVar(&testSuiteJUnit, "junit", "", "File to write a JUnit XML report of the test cases to")
+	myTestCommand.flags.StringVar(&testSuiteJSON, "json-report", "", "File to write a JSON report of the test cases to")
+	myTestCommand.flags.StringVar(&testTrigger, "trigger", "", "Fire the local trigger with this name: call its service with -event shaped as the platform would deliver it")
+	myTestCommand.flags.StringVar(&testTriggerEvent, "event", "", "JSON file holding the event to fire -trigger with, e.g. {\"topic\": \"devices/a/state\", \"body\": \"on\"}")
 	AddCommand("test", myTestCommand)
 }
 
-func printResults(res map[string]interface{}) {
-	fmt.Printf("\tSuccess: %v\n", res["success"])
-	fmt.Printf("\tResults: %v\n", res["results"])
-	fmt.Printf("\tLogs: %v\n", res["logs"])
-}
-
 func doTest(cmd *SubCommand, client *cb.DevClient, args ...string) error {
 	if len(args) != 0 {
 		return fmt.Errorf("Extra arguments passed to test command:%s\n", args)
@@ -51,21 +57,22 @@ func doTest(cmd *SubCommand, client *cb.DevClient, args ...string) error {
 	if err != nil {
 		return err
 	}
+	if testSuite {
+		return doTestSuite(systemInfo.Key, client)
+	}
+	if testTrigger != "" {
+		return doTestTrigger(systemInfo.Key, client)
+	} else if testTriggerEvent != "" {
+		return fmt.Errorf("-event provided but -trigger is missing")
+	}
 	if ServiceName != "" {
 		if Push {
-			if err = doPushService(systemInfo.Key, client); err != nil {
+			if err = doPushService(systemInfo.Key, ServiceName, client); err != nil {
 				return err
 			}
 			fmt.Printf("Sucessfully pushed service '%s'\n", ServiceName)
 		}
-		fmt.Printf("Running service '%s'... ", ServiceName)
-		resMap, err := doCallService(systemInfo.Key, client)
-		if err != nil {
-			fmt.Printf("Failed\n")
-			return err
-		}
-		fmt.Printf("Finished\n")
-		printResults(resMap)
+		return callService(systemInfo.Key, ServiceName, client, true /* turn on logging */)
 	} else if Topic != "" {
 		if err = doPublishMessage(systemInfo.Key, client); err != nil {
 			return err
@@ -77,32 +84,16 @@ func doTest(cmd *SubCommand, client *cb.DevClient, args ...string) error {
 	return nil
 }
 
-func doPushService(systemKey string, client *cb.DevClient) error {
-	svcMap, err := findService(ServiceName)
+func doPushService(systemKey, name string, client *cb.DevClient) error {
+	svcMap, err := findService(name)
 	if err != nil {
 		if err.Error() == NotExistErrorString {
-			fmt.Printf("Service '%s' does not exist locally. Not pushing...", ServiceName)
+			fmt.Printf("Service '%s' does not exist locally. Not pushing...", name)
 			return nil
 		}
 		return err
 	}
-	return updateServiceWithRunAs(systemKey, ServiceName, svcMap, client)
-}
-
-func doCallService(systemKey string, client *cb.DevClient) (map[string]interface{}, error) {
-	parsedParams := map[string]interface{}{}
-	var err error
-	if Params != "" {
-		parsedParams, _, err = cbjson.GetJSONFromString(Params)
-		if err != nil {
-			return nil, fmt.Errorf("Could not parse parameters string: %s", err.Error())
-		}
-	}
-	resMap, err := client.CallService(systemKey, ServiceName, parsedParams, true /* turn on logging */)
-	if err != nil {
-		return nil, fmt.Errorf("Call service failed: %s", err.Error())
-	}
-	return resMap, nil
+	return updateServiceWithRunAs(systemKey, name, svcMap, client)
 }
 
 func doPublishMessage(systemKey string, client *cb.DevClient) error {
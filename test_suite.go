@@ -0,0 +1,80 @@
+package cblib
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cblib/internal/testsuite"
+)
+
+var (
+	testSuite      bool
+	testSuiteDir   string
+	testSuiteJUnit string
+	testSuiteJSON  string
+)
+
+// doTestSuite runs the test cases of the system, pushing their services
+// first unless -push=false.
+func doTestSuite(systemKey string, client *cb.DevClient) error {
+	services := []string{}
+	if ServiceName != "" {
+		services = append(services, ServiceName)
+	}
+
+	cases, err := testsuite.Load(testSuiteDir, services)
+	if err != nil {
+		return err
+	}
+	if len(cases) == 0 {
+		return fmt.Errorf("No test cases found in %s", testSuiteDir)
+	}
+
+	if Push {
+		pushed := map[string]bool{}
+		for _, c := range cases {
+			if pushed[c.Service] {
+				continue
+			}
+			pushed[c.Service] = true
+			if err := doPushService(systemKey, c.Service, client); err != nil {
+				return err
+			}
+			fmt.Printf("Successfully pushed service '%s'\n", c.Service)
+		}
+	}
+
+	report := testsuite.Run(cases, func(service string, params map[string]interface{}) (map[string]interface{}, error) {
+		return client.CallService(systemKey, service, params, true /* turn on logging */)
+	})
+
+	if err := testsuite.WriteText(os.Stdout, report); err != nil {
+		return err
+	}
+	if testSuiteJUnit != "" {
+		if err := writeTestReport(testSuiteJUnit, report, testsuite.WriteJUnit); err != nil {
+			return err
+		}
+	}
+	if testSuiteJSON != "" {
+		if err := writeTestReport(testSuiteJSON, report, testsuite.WriteJSON); err != nil {
+			return err
+		}
+	}
+
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("%d of %d test cases failed", failed, len(report.Results))
+	}
+	return nil
+}
+
+func writeTestReport(file string, report *testsuite.Report, write func(io.Writer, *testsuite.Report) error) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return write(f, report)
+}
@@ -0,0 +1,55 @@
+package cblib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cblib/internal/triggersim"
+)
+
+var (
+	testTrigger      string
+	testTriggerEvent string
+)
+
+// doTestTrigger checks the event against the local trigger and calls the
+// trigger's service with the params the platform would give it, pushing the
+// service first unless -push=false.
+func doTestTrigger(systemKey string, client *cb.DevClient) error {
+	raw, err := getTrigger(testTrigger)
+	if err != nil {
+		return fmt.Errorf("Could not read trigger '%s': %s", testTrigger, err.Error())
+	}
+	trigger, err := triggersim.FromMap(raw)
+	if err != nil {
+		return err
+	}
+
+	event := map[string]interface{}{}
+	if testTriggerEvent != "" {
+		data, err := os.ReadFile(testTriggerEvent)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("Could not parse %s: %s", testTriggerEvent, err.Error())
+		}
+	}
+
+	params, err := trigger.Params(event)
+	if err != nil {
+		return err
+	}
+
+	if Push {
+		if err := doPushService(systemKey, trigger.Service, client); err != nil {
+			return err
+		}
+		fmt.Printf("Successfully pushed service '%s'\n", trigger.Service)
+	}
+
+	fmt.Printf("Firing trigger '%s' (%s) on service '%s'\n", trigger.Name, trigger.Definition.ID(), trigger.Service)
+	return callServiceWithParams(systemKey, trigger.Service, params, client, true /* turn on logging */)
+}
@@ -0,0 +1,201 @@
+package cblib
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cblib/fs"
+	"github.com/clearblade/cblib/internal/build"
+	"github.com/clearblade/cblib/internal/watch"
+	"github.com/clearblade/cblib/models/systemUpload"
+	"github.com/clearblade/cblib/types"
+)
+
+var watchDebounce time.Duration
+
+func init() {
+
+	usage :=
+		`
+	Watch the code, portals, plugins, adapters and src directories and push the assets whose files change.
+	Changes are pushed once the files settle for -debounce. The services kept under src/ are built
+	first, like push does, and pushed once built. The dry run of a push of services and libraries
+	only is approved automatically; other assets ask first unless -auto-approve is given.
+	Build and push errors are printed and watching goes on. Press Ctrl-C to stop.
+	`
+
+	example :=
+		`
+	cb-cli watch					# Push services, libraries, portals, plugins and adapters as they're saved
+	cb-cli watch -debounce=2s			# Wait for 2 seconds without changes before pushing
+	cb-cli watch -auto-approve			# Never ask before pushing
+	`
+
+	watchCommand := &SubCommand{
+		name:      "watch",
+		usage:     usage,
+		needsAuth: true,
+		run:       doWatch,
+		example:   example,
+	}
+
+	watchCommand.flags.DurationVar(&watchDebounce, "debounce", 500*time.Millisecond, "Time without changes to wait for before pushing")
+	watchCommand.flags.BoolVar(&AutoApprove, "auto-approve", false, "automatically answer yes to all prompts")
+
+	AddCommand("watch", watchCommand)
+}
+
+func doWatch(cmd *SubCommand, client *cb.DevClient, args ...string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("watch takes no arguments; only options")
+	}
+
+	SetRootDir(".")
+	systemInfo, err := getSysMeta()
+	if err != nil {
+		return err
+	}
+
+	version, err := systemUpload.GetSystemUploadVersion(systemInfo, client)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := watch.New(".", watch.Dirs)
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	stop := make(chan struct{})
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	defer signal.Stop(interrupted)
+	go func() {
+		<-interrupted
+		close(stop)
+	}()
+
+	fmt.Printf("Watching %s for changes. Press Ctrl-C to stop\n", strings.Join(watch.Dirs, ", "))
+	watcher.Run(watchDebounce, stop, func(assets []watch.Asset) {
+		client, err = checkIfTokenHasExpired(client, systemInfo.Key)
+		if err != nil {
+			fmt.Printf("Re-auth failed: %s\n", err)
+			return
+		}
+		if err := buildWatchedSources(assets); err != nil {
+			fmt.Printf("Build failed: %s\n", err)
+			return
+		}
+		if err := pushWatchedAssets(systemInfo, client, version, assets); err != nil {
+			fmt.Printf("Push failed: %s\n", err)
+		} else {
+			fmt.Println("Watching for changes")
+		}
+	}, func(err error) {
+		fmt.Printf("Watch error: %s\n", err)
+	})
+	return nil
+}
+
+// buildWatchedSources builds the changed services kept under src/, or every
+// one of them when the sources they share changed. Services whose sources
+// didn't change aren't rebuilt; the files of those rebuilt are changes of the
+// next batch, which pushes them.
+func buildWatchedSources(assets []watch.Asset) error {
+	services := []string{}
+	for _, asset := range assets {
+		switch {
+		case asset.Kind == watch.KindSource && asset.Name == build.SrcDir:
+			return buildSources(build.Options{})
+		case asset.Kind == watch.KindSource || asset.Kind == watch.KindService:
+			if build.HasServiceSources(rootDir, asset.Name) {
+				services = append(services, asset.Name)
+			}
+		}
+	}
+	if len(services) == 0 {
+		return nil
+	}
+	return buildSources(build.Options{Services: services})
+}
+
+// pushWatchedAssets pushes the changed assets: services and libraries in a
+// single push approved automatically, every other asset in a push of its
+// own. Platforms without system uploads get a legacy push of each asset.
+func pushWatchedAssets(systemInfo *types.System_meta, client *cb.DevClient, version int, assets []watch.Asset) error {
+	names := []string{}
+	for _, asset := range assets {
+		names = append(names, asset.String())
+	}
+	fmt.Printf("\n%s changed: %s\n", time.Now().Format("15:04:05"), strings.Join(names, ", "))
+
+	if version < 5 {
+		for _, asset := range assets {
+			if asset.Kind == watch.KindSource {
+				continue
+			}
+			if err := pushWatchedAssetLegacy(systemInfo, client, asset); err != nil {
+				return fmt.Errorf("%s: %s", asset, err)
+			}
+		}
+		return nil
+	}
+
+	code := fs.NewZipOptions(&mapper{})
+	others := []watch.Asset{}
+	for _, asset := range assets {
+		switch asset.Kind {
+		case watch.KindService:
+			code.ServiceNames = append(code.ServiceNames, asset.Name)
+		case watch.KindLibrary:
+			code.LibraryNames = append(code.LibraryNames, asset.Name)
+		case watch.KindSource:
+			// pushed as services once built
+		default:
+			others = append(others, asset)
+		}
+	}
+
+	if len(code.ServiceNames) > 0 || len(code.LibraryNames) > 0 {
+		if err := uploadSystemZip(systemInfo, client, code, true); err != nil {
+			return err
+		}
+	}
+
+	for _, asset := range others {
+		opts := fs.NewZipOptions(&mapper{})
+		switch asset.Kind {
+		case watch.KindPortal:
+			opts.PortalName = asset.Name
+		case watch.KindPlugin:
+			opts.PluginName = asset.Name
+		case watch.KindAdaptor:
+			opts.AdaptorName = asset.Name
+		}
+		if err := pushSystemZip(systemInfo, client, opts); err != nil {
+			return fmt.Errorf("%s: %s", asset, err)
+		}
+	}
+	return nil
+}
+
+func pushWatchedAssetLegacy(systemInfo *types.System_meta, client *cb.DevClient, asset watch.Asset) error {
+	switch asset.Kind {
+	case watch.KindService:
+		return pushOneService(systemInfo, client, asset.Name)
+	case watch.KindLibrary:
+		return pushOneLibrary(systemInfo, client, asset.Name)
+	case watch.KindPortal:
+		return pushOnePortal(systemInfo, client, asset.Name)
+	case watch.KindPlugin:
+		return pushOnePlugin(systemInfo, client, asset.Name)
+	case watch.KindAdaptor:
+		return pushOneAdaptor(systemInfo, client, asset.Name)
+	}
+	return fmt.Errorf("unknown asset kind '%s'", asset.Kind)
+}
@@ -0,0 +1,123 @@
+package cblib
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	cb "github.com/clearblade/Go-SDK"
+	"github.com/clearblade/cblib/internal/workspace"
+)
+
+var (
+	workspaceSystems string
+)
+
+// workspaceUnsupportedCommands can't run per member, either because they
+// manage the workspace itself or because they read os.Args directly.
+var workspaceUnsupportedCommands = map[string]bool{
+	"workspace": true,
+	"remote":    true,
+}
+
+func init() {
+
+	usage :=
+		`
+	Run a command across the systems of a multi-system workspace. The workspace is
+	described by a ` + workspace.FileName + ` file in the current directory, listing
+	each system directory. Every system uses its own remotes.
+	`
+
+	example :=
+		`
+	cb-cli workspace pull -all						# Pull all assets of every system in the workspace
+	cb-cli workspace -systems=core,edge push -all	# Push all assets of the core and edge systems only
+	`
+
+	workspaceCommand := &SubCommand{
+		name:      "workspace",
+		usage:     usage,
+		needsAuth: false,
+		run:       doWorkspace,
+		example:   example,
+	}
+
+	workspaceCommand.flags.StringVar(&workspaceSystems, "systems", "", "Comma separated names of the workspace systems to run the command against. Defaults to all")
+
+	AddCommand("workspace", workspaceCommand)
+}
+
+func doWorkspace(cmd *SubCommand, client *cb.DevClient, args ...string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("workspace requires a command to run, e.g. 'cb-cli workspace push -all'")
+	}
+
+	commandName := strings.ToLower(args[0])
+	if workspaceUnsupportedCommands[commandName] {
+		return fmt.Errorf("command '%s' can't be run across a workspace", commandName)
+	}
+
+	subCommand, err := GetCommand(commandName)
+	if err != nil {
+		return err
+	}
+
+	ws, err := workspace.LoadFromDir(".")
+	if err != nil {
+		return err
+	}
+
+	members, err := ws.Select(splitWorkspaceSystems(workspaceSystems))
+	if err != nil {
+		return err
+	}
+
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	report := workspace.Run(commandName, members, func(m *workspace.Member) error {
+		return runInWorkspaceMember(ws, m, workingDir, subCommand, args[1:])
+	})
+
+	fmt.Printf("\n%s", report)
+	return report.Err()
+}
+
+// runInWorkspaceMember runs the given subcommand from inside the member
+// directory, since most of the CLI resolves paths relative to the current
+// directory.
+func runInWorkspaceMember(ws *workspace.Workspace, m *workspace.Member, workingDir string, subCommand *SubCommand, args []string) error {
+	fmt.Printf("\n==> %s (%s)\n", m.Name, m.Dir)
+
+	if subCommand.name == "push" {
+		vendored, err := ws.VendorSharedLibraries(m)
+		if err != nil {
+			return err
+		}
+		if len(vendored) > 0 {
+			logInfo(fmt.Sprintf("Vendored shared libraries: %s", strings.Join(vendored, ", ")))
+		}
+	}
+
+	err := os.Chdir(ws.MemberDir(m))
+	if err != nil {
+		return err
+	}
+	defer os.Chdir(workingDir)
+
+	return subCommand.Execute(args)
+}
+
+func splitWorkspaceSystems(systems string) []string {
+	names := make([]string, 0)
+	for _, name := range strings.Split(systems, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}